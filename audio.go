@@ -25,76 +25,189 @@ type InterleavedWriter interface {
 	WriteFloat64Interleaved(p [][]float64) (n int, err error)
 }
 
+// maxEmptyReads is the number of reads which return no data and no error
+// before frameReader gives up, as bufio does.
+const maxEmptyReads = 100
+
+// frameReader assembles complete frames from r.
+// The bytes of an incomplete frame are carried over to the next read,
+// so the underlying reader may return any number of bytes.
+type frameReader struct {
+	r   io.Reader
+	buf []byte
+	n   int // number of bytes in buf
+	err error
+}
+
+// fill reads until buf holds at least one complete frame of size bytes,
+// and it returns the number of complete frames up to frames.
+// It returns an error only if there is no complete frame.
+func (f *frameReader) fill(frames, size int) (int, error) {
+	if frames == 0 {
+		return 0, nil
+	}
+	ln := frames * size
+	if ln > len(f.buf) {
+		buf := make([]byte, ln)
+		copy(buf, f.buf[:f.n])
+		f.buf = buf
+	}
+	for empty := 0; f.n < size && f.err == nil; {
+		var rn int
+		rn, f.err = f.r.Read(f.buf[f.n:ln])
+		f.n += rn
+		if rn > 0 {
+			empty = 0
+		} else if empty++; empty >= maxEmptyReads {
+			f.err = io.ErrNoProgress
+		}
+	}
+	if n := f.n / size; n > 0 {
+		if n > frames {
+			n = frames
+		}
+		return n, nil
+	}
+	if f.err == io.EOF && f.n > 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return 0, f.err
+}
+
+// discard drops the first n bytes of buf, the rest is moved to the front.
+func (f *frameReader) discard(n int) {
+	f.n = copy(f.buf, f.buf[n:f.n])
+}
+
 type reader struct {
 	conv converter.Converter
-	r    io.Reader
-	buf  []byte
+	fr   frameReader
 }
 
+// NewReader returns a Reader which decodes the samples of r by conv.
+// A read returns only complete samples, even if r returns some bytes of a sample.
 func NewReader(conv converter.Converter, r io.Reader) Reader {
 	return &reader{
 		conv: conv,
-		r:    r,
+		fr:   frameReader{r: r},
 	}
 }
 
 func (r *reader) ReadFloat32(p []float32) (n int, err error) {
-	ln := len(p)
-	if ln > len(r.buf) {
-		r.buf = make([]byte, ln)
+	size := r.conv.SampleSize()
+	if n, err = r.fr.fill(len(p), size); n == 0 {
+		return 0, err
 	}
-
-	n, err = r.r.Read(r.buf[:ln])
-	r.conv.ToFloat32(r.buf[:n], p)
-	return
+	r.conv.ToFloat32(r.fr.buf[:n*size], p)
+	r.fr.discard(n * size)
+	return n, nil
 }
 
 func (r *reader) ReadFloat64(p []float64) (n int, err error) {
-	ln := len(p)
-	if ln > len(r.buf) {
-		r.buf = make([]byte, ln)
+	size := r.conv.SampleSize()
+	if n, err = r.fr.fill(len(p), size); n == 0 {
+		return 0, err
 	}
-
-	n, err = r.r.Read(r.buf[:ln])
-	r.conv.ToFloat64(r.buf[:n], p)
-	return
+	r.conv.ToFloat64(r.fr.buf[:n*size], p)
+	r.fr.discard(n * size)
+	return n, nil
 }
 
 type interleavedReader struct {
 	conv converter.InterleavedConverter
-	r    io.Reader
-	buf  []byte
+	fr   frameReader
 }
 
+// NewInterleavedReader returns an InterleavedReader which decodes the frames of r by conv.
+// A read returns only complete frames, even if r returns some bytes of a frame,
+// so every read must have the same number of channels.
 func NewInterleavedReader(conv converter.InterleavedConverter, r io.Reader) InterleavedReader {
 	return &interleavedReader{
 		conv: conv,
-		r:    r,
+		fr:   frameReader{r: r},
 	}
 }
 
 func (r *interleavedReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
-	ln := len(p) * len(p[0]) * r.conv.SampleSize()
-	if ln > len(r.buf) {
-		r.buf = make([]byte, ln)
+	size := len(p) * r.conv.SampleSize()
+	if n, err = r.fr.fill(len(p[0]), size); n == 0 {
+		return 0, err
 	}
-
-	n, err = r.r.Read(r.buf[:ln])
-	r.conv.ToFloat32Interleaved(r.buf[:n], p)
-	n /= len(p) * r.conv.SampleSize()
-	return
+	r.conv.ToFloat32Interleaved(r.fr.buf[:n*size], p)
+	r.fr.discard(n * size)
+	return n, nil
 }
 
 func (r *interleavedReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
-	ln := len(p) * len(p[0]) * r.conv.SampleSize()
-	if ln > len(r.buf) {
-		r.buf = make([]byte, ln)
+	size := len(p) * r.conv.SampleSize()
+	if n, err = r.fr.fill(len(p[0]), size); n == 0 {
+		return 0, err
 	}
+	r.conv.ToFloat64Interleaved(r.fr.buf[:n*size], p)
+	r.fr.discard(n * size)
+	return n, nil
+}
 
-	n, err = r.r.Read(r.buf[:ln])
-	r.conv.ToFloat64Interleaved(r.buf[:n], p)
-	n /= len(p) * r.conv.SampleSize()
-	return
+// ReadFullFloat32 reads exactly len(p) samples from r.
+// The error is io.EOF only if no samples were read,
+// and io.ErrUnexpectedEOF if EOF happens after reading some but not all samples.
+func ReadFullFloat32(r Reader, p []float32) (n int, err error) {
+	for n < len(p) && err == nil {
+		var rn int
+		rn, err = r.ReadFloat32(p[n:])
+		n += rn
+	}
+	return full(n, len(p), err)
+}
+
+// ReadFullFloat64 reads exactly len(p) samples from r, it works as ReadFullFloat32.
+func ReadFullFloat64(r Reader, p []float64) (n int, err error) {
+	for n < len(p) && err == nil {
+		var rn int
+		rn, err = r.ReadFloat64(p[n:])
+		n += rn
+	}
+	return full(n, len(p), err)
+}
+
+// ReadFullFloat32Interleaved reads exactly len(p[0]) frames from r, it works as ReadFullFloat32.
+func ReadFullFloat32Interleaved(r InterleavedReader, p [][]float32) (n int, err error) {
+	ln := len(p[0])
+	tmp := make([][]float32, len(p))
+	for n < ln && err == nil {
+		for ch := range tmp {
+			tmp[ch] = p[ch][n:]
+		}
+		var rn int
+		rn, err = r.ReadFloat32Interleaved(tmp)
+		n += rn
+	}
+	return full(n, ln, err)
+}
+
+// ReadFullFloat64Interleaved reads exactly len(p[0]) frames from r, it works as ReadFullFloat32.
+func ReadFullFloat64Interleaved(r InterleavedReader, p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	tmp := make([][]float64, len(p))
+	for n < ln && err == nil {
+		for ch := range tmp {
+			tmp[ch] = p[ch][n:]
+		}
+		var rn int
+		rn, err = r.ReadFloat64Interleaved(tmp)
+		n += rn
+	}
+	return full(n, ln, err)
+}
+
+func full(n, ln int, err error) (int, error) {
+	if n >= ln {
+		return n, nil
+	}
+	if n > 0 && err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
 }
 
 type writer struct {
@@ -108,24 +221,26 @@ func NewWriter(conv converter.Converter, w io.Writer) Writer {
 }
 
 func (w *writer) WriteFloat32(p []float32) (n int, err error) {
-	ln := len(p)
+	ln := len(p) * w.conv.SampleSize()
 	if ln > len(w.buf) {
 		w.buf = make([]byte, ln)
 	}
 
 	w.conv.FromFloat32(p, w.buf[:ln])
-	n, err = w.w.Write(w.buf[:ln*w.conv.SampleSize()])
+	n, err = w.w.Write(w.buf[:ln])
+	n /= w.conv.SampleSize()
 	return
 }
 
 func (w *writer) WriteFloat64(p []float64) (n int, err error) {
-	ln := len(p)
+	ln := len(p) * w.conv.SampleSize()
 	if ln > len(w.buf) {
 		w.buf = make([]byte, ln)
 	}
 
 	w.conv.FromFloat64(p, w.buf[:ln])
-	n, err = w.w.Write(w.buf[:ln*w.conv.SampleSize()])
+	n, err = w.w.Write(w.buf[:ln])
+	n /= w.conv.SampleSize()
 	return
 }
 
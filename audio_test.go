@@ -0,0 +1,232 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/oov/audio/converter"
+)
+
+// chunkReader returns the bytes of r in chunks whose sizes are taken from sizes in turn.
+type chunkReader struct {
+	r     io.Reader
+	sizes []byte
+	i     int
+}
+
+// Without sizes, it returns a byte at a time.
+func (c *chunkReader) Read(p []byte) (int, error) {
+	n := 1
+	if len(c.sizes) > 0 {
+		n = int(c.sizes[c.i%len(c.sizes)])
+		c.i++
+	}
+	if n > len(p) {
+		n = len(p)
+	}
+	return c.r.Read(p[:n])
+}
+
+func encode(conv converter.InterleavedConverter, channels, frames int) []byte {
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, frames)
+		for i := range p[ch] {
+			p[ch][i] = float64((i*7+ch*3)%200-100) / 128
+		}
+	}
+	b := make([]byte, channels*frames*conv.SampleSize())
+	conv.FromFloat64Interleaved(p, b)
+	return b
+}
+
+// decode reads all frames from r by blocks of block frames.
+func decode(t *testing.T, r InterleavedReader, channels, block int) ([][]float64, error) {
+	out := make([][]float64, channels)
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, block)
+	}
+	for {
+		n, err := r.ReadFloat64Interleaved(p)
+		if n > 0 && err != nil {
+			t.Fatalf("%d frames are returned with %v", n, err)
+		}
+		for ch := range out {
+			out[ch] = append(out[ch], p[ch][:n]...)
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
+
+func equalFrames(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for ch := range a {
+		if len(a[ch]) != len(b[ch]) {
+			return false
+		}
+		for i := range a[ch] {
+			if a[ch][i] != b[ch][i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestInterleavedReader(t *testing.T) {
+	const channels, frames = 3, 1000
+	data := encode(converter.Int24, channels, frames)
+	want, err := decode(t, NewInterleavedReader(converter.Int24, bytes.NewReader(data)), channels, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readers := []func(io.Reader) io.Reader{
+		iotest.OneByteReader,
+		iotest.HalfReader,
+		iotest.DataErrReader,
+		func(r io.Reader) io.Reader { return &chunkReader{r: r, sizes: []byte{4, 7, 1, 13, 2}} },
+	}
+	for i, newReader := range readers {
+		for _, block := range []int{1, 5, 64, 2000} {
+			r := NewInterleavedReader(converter.Int24, newReader(bytes.NewReader(data)))
+			got, err := decode(t, r, channels, block)
+			if err != nil {
+				t.Fatalf("readers[%d] block %d: %v", i, block, err)
+			}
+			if !equalFrames(want, got) {
+				t.Errorf("readers[%d] block %d: frames differ", i, block)
+			}
+		}
+	}
+
+	// a reader which never makes progress
+	r := NewInterleavedReader(converter.Int24, &chunkReader{r: bytes.NewReader(data), sizes: []byte{0}})
+	if _, err := decode(t, r, channels, 64); err != io.ErrNoProgress {
+		t.Errorf("want %v got %v", io.ErrNoProgress, err)
+	}
+
+	// an incomplete frame at the end
+	r = NewInterleavedReader(converter.Int24, iotest.OneByteReader(bytes.NewReader(data[:len(data)-1])))
+	got, err := decode(t, r, channels, 64)
+	if err != io.ErrUnexpectedEOF || len(got[0]) != frames-1 {
+		t.Errorf("want %d frames and %v got %d frames and %v", frames-1, io.ErrUnexpectedEOF, len(got[0]), err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := encode(converter.Int16, 1, 100)
+	want := make([]float32, 100)
+	converter.Int16.ToFloat32(data, want)
+
+	r := NewReader(converter.Int16, iotest.OneByteReader(bytes.NewReader(data)))
+	got := make([]float32, 0, 100)
+	p := make([]float32, 7)
+	for {
+		n, err := r.ReadFloat32(p)
+		got = append(got, p[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil || n == 0 {
+			t.Fatalf("unexpected read %d %v", n, err)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d samples got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d want %v got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	data := encode(converter.Int16, 2, 10)
+	r := NewInterleavedReader(converter.Int16, iotest.OneByteReader(bytes.NewReader(data)))
+	p := [][]float32{make([]float32, 6), make([]float32, 6)}
+	if n, err := ReadFullFloat32Interleaved(r, p); n != 6 || err != nil {
+		t.Fatalf("want 6 <nil> got %d %v", n, err)
+	}
+	if n, err := ReadFullFloat32Interleaved(r, p); n != 4 || err != io.ErrUnexpectedEOF {
+		t.Fatalf("want 4 %v got %d %v", io.ErrUnexpectedEOF, n, err)
+	}
+	if n, err := ReadFullFloat32Interleaved(r, p); n != 0 || err != io.EOF {
+		t.Fatalf("want 0 EOF got %d %v", n, err)
+	}
+
+	sr := NewReader(converter.Int16, iotest.HalfReader(bytes.NewReader(data)))
+	q := make([]float64, 20)
+	if n, err := ReadFullFloat64(sr, q); n != 20 || err != nil {
+		t.Fatalf("want 20 <nil> got %d %v", n, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(converter.Int24, &b)
+	if n, err := w.WriteFloat64([]float64{0, 0.5, -0.5}); n != 3 || err != nil {
+		t.Fatalf("want 3 <nil> got %d %v", n, err)
+	}
+	if n, err := w.WriteFloat32([]float32{0.25}); n != 1 || err != nil {
+		t.Fatalf("want 1 <nil> got %d %v", n, err)
+	}
+	p := make([]float64, 5)
+	if n, err := ReadFullFloat64(NewReader(converter.Int24, &b), p); n != 4 || err != io.ErrUnexpectedEOF || math.Abs(p[1]-0.5) > 1e-6 || math.Abs(p[3]-0.25) > 1e-6 {
+		t.Errorf("unexpected samples %d %v %v", n, err, p)
+	}
+}
+
+func FuzzInterleavedReader(f *testing.F) {
+	f.Add(encode(converter.Int16, 2, 16), []byte{3, 1, 5}, uint8(2), uint8(3))
+	f.Add(encode(converter.Int24, 3, 9), []byte{0, 7}, uint8(3), uint8(1))
+	f.Add([]byte{1, 2, 3}, []byte{}, uint8(1), uint8(4))
+	f.Fuzz(func(t *testing.T, data, sizes []byte, channels, block uint8) {
+		chs, blk := int(channels%8)+1, int(block%32)+1
+		if len(sizes) > 64 {
+			sizes = sizes[:64]
+		}
+		if bytes.Count(sizes, []byte{0}) == len(sizes) {
+			// empty reads are allowed but a reader must make progress
+			sizes = nil
+		}
+		conv := converter.Int24
+		frameSize := chs * conv.SampleSize()
+		frames := len(data) / frameSize
+
+		want := make([][]float64, chs)
+		for ch := range want {
+			want[ch] = make([]float64, frames)
+		}
+		conv.ToFloat64Interleaved(data[:frames*frameSize], want)
+
+		for _, r := range []io.Reader{
+			iotest.OneByteReader(bytes.NewReader(data)),
+			&chunkReader{r: bytes.NewReader(data), sizes: sizes},
+		} {
+			got, err := decode(t, NewInterleavedReader(conv, r), chs, blk)
+			if len(data)%frameSize != 0 {
+				if err != io.ErrUnexpectedEOF {
+					t.Fatalf("want %v got %v", io.ErrUnexpectedEOF, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !equalFrames(want, got) {
+				t.Fatalf("frames differ")
+			}
+		}
+	})
+}
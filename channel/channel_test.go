@@ -0,0 +1,221 @@
+package channel
+
+import (
+	"io"
+	"math"
+	"testing"
+
+	"github.com/oov/audio/wave"
+)
+
+const h = math.Sqrt2 / 2
+
+func equal(t *testing.T, name string, m, expected Matrix) {
+	if len(m) != len(expected) {
+		t.Errorf("%s: outputs: %d expected: %d", name, len(m), len(expected))
+		return
+	}
+	for i := range m {
+		for j := range m[i] {
+			if math.Abs(m[i][j]-expected[i][j]) > 1e-12 {
+				t.Errorf("%s: %v expected: %v", name, m, expected)
+				return
+			}
+		}
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	mono := wave.DefaultChannelMask(1)
+	stereo := wave.DefaultChannelMask(2)
+	surround51 := wave.DefaultChannelMask(6)
+	surround71 := wave.DefaultChannelMask(8)
+
+	tests := []struct {
+		name     string
+		in, out  wave.WFESpeaker
+		opts     Options
+		expected Matrix
+	}{
+		{
+			"5.1 to stereo", surround51, stereo, Options{},
+			// FL FR FC LFE BL BR
+			Matrix{
+				{1, 0, h, 0, h, 0},
+				{0, 1, h, 0, 0, h},
+			},
+		},
+		{
+			"5.1 to stereo with levels", surround51, stereo, Options{CenterGain: 0.5, SurroundGain: 0.25, LFEGain: 0.1},
+			Matrix{
+				{1, 0, 0.5, 0.1, 0.25, 0},
+				{0, 1, 0.5, 0.1, 0, 0.25},
+			},
+		},
+		{
+			"5.1 to mono", surround51, mono, Options{},
+			Matrix{
+				{h, h, 1, 0, 0.5, 0.5},
+			},
+		},
+		{
+			"mono to stereo", mono, stereo, Options{},
+			Matrix{
+				{h},
+				{h},
+			},
+		},
+		{
+			"mono to 5.1", mono, surround51, Options{},
+			Matrix{{0}, {0}, {1}, {0}, {0}, {0}},
+		},
+		{
+			"stereo to 5.1", stereo, surround51, Options{},
+			Matrix{{1, 0}, {0, 1}, {0, 0}, {0, 0}, {0, 0}, {0, 0}},
+		},
+		{
+			"7.1 to 5.1", surround71, surround51, Options{},
+			// FL FR FC LFE BL BR SL SR
+			Matrix{
+				{1, 0, 0, 0, 0, 0, 0, 0},
+				{0, 1, 0, 0, 0, 0, 0, 0},
+				{0, 0, 1, 0, 0, 0, 0, 0},
+				{0, 0, 0, 1, 0, 0, 0, 0},
+				{0, 0, 0, 0, 1, 0, 1, 0},
+				{0, 0, 0, 0, 0, 1, 0, 1},
+			},
+		},
+		{
+			"6.1 to stereo", wave.DefaultChannelMask(7), stereo, Options{},
+			// FL FR FC LFE BL BR BC
+			Matrix{
+				{1, 0, h, 0, h, 0, 0.5},
+				{0, 1, h, 0, 0, h, 0.5},
+			},
+		},
+		{
+			"5.1 to stereo normalized", surround51, stereo, Options{Normalize: true},
+			Matrix{
+				{1 / (1 + 2*h), 0, h / (1 + 2*h), 0, h / (1 + 2*h), 0},
+				{0, 1 / (1 + 2*h), h / (1 + 2*h), 0, 0, h / (1 + 2*h)},
+			},
+		},
+	}
+	for _, tc := range tests {
+		m, err := NewMatrix(tc.in, tc.out, tc.opts)
+		if err != nil {
+			t.Fatal(tc.name, err)
+		}
+		equal(t, tc.name, m, tc.expected)
+	}
+
+	if _, err := NewMatrix(0, stereo, Options{}); err == nil {
+		t.Error("empty mask must be rejected")
+	}
+}
+
+type constReader struct {
+	values []float64
+	frames int
+}
+
+func (r *constReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *constReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	for n < len(p[0]) && r.frames > 0 {
+		for ch := range p {
+			p[ch][n] = r.values[ch]
+		}
+		n++
+		r.frames--
+	}
+	if r.frames == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+func TestReader(t *testing.T) {
+	m := Matrix{{0.5, 0.5}}
+	r := NewReader(&constReader{values: []float64{1, 0.5}, frames: 10}, m)
+	p := [][]float64{make([]float64, 8)}
+	n, err := r.ReadFloat64Interleaved(p)
+	if n != 8 || err != nil {
+		t.Fatal(n, err)
+	}
+	if p[0][7] != 0.75 {
+		t.Error("unexpected value:", p[0][7])
+	}
+	if n, err = r.ReadFloat64Interleaved(p); n != 2 || err != io.EOF {
+		t.Fatal(n, err)
+	}
+
+	dst := [][]float32{make([]float32, 2), make([]float32, 2)}
+	NewIdentity(1, 2).ProcessFloat32(dst, [][]float32{{1, 2}})
+	if dst[0][1] != 2 || dst[1][1] != 0 {
+		t.Error("identity:", dst)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	s51 := wave.DefaultChannelMask(6)
+	s71 := wave.DefaultChannelMask(8)
+	tests := []struct {
+		name     string
+		mask     wave.WFESpeaker
+		order    *Order
+		expected []wave.WFESpeaker
+	}{
+		{"wav 5.1", s51, WAV, []wave.WFESpeaker{fl, fr, fc, lfe, bl, br}},
+		{"film 5.1", s51, Film, []wave.WFESpeaker{fl, fc, fr, bl, br, lfe}},
+		{"vorbis 5.1", s51, Vorbis, []wave.WFESpeaker{fl, fc, fr, bl, br, lfe}},
+		{"vorbis 7.1", s71, Vorbis, []wave.WFESpeaker{fl, fc, fr, sl, sr, bl, br, lfe}},
+		{"aac 5.1", s51, AAC, []wave.WFESpeaker{fc, fl, fr, bl, br, lfe}},
+		{"custom", s51, NewOrder(lfe), []wave.WFESpeaker{lfe, fl, fr, fc, bl, br}},
+	}
+	for _, tc := range tests {
+		s := tc.order.Speakers(tc.mask)
+		if len(s) != len(tc.expected) {
+			t.Fatal(tc.name, s)
+		}
+		for i := range s {
+			if s[i] != tc.expected[i] {
+				t.Error(tc.name, s, "expected:", tc.expected)
+				break
+			}
+		}
+	}
+
+	m := NewMap(s51, WAV, Film)
+	for i, v := range []int{0, 2, 1, 4, 5, 3} {
+		if m[i] != v {
+			t.Fatal("map:", m)
+		}
+	}
+}
+
+func TestReorderReader(t *testing.T) {
+	src := &constReader{values: []float64{0, 1, 2, 3, 4, 5}, frames: 4}
+	r, err := NewReorderReader(src, 6, wave.DefaultChannelMask(6), WAV, Film)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([][]float64, 6)
+	for ch := range p {
+		p[ch] = make([]float64, 4)
+	}
+	if n, _ := r.ReadFloat64Interleaved(p); n != 4 {
+		t.Fatal("read:", n)
+	}
+	for ch, v := range []float64{0, 2, 1, 4, 5, 3} {
+		if p[ch][3] != v {
+			t.Error("channel:", ch, "value:", p[ch][3], "expected:", v)
+		}
+	}
+
+	if _, err = NewReorderReader(src, 5, wave.DefaultChannelMask(6), WAV, Film); err == nil {
+		t.Error("mismatched channels must be rejected")
+	}
+}
@@ -0,0 +1,268 @@
+// Package channel implements channel layout conversion.
+//
+// A Matrix mixes every input channel into every output channel with a gain,
+// which covers downmixing, upmixing and custom routing. Matrices between
+// speaker layouts are built from the ChannelMask of WAVE_FORMAT_EXTENSIBLE,
+// where the channels are ordered by the bits of the mask.
+package channel
+
+import (
+	"errors"
+	"math"
+
+	"github.com/oov/audio/wave"
+)
+
+// Matrix holds the gains of a channel mixer.
+// m[out][in] is the gain from input channel in to output channel out.
+type Matrix [][]float64
+
+// Options configures NewMatrix.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	CenterGain   float64 // gain of the center channel mixed into the left and right channels (default -3 dB)
+	SurroundGain float64 // gain of the surround channels mixed into the front channels (default -3 dB)
+	LFEGain      float64 // gain of the LFE channel mixed into the other channels when the output has no LFE (default 0, LFE is dropped)
+	Normalize    bool    // scale the matrix so that no output channel can exceed full scale
+}
+
+var errEmptyMask = errors.New("channel: empty channel mask")
+
+// gain is a gain to a speaker.
+type gain struct {
+	speaker wave.WFESpeaker
+	gain    float64
+}
+
+const (
+	fl  = wave.SPEAKER_FRONT_LEFT
+	fr  = wave.SPEAKER_FRONT_RIGHT
+	fc  = wave.SPEAKER_FRONT_CENTER
+	lfe = wave.SPEAKER_LOW_FREQUENCY
+	bl  = wave.SPEAKER_BACK_LEFT
+	br  = wave.SPEAKER_BACK_RIGHT
+	flc = wave.SPEAKER_FRONT_LEFT_OF_CENTER
+	frc = wave.SPEAKER_FRONT_RIGHT_OF_CENTER
+	bc  = wave.SPEAKER_BACK_CENTER
+	sl  = wave.SPEAKER_SIDE_LEFT
+	sr  = wave.SPEAKER_SIDE_RIGHT
+	tc  = wave.SPEAKER_TOP_CENTER
+	tfl = wave.SPEAKER_TOP_FRONT_LEFT
+	tfc = wave.SPEAKER_TOP_FRONT_CENTER
+	tfr = wave.SPEAKER_TOP_FRONT_RIGHT
+	tbl = wave.SPEAKER_TOP_BACK_LEFT
+	tbc = wave.SPEAKER_TOP_BACK_CENTER
+	tbr = wave.SPEAKER_TOP_BACK_RIGHT
+)
+
+// fallbacks returns the alternatives for a speaker which is missing in the output, in order of preference.
+func (o *Options) fallbacks(s wave.WFESpeaker) [][]gain {
+	const h = math.Sqrt2 / 2
+	c, sur := o.CenterGain, o.SurroundGain
+	switch s {
+	case fl:
+		return [][]gain{{{flc, 1}}, {{fc, h}}}
+	case fr:
+		return [][]gain{{{frc, 1}}, {{fc, h}}}
+	case fc:
+		return [][]gain{{{flc, h}, {frc, h}}, {{fl, c}, {fr, c}}}
+	case lfe:
+		if o.LFEGain == 0 {
+			return nil
+		}
+		return [][]gain{{{fc, o.LFEGain}}, {{fl, o.LFEGain}, {fr, o.LFEGain}}}
+	case bl:
+		return [][]gain{{{sl, 1}}, {{fl, sur}}}
+	case br:
+		return [][]gain{{{sr, 1}}, {{fr, sur}}}
+	case sl:
+		return [][]gain{{{bl, 1}}, {{fl, sur}}}
+	case sr:
+		return [][]gain{{{br, 1}}, {{fr, sur}}}
+	case flc:
+		return [][]gain{{{fl, h}, {fc, h}}, {{fl, 1}}}
+	case frc:
+		return [][]gain{{{fr, h}, {fc, h}}, {{fr, 1}}}
+	case bc:
+		return [][]gain{{{bl, h}, {br, h}}, {{sl, h}, {sr, h}}, {{fl, sur * h}, {fr, sur * h}}}
+	case tc:
+		return [][]gain{{{fl, h}, {fr, h}}}
+	case tfl:
+		return [][]gain{{{fl, 1}}}
+	case tfc:
+		return [][]gain{{{fc, 1}}}
+	case tfr:
+		return [][]gain{{{fr, 1}}}
+	case tbl:
+		return [][]gain{{{bl, 1}}}
+	case tbc:
+		return [][]gain{{{bc, 1}}}
+	case tbr:
+		return [][]gain{{{br, 1}}}
+	}
+	return nil
+}
+
+// route returns the gains from speaker s to the speakers in out,
+// or nil when s cannot be reproduced. Alternatives which only use speakers
+// in out are preferred. visited prevents circular fallbacks.
+func (o *Options) route(s, out, visited wave.WFESpeaker) []gain {
+	if out&s != 0 {
+		return []gain{{s, 1}}
+	}
+	alts := o.fallbacks(s)
+	for _, alt := range alts {
+		direct := true
+		for _, a := range alt {
+			direct = direct && out&a.speaker != 0
+		}
+		if direct {
+			return alt
+		}
+	}
+	visited |= s
+	for _, alt := range alts {
+		var r []gain
+		for _, a := range alt {
+			if visited&a.speaker != 0 {
+				r = nil
+				break
+			}
+			sub := o.route(a.speaker, out, visited)
+			if sub == nil {
+				r = nil
+				break
+			}
+			for _, g := range sub {
+				r = append(r, gain{g.speaker, g.gain * a.gain})
+			}
+		}
+		if r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// NewMatrix returns a Matrix which converts the speaker layout in to out
+// following ITU-R BS.775 where it applies.
+//
+// Speakers which exist in both layouts are passed through, and missing speakers
+// are mixed into the nearest speakers. The LFE channel is only mixed into
+// other channels when LFEGain is set.
+func NewMatrix(in, out wave.WFESpeaker, opts Options) (Matrix, error) {
+	if in == 0 || out == 0 {
+		return nil, errEmptyMask
+	}
+	if opts.CenterGain == 0 {
+		opts.CenterGain = math.Sqrt2 / 2
+	}
+	if opts.SurroundGain == 0 {
+		opts.SurroundGain = math.Sqrt2 / 2
+	}
+	outs := out.Speakers()
+	index := make(map[wave.WFESpeaker]int, len(outs))
+	for i, s := range outs {
+		index[s] = i
+	}
+	ins := in.Speakers()
+	m := make(Matrix, len(outs))
+	for i := range m {
+		m[i] = make([]float64, len(ins))
+	}
+	for j, s := range ins {
+		for _, g := range opts.route(s, out, 0) {
+			m[index[g.speaker]][j] += g.gain
+		}
+	}
+	if opts.Normalize {
+		m.Normalize()
+	}
+	return m, nil
+}
+
+// NewIdentity returns a Matrix which routes input channel i to output channel i.
+// Extra output channels are silent and extra input channels are dropped.
+func NewIdentity(in, out int) Matrix {
+	if in < 1 || out < 1 {
+		panic("you must have at least one channel")
+	}
+	m := make(Matrix, out)
+	for i := range m {
+		m[i] = make([]float64, in)
+		if i < in {
+			m[i][i] = 1
+		}
+	}
+	return m
+}
+
+// Inputs returns the number of input channels.
+func (m Matrix) Inputs() int {
+	return len(m[0])
+}
+
+// Outputs returns the number of output channels.
+func (m Matrix) Outputs() int {
+	return len(m)
+}
+
+// Normalize scales all gains by the same factor so that the sum of
+// the absolute gains of every output channel is at most 1.
+func (m Matrix) Normalize() {
+	var peak float64
+	for _, row := range m {
+		var sum float64
+		for _, g := range row {
+			sum += math.Abs(g)
+		}
+		peak = math.Max(peak, sum)
+	}
+	if peak <= 1 {
+		return
+	}
+	for _, row := range m {
+		for j := range row {
+			row[j] /= peak
+		}
+	}
+}
+
+// ProcessFloat64 mixes src into dst. src[i] and dst[i] are the buffers of channel i.
+// All buffers must have the same length and dst must not share memory with src.
+func (m Matrix) ProcessFloat64(dst, src [][]float64) {
+	for o, row := range m {
+		d := dst[o]
+		for i := range d {
+			d[i] = 0
+		}
+		for j, g := range row {
+			if g == 0 {
+				continue
+			}
+			for i, s := range src[j][:len(d)] {
+				d[i] += s * g
+			}
+		}
+	}
+}
+
+// ProcessFloat32 mixes src into dst. src[i] and dst[i] are the buffers of channel i.
+// All buffers must have the same length and dst must not share memory with src.
+func (m Matrix) ProcessFloat32(dst, src [][]float32) {
+	for o, row := range m {
+		d := dst[o]
+		for i := range d {
+			d[i] = 0
+		}
+		for j, g := range row {
+			if g == 0 {
+				continue
+			}
+			g32 := float32(g)
+			for i, s := range src[j][:len(d)] {
+				d[i] += s * g32
+			}
+		}
+	}
+}
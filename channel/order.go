@@ -0,0 +1,95 @@
+package channel
+
+import (
+	"errors"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/wave"
+)
+
+// Order arranges the speakers of a channel mask in the channel order of a format.
+type Order struct {
+	priority []wave.WFESpeaker
+}
+
+// NewOrder returns an Order which places the speakers in the order of priority.
+// Speakers missing in priority follow in the order of the bits of the mask.
+func NewOrder(priority ...wave.WFESpeaker) *Order {
+	return &Order{priority: priority}
+}
+
+var (
+	// WAV is the order of waveform audio data, which follows the bits of the mask (L R C LFE Ls Rs).
+	WAV = NewOrder()
+	// SMPTE is the order of SMPTE 320M, which is the same as WAV.
+	SMPTE = WAV
+	// Film is the order used by film and AC-3 (L C R Ls Rs LFE).
+	Film = NewOrder(fl, flc, fc, frc, fr, sl, sr, bl, br, bc, lfe)
+	// Vorbis is the order of Vorbis and Opus (L C R Ls Rs LFE, L C R Ls Rs Lb Rb LFE for 7.1).
+	Vorbis = NewOrder(fl, fc, fr, sl, sr, bl, br, bc, lfe)
+	// AAC is the order of the AAC channel configurations (C L R Ls Rs LFE).
+	AAC = NewOrder(fc, flc, frc, fl, fr, sl, sr, bl, br, bc, lfe)
+)
+
+var errMaskMismatch = errors.New("channel: number of channels does not match the channel mask")
+
+// Speakers returns the speakers of mask in the order.
+func (o *Order) Speakers(mask wave.WFESpeaker) []wave.WFESpeaker {
+	r := make([]wave.WFESpeaker, 0, mask.Channels())
+	for _, s := range o.priority {
+		if mask&s != 0 {
+			r = append(r, s)
+			mask &^= s
+		}
+	}
+	return append(r, mask.Speakers()...)
+}
+
+// NewMap returns the channel map which converts the order from to the order to.
+// Channel i of the converted data is channel m[i] of the source.
+func NewMap(mask wave.WFESpeaker, from, to *Order) (m []int) {
+	index := map[wave.WFESpeaker]int{}
+	for i, s := range from.Speakers(mask) {
+		index[s] = i
+	}
+	for _, s := range to.Speakers(mask) {
+		m = append(m, index[s])
+	}
+	return m
+}
+
+// reorderReader passes the buffers to the source in its order, so no samples are copied.
+type reorderReader struct {
+	r   audio.InterleavedReader
+	m   []int
+	p32 [][]float32
+	p64 [][]float64
+}
+
+// NewReorderReader returns an InterleavedReader which reads channels in the order from
+// and returns them in the order to. channels must match the number of speakers in mask.
+func NewReorderReader(r audio.InterleavedReader, channels int, mask wave.WFESpeaker, from, to *Order) (audio.InterleavedReader, error) {
+	if channels != mask.Channels() {
+		return nil, errMaskMismatch
+	}
+	return &reorderReader{
+		r:   r,
+		m:   NewMap(mask, from, to),
+		p32: make([][]float32, channels),
+		p64: make([][]float64, channels),
+	}, nil
+}
+
+func (r *reorderReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	for i, src := range r.m {
+		r.p32[src] = p[i]
+	}
+	return r.r.ReadFloat32Interleaved(r.p32)
+}
+
+func (r *reorderReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	for i, src := range r.m {
+		r.p64[src] = p[i]
+	}
+	return r.r.ReadFloat64Interleaved(r.p64)
+}
@@ -0,0 +1,49 @@
+package channel
+
+import (
+	"github.com/oov/audio"
+)
+
+type reader struct {
+	r     audio.InterleavedReader
+	m     Matrix
+	buf32 [][]float32
+	buf64 [][]float64
+}
+
+// NewReader returns an InterleavedReader which reads m.Inputs() channels from r
+// and returns m.Outputs() channels mixed by m.
+func NewReader(r audio.InterleavedReader, m Matrix) audio.InterleavedReader {
+	return &reader{
+		r:     r,
+		m:     m,
+		buf32: make([][]float32, m.Inputs()),
+		buf64: make([][]float64, m.Inputs()),
+	}
+}
+
+func (r *reader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	ln := len(p[0])
+	for ch, b := range r.buf32 {
+		if cap(b) < ln {
+			b = make([]float32, ln)
+		}
+		r.buf32[ch] = b[:ln]
+	}
+	n, err = r.r.ReadFloat32Interleaved(r.buf32)
+	r.m.ProcessFloat32(p, r.buf32)
+	return
+}
+
+func (r *reader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	for ch, b := range r.buf64 {
+		if cap(b) < ln {
+			b = make([]float64, ln)
+		}
+		r.buf64[ch] = b[:ln]
+	}
+	n, err = r.r.ReadFloat64Interleaved(r.buf64)
+	r.m.ProcessFloat64(p, r.buf64)
+	return
+}
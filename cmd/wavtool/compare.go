@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/oov/audio/compare"
+	"github.com/oov/audio/wave"
+)
+
+type compareOptions struct {
+	compare.Options
+	diff   string
+	asJSON bool
+}
+
+func compareCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var opts compareOptions
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.IntVar(&opts.Offset, "offset", 0, "frames by which the second file lags behind the first file, negative if it leads")
+	fs.BoolVar(&opts.Align, "align", false, "estimate the offset by cross-correlation")
+	fs.IntVar(&opts.MaxOffset, "max-offset", 16384, "largest offset in frames searched by -align")
+	fs.Float64Var(&opts.Threshold, "threshold", 0, "absolute differences up to this value are regarded as equal, 1 is the full scale")
+	fs.StringVar(&opts.diff, "diff", "", "write the difference signal to this path in 32-bit float")
+	fs.BoolVar(&opts.asJSON, "json", false, "print in JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: wavtool compare [options] file1 file2\n\nThe options are:\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != 2 || opts.Threshold < 0 || (fs.Arg(0) == "-" && fs.Arg(1) == "-") {
+		fs.Usage()
+		return errUsage
+	}
+
+	var files [2]io.ReadCloser
+	for i := range files {
+		f, err := openInput(fs.Arg(i), stdin)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	ra, wfa, err := wave.NewReader(files[0])
+	if err != nil {
+		return err
+	}
+	rb, wfb, err := wave.NewReader(files[1])
+	if err != nil {
+		return err
+	}
+	if wfa.Format.Channels != wfb.Format.Channels {
+		return errors.New("number of channels differs")
+	}
+	if wfa.Format.SamplesPerSec != wfb.Format.SamplesPerSec {
+		return errors.New("sample rate differs")
+	}
+	channels := int(wfa.Format.Channels)
+
+	var diff *wave.Writer
+	if opts.diff != "" {
+		f, err := os.Create(opts.diff)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		diff, err = wave.NewWriter(f, &wave.WaveFormatExtensible{
+			Format: wave.WaveFormatEx{
+				FormatTag:      wave.WAVE_FORMAT_IEEE_FLOAT,
+				Channels:       uint16(channels),
+				SamplesPerSec:  wfa.Format.SamplesPerSec,
+				AvgBytesPerSec: wfa.Format.SamplesPerSec * uint32(channels*4),
+				BlockAlign:     uint16(channels * 4),
+				BitsPerSample:  32,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		opts.Diff = diff
+	}
+
+	res, err := compare.Compare(ra, rb, channels, opts.Options)
+	if err != nil {
+		return err
+	}
+	if diff != nil {
+		if err = diff.Close(); err != nil {
+			return err
+		}
+	}
+
+	if opts.asJSON {
+		if err = printCompareJSON(stdout, res); err != nil {
+			return err
+		}
+	} else {
+		printCompare(stdout, res)
+	}
+	if !res.Equal() {
+		return errors.New("files differ")
+	}
+	return nil
+}
+
+func printCompare(w io.Writer, res *compare.Result) {
+	fmt.Fprintln(w, "Offset:", res.Offset)
+	fmt.Fprintln(w, "Frames:", res.Frames)
+	if res.FramesA != res.FramesB {
+		fmt.Fprintf(w, "Length differs: %d and %d frames\n", res.FramesA, res.FramesB)
+	}
+	for ch, c := range res.Channels {
+		fmt.Fprintf(w, "Channel %d: max diff %s, diff RMS %.2f dBFS, SNR %.2f dB", ch+1, dB(c.MaxDiff), c.DiffRMS, c.SNR)
+		if c.FirstDiff >= 0 {
+			fmt.Fprintf(w, ", first diff at frame %d", c.FirstDiff)
+		}
+		fmt.Fprintln(w)
+	}
+	if res.Equal() {
+		fmt.Fprintln(w, "Result: equal")
+	} else {
+		fmt.Fprintln(w, "Result: different")
+	}
+}
+
+// finite returns nil for infinities which cannot be represented in JSON.
+func finite(v float64) *float64 {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+func printCompareJSON(w io.Writer, res *compare.Result) error {
+	type channel struct {
+		MaxDiff   float64  `json:"maxDiff"`
+		DiffRMS   *float64 `json:"diffRMS"` // null if there is no difference
+		SNR       *float64 `json:"snr"`     // null if there is no difference
+		FirstDiff int64    `json:"firstDiff"`
+	}
+	v := struct {
+		Equal    bool      `json:"equal"`
+		Offset   int       `json:"offset"`
+		Frames   int64     `json:"frames"`
+		FramesA  int64     `json:"framesA"`
+		FramesB  int64     `json:"framesB"`
+		Channels []channel `json:"channels"`
+	}{
+		Equal:   res.Equal(),
+		Offset:  res.Offset,
+		Frames:  res.Frames,
+		FramesA: res.FramesA,
+		FramesB: res.FramesB,
+	}
+	for _, c := range res.Channels {
+		v.Channels = append(v.Channels, channel{
+			MaxDiff:   c.MaxDiff,
+			DiffRMS:   finite(c.DiffRMS),
+			SNR:       finite(c.SNR),
+			FirstDiff: c.FirstDiff,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
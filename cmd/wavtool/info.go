@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/oov/audio/wave"
+)
+
+func infoCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var asJSON bool
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&asJSON, "json", false, "print in JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: wavtool info [-json] infile\n\nThe options are:\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	in, err := openInput(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := wave.Inspect(in)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(info); err != nil {
+			return err
+		}
+	} else {
+		printInfo(stdout, fs.Arg(0), info)
+	}
+	if !info.OK() {
+		return fmt.Errorf("%d problems found", len(info.Problems))
+	}
+	return nil
+}
+
+func dB(v float64) string {
+	if v == 0 {
+		return "-inf dBFS"
+	}
+	return fmt.Sprintf("%.2f dBFS", 20*math.Log10(v))
+}
+
+func printInfo(w io.Writer, path string, info *wave.Info) {
+	fmt.Fprintln(w, "Path:", path)
+	fmt.Fprintln(w, "FileSize:", info.FileSize)
+	fmt.Fprintln(w, "RIFFSize:", info.RIFFSize)
+	fmt.Fprintln(w, "Chunks:")
+	for _, c := range info.Chunks {
+		fmt.Fprintf(w, "  %q offset %d size %d\n", c.ID, c.Offset, c.Size)
+	}
+	if f := info.Format; f != nil {
+		fmt.Fprintf(w, "Format: %s (0x%04X)\n", f.FormatName, uint16(f.FormatTag))
+		fmt.Fprintln(w, "Channels:", f.Channels)
+		fmt.Fprintln(w, "Samplerate:", f.SamplesPerSec)
+		fmt.Fprintln(w, "AvgBytesPerSec:", f.AvgBytesPerSec)
+		fmt.Fprintln(w, "BlockAlign:", f.BlockAlign)
+		fmt.Fprintln(w, "Bits:", f.BitsPerSample)
+		if f.FormatTag == wave.WAVE_FORMAT_EXTENSIBLE {
+			fmt.Fprintln(w, "ValidBits:", f.ValidBitsPerSample)
+			fmt.Fprintf(w, "ChannelMask: 0x%08X (%s)\n", uint32(f.ChannelMask), f.Speakers)
+			fmt.Fprintln(w, "SubFormat:", f.SubFormat)
+		}
+	}
+	fmt.Fprintln(w, "Frames:", info.Frames)
+	fmt.Fprintf(w, "Duration: %.3fs\n", info.Duration)
+	for ch, st := range info.Channels {
+		fmt.Fprintf(w, "Channel %d: peak %s, RMS %s, DC %+.6f\n", ch+1, dB(st.Peak), dB(st.RMS), st.DC)
+	}
+	if info.OK() {
+		return
+	}
+	fmt.Fprintln(w, "Problems:")
+	for _, p := range info.Problems {
+		fmt.Fprintln(w, "  "+p)
+	}
+}
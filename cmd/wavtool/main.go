@@ -0,0 +1,385 @@
+// Command wavtool converts, inspects and compares RIFF waveform audio files.
+//
+// Usage:
+//
+//	wavtool convert [options] infile
+//	wavtool resample -rate Hz [options] infile
+//	wavtool info [-json] infile
+//	wavtool compare [options] file1 file2
+//
+// "-" as infile reads from the standard input, and "-" as the output path
+// writes to the standard output. Run a subcommand with -h for its options.
+//
+// When convert only changes the format of integer samples, they are transcoded
+// without floating point. Floating point input is always processed in floating point.
+// Integer output is rounded to the nearest value on both paths, so they give the same
+// samples except for the dither noise.
+//
+// The exit status is 0 on success, 1 on errors and 2 on invalid usage.
+// info also exits with 1 if the file violates the specification,
+// and compare exits with 1 if the files differ.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/channel"
+	"github.com/oov/audio/converter"
+	"github.com/oov/audio/dither"
+	"github.com/oov/audio/resampler"
+	"github.com/oov/audio/transcode"
+	"github.com/oov/audio/wave"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "wavtool converts, inspects and compares RIFF waveform audio files.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  wavtool convert [options] infile")
+	fmt.Fprintln(w, "  wavtool resample -rate Hz [options] infile")
+	fmt.Fprintln(w, "  wavtool info [-json] infile")
+	fmt.Fprintln(w, "  wavtool compare [options] file1 file2")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `"-" reads from the standard input or writes to the standard output.`)
+}
+
+var errUsage = errors.New("invalid usage")
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	var err error
+	switch args[0] {
+	case "convert":
+		err = convertCommand(args[0], args[1:], false, stdin, stdout, stderr)
+	case "resample":
+		err = convertCommand(args[0], args[1:], true, stdin, stdout, stderr)
+	case "info":
+		err = infoCommand(args[1:], stdin, stdout, stderr)
+	case "compare":
+		err = compareCommand(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	default:
+		fmt.Fprintln(stderr, "wavtool: unknown command:", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+	switch err {
+	case nil, flag.ErrHelp:
+		return exitOK
+	case errUsage:
+		return exitUsage
+	}
+	fmt.Fprintln(stderr, "wavtool:", err)
+	return exitError
+}
+
+type nopCloser struct {
+	io.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+func openInput(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "-" {
+		return nopCloser{stdin}, nil
+	}
+	return os.Open(path)
+}
+
+// format is an output sample format.
+type format struct {
+	tag  wave.WaveFormatTag
+	bits int
+}
+
+// encoding returns the encoding of the samples.
+func (f format) encoding() transcode.Encoding {
+	if f.tag == wave.WAVE_FORMAT_IEEE_FLOAT {
+		if f.bits == 32 {
+			return transcode.Float32
+		}
+		return transcode.Float64
+	}
+	switch f.bits {
+	case 8:
+		return transcode.Uint8
+	case 16:
+		return transcode.Int16
+	case 24:
+		return transcode.Int24
+	}
+	return transcode.Int32
+}
+
+var formats = map[string]format{
+	"u8":  {wave.WAVE_FORMAT_PCM, 8},
+	"s16": {wave.WAVE_FORMAT_PCM, 16},
+	"s24": {wave.WAVE_FORMAT_PCM, 24},
+	"s32": {wave.WAVE_FORMAT_PCM, 32},
+	"f32": {wave.WAVE_FORMAT_IEEE_FLOAT, 32},
+	"f64": {wave.WAVE_FORMAT_IEEE_FLOAT, 64},
+}
+
+func formatNames() string {
+	return "u8, s16, s24, s32, f32 or f64"
+}
+
+type convertOptions struct {
+	output   string
+	rate     int
+	format   string
+	channels int
+	dither   string
+	quality  int
+	seed     int64
+}
+
+func convertCommand(name string, args []string, needRate bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	var opts convertOptions
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.output, "o", "", `output path, "-" for the standard output (default infile.out.wav, or "-" for the standard input)`)
+	fs.IntVar(&opts.rate, "rate", 0, "output sample rate in Hz (default the input sample rate)")
+	fs.StringVar(&opts.format, "format", "", "output sample format: "+formatNames()+" (default the input format); integer input is converted without floating point when the channels and the rate do not change")
+	fs.IntVar(&opts.channels, "channels", 0, "output number of channels (default the input channels)")
+	fs.StringVar(&opts.dither, "dither", "auto", "dither for integer output: auto, tpdf, rpdf or none; auto uses tpdf when the bit depth is reduced")
+	fs.IntVar(&opts.quality, "quality", 5, "resampling quality (0-10)")
+	fs.Int64Var(&opts.seed, "seed", 0, "random seed of the dither")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: wavtool %s [options] infile\n\nThe options are:\n\n", name)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != 1 || (needRate && opts.rate <= 0) || opts.quality < 0 || opts.quality > 10 || opts.channels < 0 || opts.rate < 0 {
+		fs.Usage()
+		return errUsage
+	}
+	input := fs.Arg(0)
+	if opts.output == "" {
+		opts.output = "-"
+		if input != "-" {
+			opts.output = input + ".out.wav"
+		}
+	}
+
+	in, err := openInput(input, stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if opts.output == "-" {
+		return convert(stdout, in, &opts)
+	}
+	out, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	if err = convert(out, in, &opts); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func ditherMode(name string, inBits, outBits int) (mode dither.Mode, enabled bool, err error) {
+	switch strings.ToLower(name) {
+	case "auto":
+		return dither.TPDF, outBits < inBits, nil
+	case "tpdf":
+		return dither.TPDF, true, nil
+	case "rpdf":
+		return dither.RPDF, true, nil
+	case "none":
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("unknown dither: %s", name)
+}
+
+func convert(w io.Writer, r io.Reader, opts *convertOptions) error {
+	lr, wfext, err := wave.NewLimitedReader(r)
+	if err != nil {
+		return err
+	}
+	inChannels, inRate := int(wfext.Format.Channels), int(wfext.Format.SamplesPerSec)
+	inFormat := format{wfext.SampleFormatTag(), int(wfext.Format.BitsPerSample)}
+
+	outFormat := inFormat
+	if opts.format != "" {
+		f, ok := formats[strings.ToLower(opts.format)]
+		if !ok {
+			return fmt.Errorf("unknown format: %s, must be %s", opts.format, formatNames())
+		}
+		outFormat = f
+	}
+	outChannels, outRate := inChannels, inRate
+	if opts.channels > 0 {
+		outChannels = opts.channels
+	}
+	if opts.rate > 0 {
+		outRate = opts.rate
+	}
+
+	var mode dither.Mode
+	var dithered bool
+	if outFormat.tag == wave.WAVE_FORMAT_PCM {
+		inBits := inFormat.bits
+		if inFormat.tag != wave.WAVE_FORMAT_PCM || outRate != inRate || outChannels != inChannels {
+			// processed or floating point samples have more precision than the output
+			inBits = 64
+		}
+		if mode, dithered, err = ditherMode(opts.dither, inBits, outFormat.bits); err != nil {
+			return err
+		}
+	}
+
+	topts := transcode.Options{
+		Dither: dithered,
+		Mode:   mode,
+		Seed:   opts.seed,
+	}
+	if outChannels != inChannels || outRate != inRate || inFormat.tag != wave.WAVE_FORMAT_PCM {
+		// floating point input is always processed in floating point,
+		// so it is converted in the same way whether or not the channels and the rate change.
+		return process(w, lr, wfext, outFormat, outChannels, outRate, opts.quality, topts)
+	}
+
+	// only the format of integer samples changes, so they are transcoded without floating point,
+	// which gives the same samples as process except for the dither noise.
+	return wave.RewriteFormatData(w, lr, wfext, outFormat.encoding(), topts)
+}
+
+// process converts the samples of r in floating point and writes them to w.
+// Integer output is rounded and dithered as topts specifies.
+func process(w io.Writer, r io.Reader, wfext *wave.WaveFormatExtensible, outFormat format, outChannels, outRate, quality int, topts transcode.Options) error {
+	inChannels, inRate := int(wfext.Format.Channels), int(wfext.Format.SamplesPerSec)
+	conv, err := wfext.InterleavedConverter()
+	if err != nil {
+		return err
+	}
+	ar := audio.NewInterleavedReader(conv, r)
+
+	inMask, outMask := wfext.ChannelMask, wave.DefaultChannelMask(outChannels)
+	if inMask == 0 {
+		inMask = wave.DefaultChannelMask(inChannels)
+	}
+	if outChannels != inChannels {
+		m := channel.NewIdentity(inChannels, outChannels)
+		if inMask.Channels() == inChannels && outMask != 0 {
+			if m, err = channel.NewMatrix(inMask, outMask, channel.Options{}); err != nil {
+				return err
+			}
+		}
+		ar = channel.NewReader(ar, m)
+	} else {
+		outMask = inMask
+	}
+	if outRate != inRate {
+		ar = resampler.NewReader(ar, outChannels, inRate, outRate, quality)
+	}
+
+	blockAlign := outChannels * outFormat.bits / 8
+	outWfext := &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      outFormat.tag,
+			Channels:       uint16(outChannels),
+			SamplesPerSec:  uint32(outRate),
+			AvgBytesPerSec: uint32(outRate * blockAlign),
+			BlockAlign:     uint16(blockAlign),
+			BitsPerSample:  uint16(outFormat.bits),
+		},
+	}
+	if outChannels > 2 {
+		// the speaker positions of multichannel audio need WAVE_FORMAT_EXTENSIBLE
+		outWfext.Format.FormatTag = wave.WAVE_FORMAT_EXTENSIBLE
+		outWfext.Format.ExtSize = 22
+		outWfext.Samples = uint16(outFormat.bits)
+		outWfext.ChannelMask = outMask
+		outWfext.SubFormat = wave.KSDATAFORMAT_SUBTYPE_PCM
+		if outFormat.tag == wave.WAVE_FORMAT_IEEE_FLOAT {
+			outWfext.SubFormat = wave.KSDATAFORMAT_SUBTYPE_IEEE_FLOAT
+		}
+	}
+	aw, err := wave.NewWriter(w, outWfext)
+	if err != nil {
+		return err
+	}
+
+	var t *transcode.Transcoder
+	if outFormat.tag == wave.WAVE_FORMAT_PCM {
+		t = transcode.New(transcode.Format{Encoding: transcode.Float64}, transcode.Format{Encoding: outFormat.encoding()}, topts)
+	}
+	if err = copyAudio(aw, ar, outChannels, t); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// copyAudio copies the samples of r to w. If t is not nil, the samples are encoded by t,
+// because the converters of w truncate toward zero instead of rounding,
+// which leaves distortion in dithered signals.
+func copyAudio(w *wave.Writer, r audio.InterleavedReader, channels int, t *transcode.Transcoder) error {
+	const frames = 4096
+	buf := make([][]float64, channels)
+	for ch := range buf {
+		buf[ch] = make([]float64, frames)
+	}
+	var f, b []byte
+	if t != nil {
+		f = make([]byte, frames*channels*8)
+		b = make([]byte, frames*channels*t.To().SampleSize())
+	}
+	p := make([][]float64, channels)
+	for {
+		n, rerr := r.ReadFloat64Interleaved(buf)
+		if n > 0 {
+			for ch := range p {
+				p[ch] = buf[ch][:n]
+			}
+			var err error
+			if t != nil {
+				converter.Float64.FromFloat64Interleaved(p, f)
+				m := t.Transcode(b, f[:n*channels*8])
+				_, err = w.Write(b[:m*t.To().SampleSize()])
+			} else {
+				_, err = w.WriteFloat64Interleaved(p)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
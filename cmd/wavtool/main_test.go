@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oov/audio/transcode"
+	"github.com/oov/audio/wave"
+)
+
+const testFile = "../../wave/48kHz2ch16bit.wav"
+
+func TestConvert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavtool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		args     []string
+		tag      wave.WaveFormatTag
+		channels int
+		rate     int
+		bits     int
+	}{
+		{[]string{"convert", "-format", "f32"}, wave.WAVE_FORMAT_IEEE_FLOAT, 2, 48000, 32},
+		{[]string{"convert", "-format", "u8", "-channels", "1"}, wave.WAVE_FORMAT_PCM, 1, 48000, 8},
+		{[]string{"convert", "-channels", "6", "-dither", "none"}, wave.WAVE_FORMAT_PCM, 6, 48000, 16},
+		{[]string{"resample", "-rate", "44100", "-format", "s24", "-quality", "3"}, wave.WAVE_FORMAT_PCM, 2, 44100, 24},
+	}
+	for i, tt := range tests {
+		out := filepath.Join(dir, "out.wav")
+		args := append(tt.args, "-o", out, testFile)
+		var stderr bytes.Buffer
+		if code := run(args, nil, ioutil.Discard, &stderr); code != exitOK {
+			t.Fatalf("tests[%d] exit status want %d got %d: %s", i, exitOK, code, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lr, wfext, err := wave.NewLimitedReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		fm := wfext.Format
+		if wfext.SampleFormatTag() != tt.tag || int(fm.Channels) != tt.channels || int(fm.SamplesPerSec) != tt.rate || int(fm.BitsPerSample) != tt.bits {
+			t.Errorf("tests[%d] unexpected format %+v", i, fm)
+		}
+		if tt.channels > 2 && (fm.FormatTag != wave.WAVE_FORMAT_EXTENSIBLE || wfext.ChannelMask != wave.DefaultChannelMask(tt.channels)) {
+			t.Errorf("tests[%d] multichannel output must have a channel mask: %+v", i, wfext)
+		}
+		if lr.N == 0 {
+			t.Errorf("tests[%d] no samples written", i)
+		}
+	}
+}
+
+// TestConvertPaths converts the same input with the transcoding path and the floating point path.
+func TestConvertPaths(t *testing.T) {
+	in, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"u8", "s16", "s24", "s32", "f32", "f64"} {
+		f := formats[name]
+		var fast, slow bytes.Buffer
+		if err = wave.RewriteFormat(&fast, bytes.NewReader(in), f.encoding(), transcode.Options{}); err != nil {
+			t.Fatal(name, err)
+		}
+		lr, wfext, err := wave.NewLimitedReader(bytes.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = process(&slow, lr, wfext, f, int(wfext.Format.Channels), int(wfext.Format.SamplesPerSec), 5, transcode.Options{}); err != nil {
+			t.Fatal(name, err)
+		}
+		if !bytes.Equal(fast.Bytes(), slow.Bytes()) {
+			t.Errorf("%s: the outputs of the two paths differ", name)
+		}
+	}
+}
+
+// TestDither converts a constant below the least significant bit with dither,
+// the mean of the output must stay at the input level.
+func TestDither(t *testing.T) {
+	const frames, level = 48000, 0.25
+	wfext := &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_IEEE_FLOAT,
+			Channels:       1,
+			SamplesPerSec:  48000,
+			AvgBytesPerSec: 48000 * 8,
+			BlockAlign:     8,
+			BitsPerSample:  64,
+		},
+	}
+	var in bytes.Buffer
+	w, err := wave.NewWriter(&in, wfext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]float64, frames)
+	for i := range p {
+		p[i] = level / 32768
+	}
+	if _, err = w.WriteFloat64Interleaved([][]float64{p}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []string{"tpdf", "rpdf"} {
+		var out bytes.Buffer
+		if err = convert(&out, bytes.NewReader(in.Bytes()), &convertOptions{format: "s16", dither: mode}); err != nil {
+			t.Fatal(mode, err)
+		}
+		lr, _, err := wave.NewLimitedReader(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatal(mode, err)
+		}
+		b, err := ioutil.ReadAll(lr)
+		if err != nil {
+			t.Fatal(mode, err)
+		}
+		var sum float64
+		for i := 0; i < len(b); i += 2 {
+			sum += float64(int16(b[i]) | int16(b[i+1])<<8)
+		}
+		if mean := sum / frames; math.Abs(mean-level) > 0.02 {
+			t.Errorf("%s: mean want %v got %v", mode, level, mean)
+		}
+	}
+}
+
+// TestConvertExtensible converts a WAVE_FORMAT_EXTENSIBLE input.
+func TestConvertExtensible(t *testing.T) {
+	var multi, stereo bytes.Buffer
+	if code := run([]string{"convert", "-channels", "6", "-format", "s24", "-"}, openTestFile(t), &multi, ioutil.Discard); code != exitOK {
+		t.Fatalf("exit status want %d got %d", exitOK, code)
+	}
+	if code := run([]string{"convert", "-channels", "2", "-"}, bytes.NewReader(multi.Bytes()), &stereo, ioutil.Discard); code != exitOK {
+		t.Fatalf("exit status want %d got %d", exitOK, code)
+	}
+	_, wfext, err := wave.NewLimitedReader(&stereo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wfext.Format.FormatTag != wave.WAVE_FORMAT_PCM || wfext.Format.Channels != 2 || wfext.Format.BitsPerSample != 24 {
+		t.Errorf("unexpected format %+v", wfext.Format)
+	}
+}
+
+func openTestFile(t *testing.T) *bytes.Reader {
+	in, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(in)
+}
+
+func TestPipe(t *testing.T) {
+	in, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"convert", "-format", "s32", "-"}, bytes.NewReader(in), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit status want %d got %d: %s", exitOK, code, stderr.String())
+	}
+	_, wfext, err := wave.NewLimitedReader(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wfext.Format.BitsPerSample != 32 || wfext.Format.Channels != 2 {
+		t.Errorf("unexpected format %+v", wfext.Format)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"info", testFile}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit status want %d got %d: %s", exitOK, code, stderr.String())
+	}
+	for _, s := range []string{"Channels: 2", "Samplerate: 48000", "Bits: 16", `"data" offset 36 size 192`, "Channel 2: peak"} {
+		if !strings.Contains(stdout.String(), s) {
+			t.Errorf("output does not contain %q:\n%s", s, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"info", "-json", testFile}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit status want %d got %d: %s", exitOK, code, stderr.String())
+	}
+	var info wave.Info
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Format == nil || info.Format.Channels != 2 || len(info.Channels) != 2 || len(info.Problems) != 0 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	// the RIFF size does not match
+	in, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{"info", "-"}, bytes.NewReader(in[:len(in)-2]), &stdout, ioutil.Discard); code != exitError {
+		t.Fatalf("exit status want %d got %d", exitError, code)
+	}
+	if !strings.Contains(stdout.String(), "Problems:") {
+		t.Errorf("problems are not reported:\n%s", stdout.String())
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"unknown"}, exitUsage},
+		{[]string{"resample", testFile}, exitUsage},
+		{[]string{"convert", "-quality", "11", testFile}, exitUsage},
+		{[]string{"convert", "-unknown", testFile}, exitUsage},
+		{[]string{"info", "not-exists.wav"}, exitError},
+		{[]string{"convert", "-format", "s12", "-o", "-", testFile}, exitError},
+		{[]string{"convert", "-o", "-", "-"}, exitError},
+	}
+	for i, tt := range tests {
+		code := run(tt.args, strings.NewReader("not a wave file"), ioutil.Discard, ioutil.Discard)
+		if code != tt.code {
+			t.Errorf("tests[%d] exit status want %d got %d", i, tt.code, code)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavtool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f32, u8, diff := filepath.Join(dir, "f32.wav"), filepath.Join(dir, "u8.wav"), filepath.Join(dir, "diff.wav")
+	if code := run([]string{"convert", "-format", "f32", "-o", f32, testFile}, nil, ioutil.Discard, ioutil.Discard); code != exitOK {
+		t.Fatal("convert failed:", code)
+	}
+	if code := run([]string{"convert", "-format", "u8", "-o", u8, testFile}, nil, ioutil.Discard, ioutil.Discard); code != exitOK {
+		t.Fatal("convert failed:", code)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"compare", "-json", testFile, f32}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit status want %d got %d: %s", exitOK, code, stderr.String())
+	}
+	var res struct {
+		Equal    bool
+		Channels []struct {
+			DiffRMS *float64
+		}
+	}
+	if err = json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal || len(res.Channels) != 2 || res.Channels[0].DiffRMS != nil {
+		t.Errorf("unexpected result: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"compare", "-diff", diff, testFile, u8}, nil, &stdout, ioutil.Discard); code != exitError {
+		t.Fatalf("exit status want %d got %d", exitError, code)
+	}
+	if !strings.Contains(stdout.String(), "first diff at frame") {
+		t.Errorf("differences are not reported:\n%s", stdout.String())
+	}
+	if _, err = os.Stat(diff); err != nil {
+		t.Error("difference signal is not written:", err)
+	}
+
+	if code := run([]string{"compare", "-threshold", "0.05", testFile, u8}, nil, ioutil.Discard, ioutil.Discard); code != exitOK {
+		t.Errorf("differences below the threshold must be ignored: %d", code)
+	}
+	if code := run([]string{"compare", testFile, "../../wave/48kHz1ch16bit.wav"}, nil, ioutil.Discard, ioutil.Discard); code != exitError {
+		t.Errorf("files of different channels must fail: %d", code)
+	}
+}
@@ -0,0 +1,327 @@
+// Package compare implements a null test which compares two audio signals sample by sample.
+//
+// Both inputs are read as floating point samples, so inputs of different
+// bit depths are compared on the same scale.
+package compare
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/fft"
+	"github.com/oov/audio/wave"
+)
+
+const blockSize = 4096
+
+var (
+	errChannels   = errors.New("compare: number of channels differs")
+	errSampleRate = errors.New("compare: sample rate differs")
+)
+
+// Options configures Compare.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	Offset    int                     // frames by which b lags behind a, negative if b leads, ignored if Align is set
+	Align     bool                    // estimate the offset by the cross-correlation of the beginning of the inputs
+	MaxOffset int                     // largest offset in frames searched by Align (default 16384)
+	Threshold float64                 // absolute differences up to Threshold are regarded as equal
+	Diff      audio.InterleavedWriter // receives a - b if it is not nil
+}
+
+// ChannelResult is the comparison of a channel.
+type ChannelResult struct {
+	MaxDiff   float64 // maximum absolute difference
+	DiffRMS   float64 // RMS of the difference in dBFS, -Inf if there is no difference
+	SNR       float64 // ratio of the RMS of a to the RMS of the difference in dB, +Inf if there is no difference
+	FirstDiff int64   // first frame of a which differs more than Threshold, -1 if there is none
+}
+
+// Result is the comparison of the inputs.
+type Result struct {
+	Offset   int   // offset applied to the inputs
+	Frames   int64 // number of compared frames, the shorter input is padded with silence
+	FramesA  int64 // number of frames of a after the offset
+	FramesB  int64 // number of frames of b after the offset
+	Channels []ChannelResult
+}
+
+// Equal reports whether no differences exceed the threshold.
+func (r *Result) Equal() bool {
+	for _, c := range r.Channels {
+		if c.FirstDiff >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxDiff returns the maximum absolute difference of all channels.
+func (r *Result) MaxDiff() float64 {
+	var m float64
+	for _, c := range r.Channels {
+		m = math.Max(m, c.MaxDiff)
+	}
+	return m
+}
+
+// source reads complete blocks from r, after the frames which were read ahead.
+type source struct {
+	r   audio.InterleavedReader
+	pre [][]float64
+	tmp [][]float64
+	err error
+}
+
+func newSource(r audio.InterleavedReader, channels int) *source {
+	return &source{
+		r:   r,
+		pre: make([][]float64, channels),
+		tmp: make([][]float64, channels),
+	}
+}
+
+// read fills p, and it returns fewer frames only at the end of the input.
+func (s *source) read(p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	if len(s.pre[0]) > 0 {
+		for ch := range p {
+			n = copy(p[ch], s.pre[ch])
+			s.pre[ch] = s.pre[ch][n:]
+		}
+	}
+	for n < ln && s.err == nil {
+		for ch := range p {
+			s.tmp[ch] = p[ch][n:]
+		}
+		var rn int
+		rn, s.err = s.r.ReadFloat64Interleaved(s.tmp)
+		n += rn
+	}
+	if s.err == io.EOF && n > 0 {
+		return n, nil
+	}
+	return n, s.err
+}
+
+// readAhead reads up to frames and keeps them for the next read.
+func (s *source) readAhead(frames int) ([][]float64, error) {
+	p := make([][]float64, len(s.pre))
+	for ch := range p {
+		p[ch] = make([]float64, frames)
+	}
+	n, err := s.read(p)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	for ch := range p {
+		p[ch] = p[ch][:n]
+		s.pre[ch] = p[ch]
+	}
+	return p, nil
+}
+
+// skip discards frames, and it returns the number of discarded frames.
+func (s *source) skip(frames int) (int, error) {
+	p := make([][]float64, len(s.pre))
+	for ch := range p {
+		p[ch] = make([]float64, blockSize)
+	}
+	skipped := 0
+	for skipped < frames {
+		for ch := range p {
+			p[ch] = p[ch][:imin(blockSize, frames-skipped)]
+		}
+		n, err := s.read(p)
+		skipped += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// mix returns the sum of all channels.
+func mix(p [][]float64, n int) []float64 {
+	m := make([]float64, n)
+	for _, c := range p {
+		for i, s := range c {
+			m[i] += s
+		}
+	}
+	return m
+}
+
+// estimateOffset returns the lag of b against a in [-maxOffset, maxOffset]
+// which maximizes the cross-correlation.
+func estimateOffset(a, b [][]float64, maxOffset int) int {
+	la, lb := len(a[0]), len(b[0])
+	n := 1
+	for n < la+lb {
+		n <<= 1
+	}
+	plan := fft.NewRealPlan(n)
+	fa, fb := make([]complex128, n/2+1), make([]complex128, n/2+1)
+	plan.ForwardFloat64(fa, mix(a, n))
+	plan.ForwardFloat64(fb, mix(b, n))
+	for i := range fa {
+		fa[i] = complex(real(fa[i]), -imag(fa[i])) * fb[i]
+	}
+	c := make([]float64, n)
+	plan.InverseFloat64(c, fa)
+
+	// c[k] is the sum of a[i]*b[i+k], negative lags are wrapped around
+	best, offset := 0.0, 0
+	for lag := -imin(maxOffset, la-1); lag <= imin(maxOffset, lb-1); lag++ {
+		if v := c[(lag+n)%n]; v > best {
+			best, offset = v, lag
+		}
+	}
+	return offset
+}
+
+// Compare reads a and b of channels until the end and compares them.
+func Compare(a, b audio.InterleavedReader, channels int, opts Options) (*Result, error) {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	sa, sb := newSource(a, channels), newSource(b, channels)
+	offset := opts.Offset
+	if opts.Align {
+		maxOffset := opts.MaxOffset
+		if maxOffset <= 0 {
+			maxOffset = 16384
+		}
+		pa, err := sa.readAhead(maxOffset * 4)
+		if err != nil {
+			return nil, err
+		}
+		pb, err := sb.readAhead(maxOffset * 4)
+		if err != nil {
+			return nil, err
+		}
+		offset = 0
+		if len(pa[0]) > 0 && len(pb[0]) > 0 {
+			offset = estimateOffset(pa, pb, maxOffset)
+		}
+	}
+	var err error
+	if offset > 0 {
+		_, err = sb.skip(offset)
+	} else if offset < 0 {
+		_, err = sa.skip(-offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Offset:   offset,
+		Channels: make([]ChannelResult, channels),
+	}
+	for ch := range res.Channels {
+		res.Channels[ch].FirstDiff = -1
+	}
+	pa, pb, diff := make([][]float64, channels), make([][]float64, channels), make([][]float64, channels)
+	for ch := 0; ch < channels; ch++ {
+		pa[ch] = make([]float64, blockSize)
+		pb[ch] = make([]float64, blockSize)
+		diff[ch] = make([]float64, blockSize)
+	}
+	sumA, sumDiff := make([]float64, channels), make([]float64, channels)
+	for {
+		na, err := sa.read(pa)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		nb, err := sb.read(pb)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		n := na
+		if nb > n {
+			n = nb
+		}
+		if n == 0 {
+			break
+		}
+		for ch := range diff {
+			c := &res.Channels[ch]
+			// the shorter input is padded with silence
+			for i := na; i < n; i++ {
+				pa[ch][i] = 0
+			}
+			for i := nb; i < n; i++ {
+				pb[ch][i] = 0
+			}
+			d := diff[ch][:n]
+			for i := range d {
+				x, sd := pa[ch][i], pa[ch][i]-pb[ch][i]
+				d[i] = sd
+				sumA[ch] += x * x
+				sumDiff[ch] += sd * sd
+				ad := math.Abs(sd)
+				if ad > c.MaxDiff {
+					c.MaxDiff = ad
+				}
+				if ad > opts.Threshold && c.FirstDiff < 0 {
+					c.FirstDiff = res.Frames + int64(i)
+				}
+			}
+			diff[ch] = d
+		}
+		if opts.Diff != nil {
+			if _, err = opts.Diff.WriteFloat64Interleaved(diff); err != nil {
+				return nil, err
+			}
+		}
+		for ch := range diff {
+			diff[ch] = diff[ch][:blockSize]
+		}
+		res.Frames += int64(n)
+		res.FramesA += int64(na)
+		res.FramesB += int64(nb)
+	}
+
+	for ch := range res.Channels {
+		c := &res.Channels[ch]
+		c.DiffRMS, c.SNR = math.Inf(-1), math.Inf(1)
+		if sumDiff[ch] > 0 {
+			c.DiffRMS = 10 * math.Log10(sumDiff[ch]/float64(res.Frames))
+			c.SNR = 10 * math.Log10(sumA[ch]/sumDiff[ch])
+		}
+	}
+	return res, nil
+}
+
+// CompareWave compares the waveform audio data of a and b.
+// The inputs must have the same number of channels and sample rate.
+func CompareWave(a, b io.Reader, opts Options) (*Result, error) {
+	ra, wfa, err := wave.NewReader(a)
+	if err != nil {
+		return nil, err
+	}
+	rb, wfb, err := wave.NewReader(b)
+	if err != nil {
+		return nil, err
+	}
+	if wfa.Format.Channels != wfb.Format.Channels {
+		return nil, errChannels
+	}
+	if wfa.Format.SamplesPerSec != wfb.Format.SamplesPerSec {
+		return nil, errSampleRate
+	}
+	return Compare(ra, rb, int(wfa.Format.Channels), opts)
+}
@@ -0,0 +1,186 @@
+package compare
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// sliceReader returns p in blocks of up to max frames.
+type sliceReader struct {
+	p   [][]float64
+	max int
+}
+
+func (r *sliceReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *sliceReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if len(r.p[0]) == 0 {
+		return 0, io.EOF
+	}
+	for ch := range p {
+		n = copy(p[ch][:imin(len(p[ch]), r.max)], r.p[ch])
+		r.p[ch] = r.p[ch][n:]
+	}
+	return n, nil
+}
+
+// diffWriter collects the difference signal.
+type diffWriter struct {
+	p [][]float64
+}
+
+func (w *diffWriter) WriteFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (w *diffWriter) WriteFloat64Interleaved(p [][]float64) (n int, err error) {
+	if w.p == nil {
+		w.p = make([][]float64, len(p))
+	}
+	for ch := range p {
+		w.p[ch] = append(w.p[ch], p[ch]...)
+	}
+	return len(p[0]), nil
+}
+
+func noise(channels, frames int, seed int64) [][]float64 {
+	rnd := rand.New(rand.NewSource(seed))
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, frames)
+		for i := range p[ch] {
+			p[ch][i] = rnd.Float64() - 0.5
+		}
+	}
+	return p
+}
+
+func clone(p [][]float64) [][]float64 {
+	r := make([][]float64, len(p))
+	for ch := range p {
+		r[ch] = append([]float64(nil), p[ch]...)
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	a := noise(2, 10000, 1)
+	res, err := Compare(&sliceReader{clone(a), 333}, &sliceReader{clone(a), 1000}, 2, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() || res.Frames != 10000 || res.MaxDiff() != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, c := range res.Channels {
+		if !math.IsInf(c.DiffRMS, -1) || !math.IsInf(c.SNR, 1) || c.FirstDiff != -1 {
+			t.Errorf("unexpected channel result: %+v", c)
+		}
+	}
+
+	b := clone(a)
+	b[1][5000] += 0.25
+	b[1][7000] += 0.001
+	var w diffWriter
+	res, err = Compare(&sliceReader{clone(a), 333}, &sliceReader{clone(b), 1000}, 2, Options{Diff: &w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Equal() || res.Channels[0].FirstDiff != -1 || res.Channels[1].FirstDiff != 5000 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	c := res.Channels[1]
+	if math.Abs(c.MaxDiff-0.25) > 1e-12 || math.Abs(c.DiffRMS-10*math.Log10((0.25*0.25+0.001*0.001)/10000)) > 1e-9 || c.SNR < 40 {
+		t.Errorf("unexpected channel result: %+v", c)
+	}
+	if len(w.p[1]) != 10000 || math.Abs(w.p[1][5000]+0.25) > 1e-12 || w.p[0][5000] != 0 {
+		t.Error("unexpected difference signal")
+	}
+
+	// the difference of 0.001 is below the threshold
+	b[1][5000] -= 0.25
+	res, err = Compare(&sliceReader{clone(a), 333}, &sliceReader{clone(b), 1000}, 2, Options{Threshold: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() || res.MaxDiff() == 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestCompareOffset(t *testing.T) {
+	a := noise(2, 20000, 2)
+	for _, offset := range []int{0, 37, -1234} {
+		var ra, rb [][]float64
+		if offset >= 0 {
+			ra, rb = clone(a), make([][]float64, 2)
+			for ch := range rb {
+				rb[ch] = append(make([]float64, offset), a[ch]...)
+			}
+		} else {
+			ra, rb = make([][]float64, 2), clone(a)
+			for ch := range ra {
+				ra[ch] = append(make([]float64, -offset), a[ch]...)
+			}
+		}
+		for _, opts := range []Options{{Offset: offset}, {Align: true, MaxOffset: 2000}} {
+			res, err := Compare(&sliceReader{clone(ra), 777}, &sliceReader{clone(rb), 1024}, 2, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Offset != offset || !res.Equal() || res.FramesA != 20000 || res.FramesB != 20000 {
+				t.Errorf("offset %d %+v: unexpected result: %+v", offset, opts, res)
+			}
+		}
+	}
+
+	// the longer input is compared with silence
+	b := clone(a)
+	for ch := range b {
+		b[ch] = b[ch][:15000]
+	}
+	res, err := Compare(&sliceReader{clone(a), 4096}, &sliceReader{b, 4096}, 2, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Frames != 20000 || res.FramesB != 15000 || res.Channels[0].FirstDiff != 15000 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestCompareWave(t *testing.T) {
+	fa, err := os.Open("../wave/48kHz1ch16bit.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fa.Close()
+	fb, err := os.Open("../wave/48kHz1ch32bitFloat.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fb.Close()
+
+	// inputs of different bit depths are compared as floating point samples
+	res, err := CompareWave(fa, fb, Options{Threshold: 1e-3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() || res.Frames == 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	fs, err := os.Open("../wave/48kHz2ch16bit.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	fa.Seek(0, os.SEEK_SET)
+	if _, err = CompareWave(fa, fs, Options{}); err != errChannels {
+		t.Error("want", errChannels, "got", err)
+	}
+}
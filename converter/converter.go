@@ -1,6 +1,11 @@
 // Package converter implements audio sample format converter.
 package converter
 
+// blockFrames is the number of frames which the conversions between planar and interleaved samples
+// process at a time. A block of interleaved samples stays in the cache while all channels are
+// converted, so the input is read from memory only once even for many channels.
+const blockFrames = 256
+
 type Converter interface {
 	SampleSize() int
 	ToFloat32(input []byte, output []float32)
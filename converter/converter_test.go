@@ -0,0 +1,285 @@
+package converter
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+var converters = []struct {
+	name string
+	c    interface {
+		Converter
+		InterleavedConverter
+	}
+}{
+	{"Uint8", Uint8},
+	{"Int16", Int16},
+	{"Int24", Int24},
+	{"Int32", Int32},
+	{"Float32", Float32},
+	{"Float64", Float64},
+}
+
+func same64(a, b float64) bool {
+	return a == b || (a != a && b != b)
+}
+
+func same32(a, b float32) bool {
+	return a == b || (a != a && b != b)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(b)
+	return b
+}
+
+func randomFloat64(channels, frames int) [][]float64 {
+	rnd := rand.New(rand.NewSource(2))
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, frames)
+		for i := range p[ch] {
+			p[ch][i] = rnd.Float64()*2 - 1
+		}
+	}
+	return p
+}
+
+// TestBulk compares the bulk conversions with the conversions of single samples.
+func TestBulk(t *testing.T) {
+	// more than two blocks, with a partial block at the end
+	const frames = 2*blockFrames + 88
+	for _, cv := range converters {
+		c, size := cv.c, cv.c.SampleSize()
+		for _, chs := range []int{1, 2, 3, 8} {
+			input := randomBytes(chs * frames * size)
+			o64, o32 := make([][]float64, chs), make([][]float32, chs)
+			for ch := range o64 {
+				o64[ch], o32[ch] = make([]float64, frames), make([]float32, frames)
+			}
+			c.ToFloat64Interleaved(input, o64)
+			c.ToFloat32Interleaved(input, o32)
+			var s64 [1]float64
+			var s32 [1]float32
+			for i := 0; i < frames; i++ {
+				for ch := 0; ch < chs; ch++ {
+					b := input[(i*chs+ch)*size : (i*chs+ch+1)*size]
+					c.ToFloat64(b, s64[:])
+					c.ToFloat32(b, s32[:])
+					if !same64(s64[0], o64[ch][i]) || !same32(s32[0], o32[ch][i]) {
+						t.Fatalf("%s %dch frame %d channel %d want %v %v got %v %v", cv.name, chs, i, ch, s64[0], s32[0], o64[ch][i], o32[ch][i])
+					}
+				}
+			}
+
+			in64 := randomFloat64(chs, frames)
+			in32 := make([][]float32, chs)
+			for ch := range in32 {
+				in32[ch] = make([]float32, frames)
+				Float64ToFloat32Slice(in64[ch], in32[ch])
+			}
+			b64, b32 := make([]byte, len(input)), make([]byte, len(input))
+			c.FromFloat64Interleaved(in64, b64)
+			c.FromFloat32Interleaved(in32, b32)
+			s := make([]byte, size)
+			for i := 0; i < frames; i++ {
+				for ch := 0; ch < chs; ch++ {
+					pos := (i*chs + ch) * size
+					c.FromFloat64(in64[ch][i:i+1], s)
+					if string(s) != string(b64[pos:pos+size]) {
+						t.Fatalf("%s %dch frame %d channel %d want %x got %x", cv.name, chs, i, ch, s, b64[pos:pos+size])
+					}
+					c.FromFloat32(in32[ch][i:i+1], s)
+					if string(s) != string(b32[pos:pos+size]) {
+						t.Fatalf("%s %dch frame %d channel %d want %x got %x", cv.name, chs, i, ch, s, b32[pos:pos+size])
+					}
+				}
+			}
+		}
+	}
+}
+
+// oldInt16ToFloat32Interleaved is the former conversion which walks the input once per channel.
+func oldInt16ToFloat32Interleaved(input []byte, outputs [][]float32) {
+	chs := len(outputs)
+	for ch, output := range outputs {
+		for i, o, ln := ch*2, 0, len(input); i < ln; i += chs * 2 {
+			output[o] = Int16ToFloat32(ByteToInt16(input[i], input[i+1]))
+			o++
+		}
+	}
+}
+
+// oldInt24ToFloat32Interleaved is the former conversion which walks the input once per channel.
+func oldInt24ToFloat32Interleaved(input []byte, outputs [][]float32) {
+	chs := len(outputs)
+	for ch, output := range outputs {
+		for i, o, ln := ch*3, 0, len(input); i < ln; i += chs * 3 {
+			output[o] = Int24ToFloat32(ByteToInt24(input[i], input[i+1], input[i+2]))
+			o++
+		}
+	}
+}
+
+// oldInt16FromFloat32Interleaved is the former conversion of a sample at a time.
+func oldInt16FromFloat32Interleaved(inputs [][]float32, output []byte) {
+	for i, o := 0, 0; o < len(output); i++ {
+		for _, input := range inputs {
+			output[o], output[o+1] = Int16ToByte(Float32ToInt16(input[i]))
+			o += 2
+		}
+	}
+}
+
+func TestOldInterleaved(t *testing.T) {
+	const chs, frames = 6, 100
+	for _, tt := range []struct {
+		c   InterleavedConverter
+		old func([]byte, [][]float32)
+	}{
+		{Int16, oldInt16ToFloat32Interleaved},
+		{Int24, oldInt24ToFloat32Interleaved},
+	} {
+		input := randomBytes(chs * frames * tt.c.SampleSize())
+		want, got := make([][]float32, chs), make([][]float32, chs)
+		for ch := range want {
+			want[ch], got[ch] = make([]float32, frames), make([]float32, frames)
+		}
+		tt.old(input, want)
+		tt.c.ToFloat32Interleaved(input, got)
+		for ch := range want {
+			for i := range want[ch] {
+				if want[ch][i] != got[ch][i] {
+					t.Fatalf("channel %d frame %d want %v got %v", ch, i, want[ch][i], got[ch][i])
+				}
+			}
+		}
+	}
+
+	in := make([][]float32, chs)
+	for ch, p := range randomFloat64(chs, frames) {
+		in[ch] = make([]float32, frames)
+		Float64ToFloat32Slice(p, in[ch])
+	}
+	want, got := make([]byte, chs*frames*2), make([]byte, chs*frames*2)
+	oldInt16FromFloat32Interleaved(in, want)
+	Int16.FromFloat32Interleaved(in, got)
+	if string(want) != string(got) {
+		t.Error("Int16.FromFloat32Interleaved differs from the former conversion")
+	}
+}
+
+const benchFrames = 4096
+
+func benchmarkToFloat32Interleaved(b *testing.B, f func([]byte, [][]float32), size, chs int) {
+	input := randomBytes(chs * benchFrames * size)
+	outputs := make([][]float32, chs)
+	for ch := range outputs {
+		outputs[ch] = make([]float32, benchFrames)
+	}
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f(input, outputs)
+	}
+}
+
+func BenchmarkToFloat32Interleaved(b *testing.B) {
+	for _, cv := range converters {
+		for _, chs := range []int{2, 8, 32} {
+			b.Run(fmt.Sprintf("%s/%dch", cv.name, chs), func(b *testing.B) {
+				benchmarkToFloat32Interleaved(b, cv.c.ToFloat32Interleaved, cv.c.SampleSize(), chs)
+			})
+		}
+	}
+	for _, chs := range []int{2, 8, 32} {
+		b.Run(fmt.Sprintf("OldInt16/%dch", chs), func(b *testing.B) {
+			benchmarkToFloat32Interleaved(b, oldInt16ToFloat32Interleaved, 2, chs)
+		})
+		b.Run(fmt.Sprintf("OldInt24/%dch", chs), func(b *testing.B) {
+			benchmarkToFloat32Interleaved(b, oldInt24ToFloat32Interleaved, 3, chs)
+		})
+	}
+}
+
+func BenchmarkToFloat64Interleaved(b *testing.B) {
+	for _, cv := range converters {
+		b.Run(fmt.Sprintf("%s/8ch", cv.name), func(b *testing.B) {
+			const chs = 8
+			input := randomBytes(chs * benchFrames * cv.c.SampleSize())
+			outputs := make([][]float64, chs)
+			for ch := range outputs {
+				outputs[ch] = make([]float64, benchFrames)
+			}
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cv.c.ToFloat64Interleaved(input, outputs)
+			}
+		})
+	}
+}
+
+func benchmarkFromFloat32Interleaved(b *testing.B, f func([][]float32, []byte), size, chs int) {
+	inputs := make([][]float32, chs)
+	for ch, p := range randomFloat64(chs, benchFrames) {
+		inputs[ch] = make([]float32, benchFrames)
+		Float64ToFloat32Slice(p, inputs[ch])
+	}
+	output := make([]byte, chs*benchFrames*size)
+	b.SetBytes(int64(len(output)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f(inputs, output)
+	}
+}
+
+func BenchmarkFromFloat32Interleaved(b *testing.B) {
+	for _, cv := range converters {
+		for _, chs := range []int{2, 8, 32} {
+			b.Run(fmt.Sprintf("%s/%dch", cv.name, chs), func(b *testing.B) {
+				benchmarkFromFloat32Interleaved(b, cv.c.FromFloat32Interleaved, cv.c.SampleSize(), chs)
+			})
+		}
+	}
+	for _, chs := range []int{2, 8, 32} {
+		b.Run(fmt.Sprintf("OldInt16/%dch", chs), func(b *testing.B) {
+			benchmarkFromFloat32Interleaved(b, oldInt16FromFloat32Interleaved, 2, chs)
+		})
+	}
+}
+
+func BenchmarkToFloat64(b *testing.B) {
+	for _, cv := range converters {
+		b.Run(cv.name, func(b *testing.B) {
+			input := randomBytes(benchFrames * cv.c.SampleSize())
+			output := make([]float64, benchFrames)
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cv.c.ToFloat64(input, output)
+			}
+		})
+	}
+}
+
+func BenchmarkFromFloat64(b *testing.B) {
+	for _, cv := range converters {
+		b.Run(cv.name, func(b *testing.B) {
+			input := randomFloat64(1, benchFrames)[0]
+			output := make([]byte, benchFrames*cv.c.SampleSize())
+			b.SetBytes(int64(len(output)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cv.c.FromFloat64(input, output)
+			}
+		})
+	}
+}
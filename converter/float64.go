@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"encoding/binary"
 	"math"
 )
 
@@ -15,69 +16,229 @@ func (c Float64Converter) SampleSize() int {
 }
 
 func (c Float64Converter) ToFloat32(input []byte, output []float32) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Float64ToFloat32(ByteToFloat64(input[i], input[i+1], input[i+2], input[i+3], input[i+4], input[i+5], input[i+6], input[i+7]))
-		o++
+	n := len(input) / 8
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*8:i*8+32:i*8+32], output[i:i+4:i+4]
+		o[0] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[0:])))
+		o[1] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[8:])))
+		o[2] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[16:])))
+		o[3] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[24:])))
+	}
+	for ; i < n; i++ {
+		b := input[i*8:]
+		output[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[0:])))
 	}
 }
 
 func (c Float64Converter) ToFloat64(input []byte, output []float64) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = ByteToFloat64(input[i], input[i+1], input[i+2], input[i+3], input[i+4], input[i+5], input[i+6], input[i+7])
-		o++
+	n := len(input) / 8
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*8:i*8+32:i*8+32], output[i:i+4:i+4]
+		o[0] = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+		o[1] = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+		o[2] = math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+		o[3] = math.Float64frombits(binary.LittleEndian.Uint64(b[24:]))
+	}
+	for ; i < n; i++ {
+		b := input[i*8:]
+		output[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
 	}
 }
 
 func (c Float64Converter) FromFloat32(input []float32, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2], output[o+3], output[o+4], output[o+5], output[o+6], output[o+7] = Float64ToByte(Float32ToFloat64(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*8]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*8:i*8+32:i*8+32]
+		binary.LittleEndian.PutUint64(b[0:], math.Float64bits(float64(p[0])))
+		binary.LittleEndian.PutUint64(b[8:], math.Float64bits(float64(p[1])))
+		binary.LittleEndian.PutUint64(b[16:], math.Float64bits(float64(p[2])))
+		binary.LittleEndian.PutUint64(b[24:], math.Float64bits(float64(p[3])))
+	}
+	for ; i < n; i++ {
+		b := output[i*8:]
+		binary.LittleEndian.PutUint64(b[0:], math.Float64bits(float64(input[i])))
 	}
 }
 
 func (c Float64Converter) FromFloat64(input []float64, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2], output[o+3], output[o+4], output[o+5], output[o+6], output[o+7] = Float64ToByte(s)
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*8]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*8:i*8+32:i*8+32]
+		binary.LittleEndian.PutUint64(b[0:], math.Float64bits(p[0]))
+		binary.LittleEndian.PutUint64(b[8:], math.Float64bits(p[1]))
+		binary.LittleEndian.PutUint64(b[16:], math.Float64bits(p[2]))
+		binary.LittleEndian.PutUint64(b[24:], math.Float64bits(p[3]))
+	}
+	for ; i < n; i++ {
+		b := output[i*8:]
+		binary.LittleEndian.PutUint64(b[0:], math.Float64bits(input[i]))
 	}
 }
 
 func (c Float64Converter) ToFloat32Interleaved(input []byte, outputs [][]float32) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Float64ToFloat32(ByteToFloat64(input[i], input[i+1], input[i+2], input[i+3], input[i+4], input[i+5], input[i+6], input[i+7]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat32(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 16
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*16 : i*16+16 : i*16+16]
+			l[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[0:])))
+			r[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[8:])))
+		}
+		return
+	}
+	size := len(outputs) * 8
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*8
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[j:])))
+				d[1] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[j+size:])))
+				d[2] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[j+size*2:])))
+				d[3] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[j+size*3:])))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b[j:])))
+			}
 		}
 	}
 }
 
 func (c Float64Converter) ToFloat64Interleaved(input []byte, outputs [][]float64) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = ByteToFloat64(input[i], input[i+1], input[i+2], input[i+3], input[i+4], input[i+5], input[i+6], input[i+7])
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat64(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 16
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*16 : i*16+16 : i*16+16]
+			l[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+			r[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+		}
+		return
+	}
+	size := len(outputs) * 8
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*8
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = math.Float64frombits(binary.LittleEndian.Uint64(b[j:]))
+				d[1] = math.Float64frombits(binary.LittleEndian.Uint64(b[j+size:]))
+				d[2] = math.Float64frombits(binary.LittleEndian.Uint64(b[j+size*2:]))
+				d[3] = math.Float64frombits(binary.LittleEndian.Uint64(b[j+size*3:]))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[j:]))
+			}
 		}
 	}
 }
 
 func (c Float64Converter) FromFloat32Interleaved(inputs [][]float32, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2], output[o+3], output[o+4], output[o+5], output[o+6], output[o+7] = Float64ToByte(Float32ToFloat64(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat32(inputs[0][:len(output)/8], output)
+		return
+	case 2:
+		n := len(output) / 16
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*16 : i*16+16 : i*16+16]
+			binary.LittleEndian.PutUint64(b[0:], math.Float64bits(float64(l[i])))
+			binary.LittleEndian.PutUint64(b[8:], math.Float64bits(float64(r[i])))
+		}
+		return
+	}
+	size := len(inputs) * 8
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*8
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				binary.LittleEndian.PutUint64(b[j:], math.Float64bits(float64(s[0])))
+				binary.LittleEndian.PutUint64(b[j+size:], math.Float64bits(float64(s[1])))
+				binary.LittleEndian.PutUint64(b[j+size*2:], math.Float64bits(float64(s[2])))
+				binary.LittleEndian.PutUint64(b[j+size*3:], math.Float64bits(float64(s[3])))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				binary.LittleEndian.PutUint64(b[j:], math.Float64bits(float64(p[i])))
+			}
 		}
 	}
 }
 
 func (c Float64Converter) FromFloat64Interleaved(inputs [][]float64, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2], output[o+3], output[o+4], output[o+5], output[o+6], output[o+7] = Float64ToByte(input[i])
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat64(inputs[0][:len(output)/8], output)
+		return
+	case 2:
+		n := len(output) / 16
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*16 : i*16+16 : i*16+16]
+			binary.LittleEndian.PutUint64(b[0:], math.Float64bits(l[i]))
+			binary.LittleEndian.PutUint64(b[8:], math.Float64bits(r[i]))
+		}
+		return
+	}
+	size := len(inputs) * 8
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*8
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				binary.LittleEndian.PutUint64(b[j:], math.Float64bits(s[0]))
+				binary.LittleEndian.PutUint64(b[j+size:], math.Float64bits(s[1]))
+				binary.LittleEndian.PutUint64(b[j+size*2:], math.Float64bits(s[2]))
+				binary.LittleEndian.PutUint64(b[j+size*3:], math.Float64bits(s[3]))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				binary.LittleEndian.PutUint64(b[j:], math.Float64bits(p[i]))
+			}
 		}
 	}
 }
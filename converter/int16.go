@@ -1,5 +1,9 @@
 package converter
 
+import (
+	"encoding/binary"
+)
+
 var (
 	Int16 Int16Converter
 )
@@ -11,69 +15,229 @@ func (c Int16Converter) SampleSize() int {
 }
 
 func (c Int16Converter) ToFloat32(input []byte, output []float32) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int16ToFloat32(ByteToInt16(input[i], input[i+1]))
-		o++
+	n := len(input) / 2
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*2:i*2+8:i*2+8], output[i:i+4:i+4]
+		o[0] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[0:])))
+		o[1] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[2:])))
+		o[2] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[4:])))
+		o[3] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[6:])))
+	}
+	for ; i < n; i++ {
+		b := input[i*2:]
+		output[i] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[0:])))
 	}
 }
 
 func (c Int16Converter) ToFloat32Interleaved(input []byte, outputs [][]float32) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int16ToFloat32(ByteToInt16(input[i], input[i+1]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat32(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 4
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*4 : i*4+4 : i*4+4]
+			l[i] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[0:])))
+			r[i] = Int16ToFloat32(int16(binary.LittleEndian.Uint16(b[2:])))
+		}
+		return
+	}
+	size := len(outputs) * 2
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*2
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int16ToFloat32(ByteToInt16(b[j], b[j+1]))
+				d[1] = Int16ToFloat32(ByteToInt16(b[j+size], b[j+size+1]))
+				d[2] = Int16ToFloat32(ByteToInt16(b[j+size*2], b[j+size*2+1]))
+				d[3] = Int16ToFloat32(ByteToInt16(b[j+size*3], b[j+size*3+1]))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int16ToFloat32(ByteToInt16(b[j], b[j+1]))
+			}
 		}
 	}
 }
 
 func (c Int16Converter) FromFloat32(input []float32, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1] = Int16ToByte(Float32ToInt16(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*2]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*2:i*2+8:i*2+8]
+		binary.LittleEndian.PutUint16(b[0:], uint16(Float32ToInt16(p[0])))
+		binary.LittleEndian.PutUint16(b[2:], uint16(Float32ToInt16(p[1])))
+		binary.LittleEndian.PutUint16(b[4:], uint16(Float32ToInt16(p[2])))
+		binary.LittleEndian.PutUint16(b[6:], uint16(Float32ToInt16(p[3])))
+	}
+	for ; i < n; i++ {
+		b := output[i*2:]
+		binary.LittleEndian.PutUint16(b[0:], uint16(Float32ToInt16(input[i])))
 	}
 }
 
 func (c Int16Converter) FromFloat32Interleaved(inputs [][]float32, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1] = Int16ToByte(Float32ToInt16(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat32(inputs[0][:len(output)/2], output)
+		return
+	case 2:
+		n := len(output) / 4
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*4 : i*4+4 : i*4+4]
+			binary.LittleEndian.PutUint16(b[0:], uint16(Float32ToInt16(l[i])))
+			binary.LittleEndian.PutUint16(b[2:], uint16(Float32ToInt16(r[i])))
+		}
+		return
+	}
+	size := len(inputs) * 2
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*2
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j], b[j+1] = Int16ToByte(Float32ToInt16(s[0]))
+				b[j+size], b[j+size+1] = Int16ToByte(Float32ToInt16(s[1]))
+				b[j+size*2], b[j+size*2+1] = Int16ToByte(Float32ToInt16(s[2]))
+				b[j+size*3], b[j+size*3+1] = Int16ToByte(Float32ToInt16(s[3]))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j], b[j+1] = Int16ToByte(Float32ToInt16(p[i]))
+			}
 		}
 	}
 }
 
 func (c Int16Converter) ToFloat64(input []byte, output []float64) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int16ToFloat64(ByteToInt16(input[i], input[i+1]))
-		o++
+	n := len(input) / 2
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*2:i*2+8:i*2+8], output[i:i+4:i+4]
+		o[0] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[0:])))
+		o[1] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[2:])))
+		o[2] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[4:])))
+		o[3] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[6:])))
+	}
+	for ; i < n; i++ {
+		b := input[i*2:]
+		output[i] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[0:])))
 	}
 }
 
 func (c Int16Converter) ToFloat64Interleaved(input []byte, outputs [][]float64) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int16ToFloat64(ByteToInt16(input[i], input[i+1]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat64(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 4
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*4 : i*4+4 : i*4+4]
+			l[i] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[0:])))
+			r[i] = Int16ToFloat64(int16(binary.LittleEndian.Uint16(b[2:])))
+		}
+		return
+	}
+	size := len(outputs) * 2
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*2
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int16ToFloat64(ByteToInt16(b[j], b[j+1]))
+				d[1] = Int16ToFloat64(ByteToInt16(b[j+size], b[j+size+1]))
+				d[2] = Int16ToFloat64(ByteToInt16(b[j+size*2], b[j+size*2+1]))
+				d[3] = Int16ToFloat64(ByteToInt16(b[j+size*3], b[j+size*3+1]))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int16ToFloat64(ByteToInt16(b[j], b[j+1]))
+			}
 		}
 	}
 }
 
 func (c Int16Converter) FromFloat64(input []float64, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1] = Int16ToByte(Float64ToInt16(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*2]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*2:i*2+8:i*2+8]
+		binary.LittleEndian.PutUint16(b[0:], uint16(Float64ToInt16(p[0])))
+		binary.LittleEndian.PutUint16(b[2:], uint16(Float64ToInt16(p[1])))
+		binary.LittleEndian.PutUint16(b[4:], uint16(Float64ToInt16(p[2])))
+		binary.LittleEndian.PutUint16(b[6:], uint16(Float64ToInt16(p[3])))
+	}
+	for ; i < n; i++ {
+		b := output[i*2:]
+		binary.LittleEndian.PutUint16(b[0:], uint16(Float64ToInt16(input[i])))
 	}
 }
 
 func (c Int16Converter) FromFloat64Interleaved(inputs [][]float64, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1] = Int16ToByte(Float64ToInt16(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat64(inputs[0][:len(output)/2], output)
+		return
+	case 2:
+		n := len(output) / 4
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*4 : i*4+4 : i*4+4]
+			binary.LittleEndian.PutUint16(b[0:], uint16(Float64ToInt16(l[i])))
+			binary.LittleEndian.PutUint16(b[2:], uint16(Float64ToInt16(r[i])))
+		}
+		return
+	}
+	size := len(inputs) * 2
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*2
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j], b[j+1] = Int16ToByte(Float64ToInt16(s[0]))
+				b[j+size], b[j+size+1] = Int16ToByte(Float64ToInt16(s[1]))
+				b[j+size*2], b[j+size*2+1] = Int16ToByte(Float64ToInt16(s[2]))
+				b[j+size*3], b[j+size*3+1] = Int16ToByte(Float64ToInt16(s[3]))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j], b[j+1] = Int16ToByte(Float64ToInt16(p[i]))
+			}
 		}
 	}
 }
@@ -11,69 +11,229 @@ func (c Int24Converter) SampleSize() int {
 }
 
 func (c Int24Converter) ToFloat32(input []byte, output []float32) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int24ToFloat32(ByteToInt24(input[i], input[i+1], input[i+2]))
-		o++
+	n := len(input) / 3
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*3:i*3+12:i*3+12], output[i:i+4:i+4]
+		o[0] = Int24ToFloat32(ByteToInt24(b[0], b[1], b[2]))
+		o[1] = Int24ToFloat32(ByteToInt24(b[3], b[4], b[5]))
+		o[2] = Int24ToFloat32(ByteToInt24(b[6], b[7], b[8]))
+		o[3] = Int24ToFloat32(ByteToInt24(b[9], b[10], b[11]))
+	}
+	for ; i < n; i++ {
+		b := input[i*3:]
+		output[i] = Int24ToFloat32(ByteToInt24(b[0], b[1], b[2]))
 	}
 }
 
 func (c Int24Converter) ToFloat32Interleaved(input []byte, outputs [][]float32) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int24ToFloat32(ByteToInt24(input[i], input[i+1], input[i+2]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat32(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 6
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*6 : i*6+6 : i*6+6]
+			l[i] = Int24ToFloat32(ByteToInt24(b[0], b[1], b[2]))
+			r[i] = Int24ToFloat32(ByteToInt24(b[3], b[4], b[5]))
+		}
+		return
+	}
+	size := len(outputs) * 3
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*3
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int24ToFloat32(ByteToInt24(b[j], b[j+1], b[j+2]))
+				d[1] = Int24ToFloat32(ByteToInt24(b[j+size], b[j+size+1], b[j+size+2]))
+				d[2] = Int24ToFloat32(ByteToInt24(b[j+size*2], b[j+size*2+1], b[j+size*2+2]))
+				d[3] = Int24ToFloat32(ByteToInt24(b[j+size*3], b[j+size*3+1], b[j+size*3+2]))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int24ToFloat32(ByteToInt24(b[j], b[j+1], b[j+2]))
+			}
 		}
 	}
 }
 
 func (c Int24Converter) FromFloat32(input []float32, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2] = Int24ToByte(Float32ToInt24(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*3]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*3:i*3+12:i*3+12]
+		b[0], b[1], b[2] = Int24ToByte(Float32ToInt24(p[0]))
+		b[3], b[4], b[5] = Int24ToByte(Float32ToInt24(p[1]))
+		b[6], b[7], b[8] = Int24ToByte(Float32ToInt24(p[2]))
+		b[9], b[10], b[11] = Int24ToByte(Float32ToInt24(p[3]))
+	}
+	for ; i < n; i++ {
+		b := output[i*3:]
+		b[0], b[1], b[2] = Int24ToByte(Float32ToInt24(input[i]))
 	}
 }
 
 func (c Int24Converter) FromFloat32Interleaved(inputs [][]float32, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2] = Int24ToByte(Float32ToInt24(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat32(inputs[0][:len(output)/3], output)
+		return
+	case 2:
+		n := len(output) / 6
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*6 : i*6+6 : i*6+6]
+			b[0], b[1], b[2] = Int24ToByte(Float32ToInt24(l[i]))
+			b[3], b[4], b[5] = Int24ToByte(Float32ToInt24(r[i]))
+		}
+		return
+	}
+	size := len(inputs) * 3
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*3
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j], b[j+1], b[j+2] = Int24ToByte(Float32ToInt24(s[0]))
+				b[j+size], b[j+size+1], b[j+size+2] = Int24ToByte(Float32ToInt24(s[1]))
+				b[j+size*2], b[j+size*2+1], b[j+size*2+2] = Int24ToByte(Float32ToInt24(s[2]))
+				b[j+size*3], b[j+size*3+1], b[j+size*3+2] = Int24ToByte(Float32ToInt24(s[3]))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j], b[j+1], b[j+2] = Int24ToByte(Float32ToInt24(p[i]))
+			}
 		}
 	}
 }
 
 func (c Int24Converter) ToFloat64(input []byte, output []float64) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int24ToFloat64(ByteToInt24(input[i], input[i+1], input[i+2]))
-		o++
+	n := len(input) / 3
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*3:i*3+12:i*3+12], output[i:i+4:i+4]
+		o[0] = Int24ToFloat64(ByteToInt24(b[0], b[1], b[2]))
+		o[1] = Int24ToFloat64(ByteToInt24(b[3], b[4], b[5]))
+		o[2] = Int24ToFloat64(ByteToInt24(b[6], b[7], b[8]))
+		o[3] = Int24ToFloat64(ByteToInt24(b[9], b[10], b[11]))
+	}
+	for ; i < n; i++ {
+		b := input[i*3:]
+		output[i] = Int24ToFloat64(ByteToInt24(b[0], b[1], b[2]))
 	}
 }
 
 func (c Int24Converter) ToFloat64Interleaved(input []byte, outputs [][]float64) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int24ToFloat64(ByteToInt24(input[i], input[i+1], input[i+2]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat64(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 6
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*6 : i*6+6 : i*6+6]
+			l[i] = Int24ToFloat64(ByteToInt24(b[0], b[1], b[2]))
+			r[i] = Int24ToFloat64(ByteToInt24(b[3], b[4], b[5]))
+		}
+		return
+	}
+	size := len(outputs) * 3
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*3
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int24ToFloat64(ByteToInt24(b[j], b[j+1], b[j+2]))
+				d[1] = Int24ToFloat64(ByteToInt24(b[j+size], b[j+size+1], b[j+size+2]))
+				d[2] = Int24ToFloat64(ByteToInt24(b[j+size*2], b[j+size*2+1], b[j+size*2+2]))
+				d[3] = Int24ToFloat64(ByteToInt24(b[j+size*3], b[j+size*3+1], b[j+size*3+2]))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int24ToFloat64(ByteToInt24(b[j], b[j+1], b[j+2]))
+			}
 		}
 	}
 }
 
 func (c Int24Converter) FromFloat64(input []float64, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2] = Int24ToByte(Float64ToInt24(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*3]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*3:i*3+12:i*3+12]
+		b[0], b[1], b[2] = Int24ToByte(Float64ToInt24(p[0]))
+		b[3], b[4], b[5] = Int24ToByte(Float64ToInt24(p[1]))
+		b[6], b[7], b[8] = Int24ToByte(Float64ToInt24(p[2]))
+		b[9], b[10], b[11] = Int24ToByte(Float64ToInt24(p[3]))
+	}
+	for ; i < n; i++ {
+		b := output[i*3:]
+		b[0], b[1], b[2] = Int24ToByte(Float64ToInt24(input[i]))
 	}
 }
 
 func (c Int24Converter) FromFloat64Interleaved(inputs [][]float64, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2] = Int24ToByte(Float64ToInt24(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat64(inputs[0][:len(output)/3], output)
+		return
+	case 2:
+		n := len(output) / 6
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*6 : i*6+6 : i*6+6]
+			b[0], b[1], b[2] = Int24ToByte(Float64ToInt24(l[i]))
+			b[3], b[4], b[5] = Int24ToByte(Float64ToInt24(r[i]))
+		}
+		return
+	}
+	size := len(inputs) * 3
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*3
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j], b[j+1], b[j+2] = Int24ToByte(Float64ToInt24(s[0]))
+				b[j+size], b[j+size+1], b[j+size+2] = Int24ToByte(Float64ToInt24(s[1]))
+				b[j+size*2], b[j+size*2+1], b[j+size*2+2] = Int24ToByte(Float64ToInt24(s[2]))
+				b[j+size*3], b[j+size*3+1], b[j+size*3+2] = Int24ToByte(Float64ToInt24(s[3]))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j], b[j+1], b[j+2] = Int24ToByte(Float64ToInt24(p[i]))
+			}
 		}
 	}
 }
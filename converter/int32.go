@@ -1,5 +1,9 @@
 package converter
 
+import (
+	"encoding/binary"
+)
+
 var (
 	Int32 Int32Converter
 )
@@ -11,69 +15,229 @@ func (c Int32Converter) SampleSize() int {
 }
 
 func (c Int32Converter) ToFloat32(input []byte, output []float32) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int32ToFloat32(ByteToInt32(input[i], input[i+1], input[i+2], input[i+3]))
-		o++
+	n := len(input) / 4
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*4:i*4+16:i*4+16], output[i:i+4:i+4]
+		o[0] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[0:])))
+		o[1] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[4:])))
+		o[2] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[8:])))
+		o[3] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[12:])))
+	}
+	for ; i < n; i++ {
+		b := input[i*4:]
+		output[i] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[0:])))
 	}
 }
 
 func (c Int32Converter) ToFloat32Interleaved(input []byte, outputs [][]float32) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int32ToFloat32(ByteToInt32(input[i], input[i+1], input[i+2], input[i+3]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat32(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 8
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*8 : i*8+8 : i*8+8]
+			l[i] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[0:])))
+			r[i] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[4:])))
+		}
+		return
+	}
+	size := len(outputs) * 4
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*4
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[j:])))
+				d[1] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[j+size:])))
+				d[2] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[j+size*2:])))
+				d[3] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[j+size*3:])))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int32ToFloat32(int32(binary.LittleEndian.Uint32(b[j:])))
+			}
 		}
 	}
 }
 
 func (c Int32Converter) FromFloat32(input []float32, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2], output[o+3] = Int32ToByte(Float32ToInt32(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*4]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*4:i*4+16:i*4+16]
+		binary.LittleEndian.PutUint32(b[0:], uint32(Float32ToInt32(p[0])))
+		binary.LittleEndian.PutUint32(b[4:], uint32(Float32ToInt32(p[1])))
+		binary.LittleEndian.PutUint32(b[8:], uint32(Float32ToInt32(p[2])))
+		binary.LittleEndian.PutUint32(b[12:], uint32(Float32ToInt32(p[3])))
+	}
+	for ; i < n; i++ {
+		b := output[i*4:]
+		binary.LittleEndian.PutUint32(b[0:], uint32(Float32ToInt32(input[i])))
 	}
 }
 
 func (c Int32Converter) FromFloat32Interleaved(inputs [][]float32, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2], output[o+3] = Int32ToByte(Float32ToInt32(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat32(inputs[0][:len(output)/4], output)
+		return
+	case 2:
+		n := len(output) / 8
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*8 : i*8+8 : i*8+8]
+			binary.LittleEndian.PutUint32(b[0:], uint32(Float32ToInt32(l[i])))
+			binary.LittleEndian.PutUint32(b[4:], uint32(Float32ToInt32(r[i])))
+		}
+		return
+	}
+	size := len(inputs) * 4
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*4
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				binary.LittleEndian.PutUint32(b[j:], uint32(Float32ToInt32(s[0])))
+				binary.LittleEndian.PutUint32(b[j+size:], uint32(Float32ToInt32(s[1])))
+				binary.LittleEndian.PutUint32(b[j+size*2:], uint32(Float32ToInt32(s[2])))
+				binary.LittleEndian.PutUint32(b[j+size*3:], uint32(Float32ToInt32(s[3])))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				binary.LittleEndian.PutUint32(b[j:], uint32(Float32ToInt32(p[i])))
+			}
 		}
 	}
 }
 
 func (c Int32Converter) ToFloat64(input []byte, output []float64) {
-	for i, o, ln := 0, 0, len(input); i < ln; i += c.SampleSize() {
-		output[o] = Int32ToFloat64(ByteToInt32(input[i], input[i+1], input[i+2], input[i+3]))
-		o++
+	n := len(input) / 4
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i*4:i*4+16:i*4+16], output[i:i+4:i+4]
+		o[0] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[0:])))
+		o[1] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[4:])))
+		o[2] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[8:])))
+		o[3] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[12:])))
+	}
+	for ; i < n; i++ {
+		b := input[i*4:]
+		output[i] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[0:])))
 	}
 }
 
 func (c Int32Converter) ToFloat64Interleaved(input []byte, outputs [][]float64) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch*c.SampleSize(), 0, len(input); i < ln; i += chs * c.SampleSize() {
-			output[o] = Int32ToFloat64(ByteToInt32(input[i], input[i+1], input[i+2], input[i+3]))
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat64(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 8
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*8 : i*8+8 : i*8+8]
+			l[i] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[0:])))
+			r[i] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[4:])))
+		}
+		return
+	}
+	size := len(outputs) * 4
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch*4
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[j:])))
+				d[1] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[j+size:])))
+				d[2] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[j+size*2:])))
+				d[3] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[j+size*3:])))
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Int32ToFloat64(int32(binary.LittleEndian.Uint32(b[j:])))
+			}
 		}
 	}
 }
 
 func (c Int32Converter) FromFloat64(input []float64, output []byte) {
-	o := 0
-	for _, s := range input {
-		output[o], output[o+1], output[o+2], output[o+3] = Int32ToByte(Float64ToInt32(s))
-		o += c.SampleSize()
+	n := len(input)
+	output = output[:n*4]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i*4:i*4+16:i*4+16]
+		binary.LittleEndian.PutUint32(b[0:], uint32(Float64ToInt32(p[0])))
+		binary.LittleEndian.PutUint32(b[4:], uint32(Float64ToInt32(p[1])))
+		binary.LittleEndian.PutUint32(b[8:], uint32(Float64ToInt32(p[2])))
+		binary.LittleEndian.PutUint32(b[12:], uint32(Float64ToInt32(p[3])))
+	}
+	for ; i < n; i++ {
+		b := output[i*4:]
+		binary.LittleEndian.PutUint32(b[0:], uint32(Float64ToInt32(input[i])))
 	}
 }
 
 func (c Int32Converter) FromFloat64Interleaved(inputs [][]float64, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o], output[o+1], output[o+2], output[o+3] = Int32ToByte(Float64ToInt32(input[i]))
-			o += c.SampleSize()
+	switch len(inputs) {
+	case 1:
+		c.FromFloat64(inputs[0][:len(output)/4], output)
+		return
+	case 2:
+		n := len(output) / 8
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*8 : i*8+8 : i*8+8]
+			binary.LittleEndian.PutUint32(b[0:], uint32(Float64ToInt32(l[i])))
+			binary.LittleEndian.PutUint32(b[4:], uint32(Float64ToInt32(r[i])))
+		}
+		return
+	}
+	size := len(inputs) * 4
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch*4
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				binary.LittleEndian.PutUint32(b[j:], uint32(Float64ToInt32(s[0])))
+				binary.LittleEndian.PutUint32(b[j+size:], uint32(Float64ToInt32(s[1])))
+				binary.LittleEndian.PutUint32(b[j+size*2:], uint32(Float64ToInt32(s[2])))
+				binary.LittleEndian.PutUint32(b[j+size*3:], uint32(Float64ToInt32(s[3])))
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				binary.LittleEndian.PutUint32(b[j:], uint32(Float64ToInt32(p[i])))
+			}
 		}
 	}
 }
@@ -11,63 +11,229 @@ func (c Uint8Converter) SampleSize() int {
 }
 
 func (c Uint8Converter) ToFloat32(input []byte, output []float32) {
-	for i, s := range input {
-		output[i] = Uint8ToFloat32(s)
+	n := len(input) / 1
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i:i+4:i+4], output[i:i+4:i+4]
+		o[0] = Uint8ToFloat32(b[0])
+		o[1] = Uint8ToFloat32(b[1])
+		o[2] = Uint8ToFloat32(b[2])
+		o[3] = Uint8ToFloat32(b[3])
+	}
+	for ; i < n; i++ {
+		b := input[i:]
+		output[i] = Uint8ToFloat32(b[0])
 	}
 }
 
 func (c Uint8Converter) ToFloat32Interleaved(input []byte, outputs [][]float32) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch, 0, len(input); i < ln; i += chs {
-			output[o] = Uint8ToFloat32(input[i])
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat32(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 2
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*2 : i*2+2 : i*2+2]
+			l[i] = Uint8ToFloat32(b[0])
+			r[i] = Uint8ToFloat32(b[1])
+		}
+		return
+	}
+	size := len(outputs)
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Uint8ToFloat32(b[j])
+				d[1] = Uint8ToFloat32(b[j+size])
+				d[2] = Uint8ToFloat32(b[j+size*2])
+				d[3] = Uint8ToFloat32(b[j+size*3])
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Uint8ToFloat32(b[j])
+			}
 		}
 	}
 }
 
 func (c Uint8Converter) FromFloat32(input []float32, output []byte) {
-	for i, s := range input {
-		output[i] = Float32ToUint8(s)
+	n := len(input)
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i:i+4:i+4]
+		b[0] = Float32ToUint8(p[0])
+		b[1] = Float32ToUint8(p[1])
+		b[2] = Float32ToUint8(p[2])
+		b[3] = Float32ToUint8(p[3])
+	}
+	for ; i < n; i++ {
+		b := output[i:]
+		b[0] = Float32ToUint8(input[i])
 	}
 }
 
 func (c Uint8Converter) FromFloat32Interleaved(inputs [][]float32, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o] = Float32ToUint8(input[i])
-			o++
+	switch len(inputs) {
+	case 1:
+		c.FromFloat32(inputs[0][:len(output)], output)
+		return
+	case 2:
+		n := len(output) / 2
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*2 : i*2+2 : i*2+2]
+			b[0] = Float32ToUint8(l[i])
+			b[1] = Float32ToUint8(r[i])
+		}
+		return
+	}
+	size := len(inputs)
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j] = Float32ToUint8(s[0])
+				b[j+size] = Float32ToUint8(s[1])
+				b[j+size*2] = Float32ToUint8(s[2])
+				b[j+size*3] = Float32ToUint8(s[3])
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j] = Float32ToUint8(p[i])
+			}
 		}
 	}
 }
 
 func (c Uint8Converter) ToFloat64(input []byte, output []float64) {
-	for i, s := range input {
-		output[i] = Uint8ToFloat64(s)
+	n := len(input) / 1
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		b, o := input[i:i+4:i+4], output[i:i+4:i+4]
+		o[0] = Uint8ToFloat64(b[0])
+		o[1] = Uint8ToFloat64(b[1])
+		o[2] = Uint8ToFloat64(b[2])
+		o[3] = Uint8ToFloat64(b[3])
+	}
+	for ; i < n; i++ {
+		b := input[i:]
+		output[i] = Uint8ToFloat64(b[0])
 	}
 }
 
 func (c Uint8Converter) ToFloat64Interleaved(input []byte, outputs [][]float64) {
-	chs := len(outputs)
-	for ch, output := range outputs {
-		for i, o, ln := ch, 0, len(input); i < ln; i += chs {
-			output[o] = Uint8ToFloat64(input[i])
-			o++
+	switch len(outputs) {
+	case 1:
+		c.ToFloat64(input, outputs[0])
+		return
+	case 2:
+		n := len(input) / 2
+		l, r := outputs[0][:n], outputs[1][:n]
+		for i := range l {
+			b := input[i*2 : i*2+2 : i*2+2]
+			l[i] = Uint8ToFloat64(b[0])
+			r[i] = Uint8ToFloat64(b[1])
+		}
+		return
+	}
+	size := len(outputs)
+	frames := len(input) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := input[start*size : end*size]
+		for ch, output := range outputs {
+			o := output[start:end]
+			i, j := 0, ch
+			for ; i+4 <= len(o); i, j = i+4, j+size*4 {
+				d := o[i : i+4 : i+4]
+				d[0] = Uint8ToFloat64(b[j])
+				d[1] = Uint8ToFloat64(b[j+size])
+				d[2] = Uint8ToFloat64(b[j+size*2])
+				d[3] = Uint8ToFloat64(b[j+size*3])
+			}
+			for ; i < len(o); i, j = i+1, j+size {
+				o[i] = Uint8ToFloat64(b[j])
+			}
 		}
 	}
 }
 
 func (c Uint8Converter) FromFloat64(input []float64, output []byte) {
-	for i, s := range input {
-		output[i] = Float64ToUint8(s)
+	n := len(input)
+	output = output[:n]
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		p, b := input[i:i+4:i+4], output[i:i+4:i+4]
+		b[0] = Float64ToUint8(p[0])
+		b[1] = Float64ToUint8(p[1])
+		b[2] = Float64ToUint8(p[2])
+		b[3] = Float64ToUint8(p[3])
+	}
+	for ; i < n; i++ {
+		b := output[i:]
+		b[0] = Float64ToUint8(input[i])
 	}
 }
 
 func (c Uint8Converter) FromFloat64Interleaved(inputs [][]float64, output []byte) {
-	for i, o := 0, 0; o < len(output); i++ {
-		for _, input := range inputs {
-			output[o] = Float64ToUint8(input[i])
-			o++
+	switch len(inputs) {
+	case 1:
+		c.FromFloat64(inputs[0][:len(output)], output)
+		return
+	case 2:
+		n := len(output) / 2
+		l, r := inputs[0][:n], inputs[1][:n]
+		for i := range l {
+			b := output[i*2 : i*2+2 : i*2+2]
+			b[0] = Float64ToUint8(l[i])
+			b[1] = Float64ToUint8(r[i])
+		}
+		return
+	}
+	size := len(inputs)
+	frames := len(output) / size
+	for start := 0; start < frames; start += blockFrames {
+		end := start + blockFrames
+		if end > frames {
+			end = frames
+		}
+		b := output[start*size : end*size]
+		for ch, input := range inputs {
+			p := input[start:end]
+			i, j := 0, ch
+			for ; i+4 <= len(p); i, j = i+4, j+size*4 {
+				s := p[i : i+4 : i+4]
+				b[j] = Float64ToUint8(s[0])
+				b[j+size] = Float64ToUint8(s[1])
+				b[j+size*2] = Float64ToUint8(s[2])
+				b[j+size*3] = Float64ToUint8(s[3])
+			}
+			for ; i < len(p); i, j = i+1, j+size {
+				b[j] = Float64ToUint8(p[i])
+			}
 		}
 	}
 }
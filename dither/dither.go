@@ -0,0 +1,82 @@
+// Package dither implements dither which is added before reducing the bit depth.
+//
+// The noise is scaled to the least significant bit of the integer formats in
+// the converter package. The converters truncate toward zero, which makes the step
+// around zero 2 LSB wide and leaves distortion that the dither does not remove,
+// so round the dithered signal to the nearest step before converting it.
+// The transcode package rounds, and dithers by itself.
+package dither
+
+import (
+	"math/rand"
+)
+
+// Mode selects the probability density function of the noise.
+type Mode int
+
+const (
+	TPDF Mode = iota // triangular, 2 LSB peak to peak
+	RPDF             // rectangular, 1 LSB peak to peak
+)
+
+// Dither is a noise source for the bit depth.
+type Dither struct {
+	mode Mode
+	lsb  float64
+	rnd  *rand.Rand
+}
+
+// New returns a Dither for bits of integer precision.
+// The same seed produces the same noise.
+func New(bits int, mode Mode, seed int64) *Dither {
+	if bits < 2 || bits > 32 {
+		panic("bits must be between 2 and 32")
+	}
+	return &Dither{
+		mode: mode,
+		lsb:  1 / float64(uint64(1)<<uint(bits-1)-1),
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// LSB returns the size of the least significant bit.
+func (d *Dither) LSB() float64 {
+	return d.lsb
+}
+
+func (d *Dither) noise() float64 {
+	if d.mode == RPDF {
+		return (d.rnd.Float64() - 0.5) * d.lsb
+	}
+	return (d.rnd.Float64() - d.rnd.Float64()) * d.lsb
+}
+
+// ProcessFloat64 adds the noise to p in place.
+func (d *Dither) ProcessFloat64(p []float64) {
+	for i := range p {
+		p[i] += d.noise()
+	}
+}
+
+// ProcessFloat32 adds the noise to p in place.
+func (d *Dither) ProcessFloat32(p []float32) {
+	for i := range p {
+		p[i] += float32(d.noise())
+	}
+}
+
+// ProcessFloat64Interleaved adds the noise to all channels of p in place.
+// p[i] is the buffer of channel i.
+func (d *Dither) ProcessFloat64Interleaved(p [][]float64) {
+	for _, b := range p {
+		d.ProcessFloat64(b)
+	}
+}
+
+// ProcessFloat32Interleaved adds the noise to all channels of p in place.
+// p[i] is the buffer of channel i.
+func (d *Dither) ProcessFloat32Interleaved(p [][]float32) {
+	for _, b := range p {
+		d.ProcessFloat32(b)
+	}
+}
@@ -0,0 +1,49 @@
+package dither
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDither(t *testing.T) {
+	for _, mode := range []Mode{TPDF, RPDF} {
+		d := New(16, mode, 1)
+		if d.LSB() != 1.0/32767 {
+			t.Fatal("lsb:", d.LSB())
+		}
+		p := make([]float64, 100000)
+		d.ProcessFloat64(p)
+		limit := d.LSB()
+		if mode == RPDF {
+			limit /= 2
+		}
+		var sum, sq float64
+		for _, v := range p {
+			if math.Abs(v) > limit {
+				t.Fatal("mode:", mode, "noise out of range:", v)
+			}
+			sum += v
+			sq += v * v
+		}
+		mean, rms := sum/float64(len(p)), math.Sqrt(sq/float64(len(p)))/d.LSB()
+		// the variance is 1/12 LSB^2 for RPDF and 1/6 LSB^2 for TPDF
+		expected := math.Sqrt(1.0 / 12)
+		if mode == TPDF {
+			expected = math.Sqrt(1.0 / 6)
+		}
+		if math.Abs(mean) > d.LSB()*0.01 || math.Abs(rms-expected) > 0.01 {
+			t.Error("mode:", mode, "mean:", mean, "rms:", rms, "expected:", expected)
+		}
+
+		a, b := New(24, mode, 42), New(24, mode, 42)
+		p32 := [][]float32{make([]float32, 16)}
+		p64 := [][]float64{make([]float64, 16)}
+		a.ProcessFloat32Interleaved(p32)
+		b.ProcessFloat64Interleaved(p64)
+		for i := range p32[0] {
+			if p32[0][i] != float32(p64[0][i]) {
+				t.Fatal("the same seed must produce the same noise")
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package dynamics implements compressors, downward expanders and noise gates.
+//
+// All processors work on planar buffers, p[i] is the buffer of channel i,
+// and keep independent state for every channel.
+package dynamics
+
+import (
+	"math"
+)
+
+// Detector selects how the level of the sidechain signal is measured.
+type Detector int
+
+const (
+	Peak Detector = iota // instantaneous absolute value
+	RMS                  // root mean square over Options.Window
+)
+
+// Options configures a Processor.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	Threshold float64  // threshold in dBFS
+	Ratio     float64  // ratio, e.g. 4 for 4:1 (default 4 for compressors and expanders)
+	Knee      float64  // knee width in dB
+	Attack    float64  // attack time in seconds (default 0.01)
+	Release   float64  // release time in seconds (default 0.1)
+	Makeup    float64  // makeup gain in dB
+	Range     float64  // maximum gain reduction in dB for expanders and gates (default 80)
+	Detector  Detector // level detector
+	Window    float64  // RMS window in seconds (default 0.05)
+}
+
+type mode int
+
+const (
+	compressor mode = iota
+	expander
+	gate
+)
+
+// Processor is a stateful multi-channel dynamic range processor.
+type Processor struct {
+	mode      mode
+	threshold float64
+	ratio     float64
+	knee      float64
+	makeup    float64
+	floor     float64
+	detector  Detector
+	attack    float64
+	release   float64
+	window    float64
+
+	levels []float64 // mean square for RMS detector
+	gains  []float64 // current gain reduction in dB
+}
+
+// NewCompressor returns a Processor that reduces the level above the threshold.
+func NewCompressor(channels, sampleRate int, opts Options) *Processor {
+	return newProcessor(compressor, channels, sampleRate, opts)
+}
+
+// NewExpander returns a Processor that reduces the level below the threshold.
+func NewExpander(channels, sampleRate int, opts Options) *Processor {
+	return newProcessor(expander, channels, sampleRate, opts)
+}
+
+// NewGate returns a Processor that mutes the signal below the threshold by Range.
+// Ratio is ignored.
+func NewGate(channels, sampleRate int, opts Options) *Processor {
+	return newProcessor(gate, channels, sampleRate, opts)
+}
+
+func timeConstant(t float64, sampleRate int) float64 {
+	return 1 - math.Exp(-1/(t*float64(sampleRate)))
+}
+
+func newProcessor(m mode, channels, sampleRate int, opts Options) *Processor {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	if opts.Ratio < 1 {
+		opts.Ratio = 4
+	}
+	if m == gate {
+		opts.Ratio = math.Inf(1)
+	}
+	if opts.Attack <= 0 {
+		opts.Attack = 0.01
+	}
+	if opts.Release <= 0 {
+		opts.Release = 0.1
+	}
+	if opts.Range <= 0 {
+		opts.Range = 80
+	}
+	if opts.Window <= 0 {
+		opts.Window = 0.05
+	}
+	return &Processor{
+		mode:      m,
+		threshold: opts.Threshold,
+		ratio:     opts.Ratio,
+		knee:      math.Max(opts.Knee, 0),
+		makeup:    opts.Makeup,
+		floor:     -opts.Range,
+		detector:  opts.Detector,
+		attack:    timeConstant(opts.Attack, sampleRate),
+		release:   timeConstant(opts.Release, sampleRate),
+		window:    timeConstant(opts.Window, sampleRate),
+		levels:    make([]float64, channels),
+		gains:     make([]float64, channels),
+	}
+}
+
+// Reset clears the state of all channels.
+func (p *Processor) Reset() {
+	for i := range p.levels {
+		p.levels[i] = 0
+		p.gains[i] = 0
+	}
+}
+
+// GainReduction returns the current gain reduction of the channel in dB.
+// The value is zero or negative and does not include the makeup gain.
+func (p *Processor) GainReduction(channelIndex int) float64 {
+	return p.gains[channelIndex]
+}
+
+// Curve returns the static output level in dB for the input level x in dB.
+func (p *Processor) Curve(x float64) float64 {
+	t, w := p.threshold, p.knee
+	var y float64
+	switch p.mode {
+	case compressor:
+		switch {
+		case w > 0 && 2*math.Abs(x-t) <= w:
+			d := x - t + w/2
+			y = x + (1/p.ratio-1)*d*d/(2*w)
+		case x > t:
+			y = t + (x-t)/p.ratio
+		default:
+			y = x
+		}
+	default:
+		switch {
+		case w > 0 && 2*math.Abs(x-t) <= w:
+			if math.IsInf(p.ratio, 1) {
+				y = math.Inf(-1)
+			} else {
+				d := x - t - w/2
+				y = x - (p.ratio-1)*d*d/(2*w)
+			}
+		case x < t:
+			if math.IsInf(p.ratio, 1) {
+				y = math.Inf(-1)
+			} else {
+				y = t + (x-t)*p.ratio
+			}
+		default:
+			y = x
+		}
+		if y-x < p.floor {
+			y = x + p.floor
+		}
+	}
+	return y
+}
+
+func (p *Processor) process(ch int, s, side float64) float64 {
+	var level float64
+	switch p.detector {
+	case RMS:
+		p.levels[ch] += (side*side - p.levels[ch]) * p.window
+		level = 10 * math.Log10(p.levels[ch]+1e-30)
+	default:
+		level = 20 * math.Log10(math.Abs(side)+1e-30)
+	}
+
+	target := p.Curve(level) - level
+	g := p.gains[ch]
+	// attack moves the gain away from unity, release moves it back
+	switch {
+	case p.mode == compressor && target < g, p.mode != compressor && target > g:
+		g += (target - g) * p.attack
+	default:
+		g += (target - g) * p.release
+	}
+	p.gains[ch] = g
+	return s * math.Pow(10, (g+p.makeup)/20)
+}
+
+// ProcessFloat64 processes buf in place.
+// The level is detected from sidechain, or from buf itself when sidechain is nil.
+// A sidechain with fewer channels than buf is repeated, so a mono key can drive all channels.
+func (p *Processor) ProcessFloat64(buf [][]float64, sidechain [][]float64) {
+	if sidechain == nil {
+		sidechain = buf
+	}
+	for ch, s := range buf {
+		side := sidechain[ch%len(sidechain)]
+		for i, v := range s {
+			s[i] = p.process(ch, v, side[i])
+		}
+	}
+}
+
+// ProcessFloat32 processes buf in place.
+// The level is detected from sidechain, or from buf itself when sidechain is nil.
+// A sidechain with fewer channels than buf is repeated, so a mono key can drive all channels.
+func (p *Processor) ProcessFloat32(buf [][]float32, sidechain [][]float32) {
+	if sidechain == nil {
+		sidechain = buf
+	}
+	for ch, s := range buf {
+		side := sidechain[ch%len(sidechain)]
+		for i, v := range s {
+			s[i] = float32(p.process(ch, float64(v), float64(side[i])))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(n int, amp float64) []float64 {
+	p := make([]float64, n)
+	for i := range p {
+		p[i] = amp * math.Sin(2*math.Pi*1000*float64(i)/48000)
+	}
+	return p
+}
+
+func rmsDB(p []float64) float64 {
+	var sum float64
+	for _, s := range p {
+		sum += s * s
+	}
+	return 10 * math.Log10(sum/float64(len(p)))
+}
+
+func TestCurve(t *testing.T) {
+	c := NewCompressor(1, 48000, Options{Threshold: -20, Ratio: 4, Knee: 6})
+	for _, v := range [][2]float64{{-40, -40}, {-23, -23}, {-20, -20.5625}, {0, -15}, {-17, -19.25}} {
+		if y := c.Curve(v[0]); math.Abs(y-v[1]) > 1e-9 {
+			t.Error("compressor:", v[0], y, v[1])
+		}
+	}
+	e := NewExpander(1, 48000, Options{Threshold: -40, Ratio: 2, Range: 30})
+	for _, v := range [][2]float64{{0, 0}, {-40, -40}, {-50, -60}, {-80, -110}} {
+		if y := e.Curve(v[0]); math.Abs(y-v[1]) > 1e-9 {
+			t.Error("expander:", v[0], y, v[1])
+		}
+	}
+	g := NewGate(1, 48000, Options{Threshold: -40, Range: 60})
+	if y := g.Curve(-41); y != -101 {
+		t.Error("gate:", y)
+	}
+}
+
+func TestZeroKnee(t *testing.T) {
+	for i, p := range []*Processor{
+		NewCompressor(1, 48000, Options{}),
+		NewExpander(1, 48000, Options{}),
+	} {
+		if y := p.Curve(0); y != 0 {
+			t.Errorf("tests[%d] curve at the threshold want 0 got %v", i, y)
+		}
+		// a full scale sample is exactly at the default threshold
+		buf := []float64{1, 0.5, -0.25, 0}
+		p.ProcessFloat64([][]float64{buf}, nil)
+		for j, v := range buf {
+			if math.IsNaN(v) {
+				t.Errorf("tests[%d] buf[%d] is NaN", i, j)
+			}
+		}
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	for _, d := range []Detector{Peak, RMS} {
+		c := NewCompressor(1, 48000, Options{Threshold: -20, Ratio: 4, Detector: d, Attack: 0.001})
+		p := sine(48000, 1)
+		c.ProcessFloat64([][]float64{p}, nil)
+		// RMS level of the input is -3dB, so -20 + 17/4 = -15.75dB is expected with the RMS detector.
+		level := rmsDB(p[24000:])
+		switch d {
+		case RMS:
+			if math.Abs(level - -15.75) > 0.5 {
+				t.Error("rms:", level)
+			}
+		case Peak:
+			if level > -14 || level < -20 {
+				t.Error("peak:", level)
+			}
+		}
+	}
+}
+
+func TestGate(t *testing.T) {
+	g := NewGate(2, 48000, Options{Threshold: -30, Range: 60, Detector: RMS})
+	quiet, loud := sine(48000, 0.001), sine(48000, 0.5)
+	p := [][]float32{make([]float32, len(quiet)), make([]float32, len(loud))}
+	for i := range quiet {
+		p[0][i], p[1][i] = float32(quiet[i]), float32(loud[i])
+	}
+	g.ProcessFloat32(p, nil)
+	if r := g.GainReduction(0); r > -59 {
+		t.Error("quiet channel must be closed:", r)
+	}
+	if r := g.GainReduction(1); r < -0.1 {
+		t.Error("loud channel must be open:", r)
+	}
+}
+
+func TestSidechain(t *testing.T) {
+	c := NewCompressor(2, 48000, Options{Threshold: -30, Ratio: 10, Detector: RMS})
+	p := [][]float64{sine(4800, 0.1), sine(4800, 0.1)}
+	key := [][]float64{sine(4800, 1)}
+	c.ProcessFloat64(p, key)
+	if c.GainReduction(0) > -20 || c.GainReduction(1) > -20 {
+		t.Error("gain reduction:", c.GainReduction(0), c.GainReduction(1))
+	}
+}
@@ -0,0 +1,301 @@
+// Package fft implements fast Fourier transforms of arbitrary lengths.
+//
+// Lengths made of small prime factors are transformed by mixed-radix
+// Cooley-Tukey butterflies (radix 2, 3, 4 and generic odd radices);
+// other lengths use Bluestein's algorithm.
+//
+// Plans hold their own work buffers, so a Plan never allocates after it was created,
+// but it must not be used from multiple goroutines at once.
+package fft
+
+import (
+	"math"
+)
+
+// maxRadix is the largest prime factor which is transformed by the generic butterfly.
+const maxRadix = 31
+
+// Plan is a precomputed complex transform of a fixed length.
+type Plan struct {
+	n        int
+	factors  []int // pairs of radix and the remaining length
+	twiddles []complex128
+	scratch  []complex128
+	buf      []complex128
+	buf2     []complex128
+	blue     *bluestein
+}
+
+type bluestein struct {
+	m      int
+	plan   *Plan
+	chirp  []complex128
+	filter []complex128
+	a, b   []complex128
+}
+
+func factorize(n int) (factors []int, ok bool) {
+	m := n
+	for m > 1 {
+		var p int
+		switch {
+		case m%4 == 0:
+			p = 4
+		case m%2 == 0:
+			p = 2
+		default:
+			p = 3
+			for m%p != 0 {
+				p += 2
+				if p*p > m {
+					p = m
+				}
+			}
+		}
+		if p > maxRadix {
+			return nil, false
+		}
+		m /= p
+		factors = append(factors, p, m)
+	}
+	return factors, true
+}
+
+func twiddle(k, n int) complex128 {
+	s, c := math.Sincos(-2 * math.Pi * float64(k) / float64(n))
+	return complex(c, s)
+}
+
+// NewPlan returns a Plan for the transform of length n.
+func NewPlan(n int) *Plan {
+	if n < 1 {
+		panic("fft: invalid length")
+	}
+	p := &Plan{
+		n:    n,
+		buf:  make([]complex128, n),
+		buf2: make([]complex128, n),
+	}
+
+	factors, ok := factorize(n)
+	if !ok {
+		p.blue = newBluestein(n)
+		return p
+	}
+	p.factors = factors
+	maxFactor := 0
+	for i := 0; i < len(factors); i += 2 {
+		if factors[i] > maxFactor {
+			maxFactor = factors[i]
+		}
+	}
+	p.scratch = make([]complex128, maxFactor)
+	p.twiddles = make([]complex128, n)
+	for k := range p.twiddles {
+		p.twiddles[k] = twiddle(k, n)
+	}
+	return p
+}
+
+func newBluestein(n int) *bluestein {
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	b := &bluestein{
+		m:      m,
+		plan:   NewPlan(m),
+		chirp:  make([]complex128, n),
+		filter: make([]complex128, m),
+		a:      make([]complex128, m),
+		b:      make([]complex128, m),
+	}
+	for k := range b.chirp {
+		// k*k can be reduced modulo 2n to keep the precision
+		kk := int64(k) * int64(k) % int64(2*n)
+		s, c := math.Sincos(-math.Pi * float64(kk) / float64(n))
+		b.chirp[k] = complex(c, s)
+	}
+	b.a[0] = conj(b.chirp[0])
+	for k := 1; k < n; k++ {
+		b.a[k] = conj(b.chirp[k])
+		b.a[m-k] = conj(b.chirp[k])
+	}
+	b.plan.transform(b.filter, b.a)
+	return b
+}
+
+func conj(c complex128) complex128 {
+	return complex(real(c), -imag(c))
+}
+
+// Len returns the length of the transform.
+func (p *Plan) Len() int {
+	return p.n
+}
+
+// transform computes the forward transform, dst and src must not overlap.
+func (p *Plan) transform(dst, src []complex128) {
+	switch {
+	case p.blue != nil:
+		p.blue.transform(dst, src)
+	case p.n == 1:
+		dst[0] = src[0]
+	default:
+		p.work(dst, src, 1, p.factors)
+	}
+}
+
+func (b *bluestein) transform(dst, src []complex128) {
+	n := len(b.chirp)
+	for k, c := range b.chirp {
+		b.a[k] = src[k] * c
+	}
+	for k := n; k < b.m; k++ {
+		b.a[k] = 0
+	}
+	b.plan.transform(b.b, b.a)
+	for k, f := range b.filter {
+		b.b[k] = conj(b.b[k] * f)
+	}
+	b.plan.transform(b.a, b.b)
+	scale := 1 / float64(b.m)
+	for k, c := range b.chirp {
+		v := conj(b.a[k])
+		dst[k] = complex(real(v)*scale, imag(v)*scale) * c
+	}
+}
+
+func (p *Plan) work(out []complex128, in []complex128, fstride int, factors []int) {
+	radix, m := factors[0], factors[1]
+	if m == 1 {
+		for j := 0; j < radix; j++ {
+			out[j] = in[j*fstride]
+		}
+	} else {
+		for j := 0; j < radix; j++ {
+			p.work(out[j*m:], in[j*fstride:], fstride*radix, factors[2:])
+		}
+	}
+
+	switch radix {
+	case 2:
+		p.butterfly2(out, fstride, m)
+	case 3:
+		p.butterfly3(out, fstride, m)
+	case 4:
+		p.butterfly4(out, fstride, m)
+	default:
+		p.butterflyGeneric(out, fstride, m, radix)
+	}
+}
+
+func (p *Plan) butterfly2(out []complex128, fstride, m int) {
+	tw := p.twiddles
+	a, b := out[:m], out[m:2*m]
+	for k := range a {
+		t := b[k] * tw[k*fstride]
+		b[k] = a[k] - t
+		a[k] += t
+	}
+}
+
+func (p *Plan) butterfly3(out []complex128, fstride, m int) {
+	tw := p.twiddles
+	epi3 := imag(tw[fstride*m])
+	for k := 0; k < m; k++ {
+		s1 := out[k+m] * tw[k*fstride]
+		s2 := out[k+2*m] * tw[2*k*fstride]
+		s3 := s1 + s2
+		s0 := s1 - s2
+		f0 := out[k]
+		f1 := f0 - complex(real(s3)*0.5, imag(s3)*0.5)
+		s0 = complex(real(s0)*epi3, imag(s0)*epi3)
+		out[k] = f0 + s3
+		out[k+2*m] = complex(real(f1)+imag(s0), imag(f1)-real(s0))
+		out[k+m] = complex(real(f1)-imag(s0), imag(f1)+real(s0))
+	}
+}
+
+func (p *Plan) butterfly4(out []complex128, fstride, m int) {
+	tw := p.twiddles
+	for k := 0; k < m; k++ {
+		s0 := out[k+m] * tw[k*fstride]
+		s1 := out[k+2*m] * tw[2*k*fstride]
+		s2 := out[k+3*m] * tw[3*k*fstride]
+		s5 := out[k] - s1
+		f0 := out[k] + s1
+		s3 := s0 + s2
+		s4 := s0 - s2
+		out[k+2*m] = f0 - s3
+		out[k] = f0 + s3
+		out[k+m] = complex(real(s5)+imag(s4), imag(s5)-real(s4))
+		out[k+3*m] = complex(real(s5)-imag(s4), imag(s5)+real(s4))
+	}
+}
+
+func (p *Plan) butterflyGeneric(out []complex128, fstride, m, radix int) {
+	tw, scratch, n := p.twiddles, p.scratch[:radix], p.n
+	for u := 0; u < m; u++ {
+		for q, k := 0, u; q < radix; q, k = q+1, k+m {
+			scratch[q] = out[k]
+		}
+		for q1, k := 0, u; q1 < radix; q1, k = q1+1, k+m {
+			t, sum := 0, scratch[0]
+			for q := 1; q < radix; q++ {
+				if t += fstride * k; t >= n {
+					t %= n
+				}
+				sum += scratch[q] * tw[t]
+			}
+			out[k] = sum
+		}
+	}
+}
+
+// ForwardComplex128 computes the forward transform of src and stores it to dst.
+// dst and src may be the same slice.
+func (p *Plan) ForwardComplex128(dst, src []complex128) {
+	copy(p.buf, src[:p.n])
+	p.transform(dst[:p.n], p.buf)
+}
+
+// InverseComplex128 computes the inverse transform of src and stores it to dst.
+// The result is scaled by 1/n, so it restores the input of ForwardComplex128.
+// dst and src may be the same slice.
+func (p *Plan) InverseComplex128(dst, src []complex128) {
+	for i, v := range src[:p.n] {
+		p.buf[i] = conj(v)
+	}
+	p.transform(dst[:p.n], p.buf)
+	scale := 1 / float64(p.n)
+	for i, v := range dst[:p.n] {
+		dst[i] = complex(real(v)*scale, -imag(v)*scale)
+	}
+}
+
+// ForwardComplex64 computes the forward transform of src and stores it to dst.
+// dst and src may be the same slice.
+func (p *Plan) ForwardComplex64(dst, src []complex64) {
+	for i, v := range src[:p.n] {
+		p.buf[i] = complex128(v)
+	}
+	p.transform(p.buf2, p.buf)
+	for i, v := range p.buf2 {
+		dst[i] = complex64(v)
+	}
+}
+
+// InverseComplex64 computes the inverse transform of src and stores it to dst.
+// The result is scaled by 1/n, so it restores the input of ForwardComplex64.
+// dst and src may be the same slice.
+func (p *Plan) InverseComplex64(dst, src []complex64) {
+	for i, v := range src[:p.n] {
+		p.buf[i] = conj(complex128(v))
+	}
+	p.transform(p.buf2, p.buf)
+	scale := 1 / float64(p.n)
+	for i, v := range p.buf2 {
+		dst[i] = complex64(complex(real(v)*scale, -imag(v)*scale))
+	}
+}
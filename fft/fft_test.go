@@ -0,0 +1,151 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+var lengths = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 15, 16, 17, 30, 31, 37, 64, 97, 100, 127, 210, 256, 480, 1000, 1021, 1024}
+
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	r := make([]complex128, n)
+	for k := range r {
+		var sum complex128
+		for j, v := range x {
+			sum += v * cmplx.Rect(1, -2*math.Pi*float64(j*k%n)/float64(n))
+		}
+		r[k] = sum
+	}
+	return r
+}
+
+func random(n int, rnd *rand.Rand) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rnd.Float64()*2-1, rnd.Float64()*2-1)
+	}
+	return x
+}
+
+func maxError(a, b []complex128) float64 {
+	var e float64
+	for i := range a {
+		e = math.Max(e, cmplx.Abs(a[i]-b[i]))
+	}
+	return e
+}
+
+func TestPlan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range lengths {
+		x := random(n, rnd)
+		expected := dft(x)
+
+		p := NewPlan(n)
+		y := make([]complex128, n)
+		p.ForwardComplex128(y, x)
+		if e := maxError(y, expected); e > 1e-9*float64(n) {
+			t.Error("length:", n, "forward error:", e)
+		}
+
+		p.InverseComplex128(y, y)
+		if e := maxError(y, x); e > 1e-12*float64(n) {
+			t.Error("length:", n, "inverse error:", e)
+		}
+
+		x64 := make([]complex64, n)
+		for i, v := range x {
+			x64[i] = complex64(v)
+		}
+		p.ForwardComplex64(x64, x64)
+		for i, v := range x64 {
+			y[i] = complex128(v)
+		}
+		if e := maxError(y, expected); e > 1e-5*float64(n) {
+			t.Error("length:", n, "complex64 forward error:", e)
+		}
+	}
+}
+
+func TestRealPlan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for _, n := range lengths {
+		x := make([]float64, n)
+		cx := make([]complex128, n)
+		for i := range x {
+			x[i] = rnd.Float64()*2 - 1
+			cx[i] = complex(x[i], 0)
+		}
+		expected := dft(cx)[:n/2+1]
+
+		p := NewRealPlan(n)
+		y := make([]complex128, n/2+1)
+		p.ForwardFloat64(y, x)
+		if e := maxError(y, expected); e > 1e-9*float64(n) {
+			t.Error("length:", n, "forward error:", e)
+		}
+
+		z := make([]float64, n)
+		p.InverseFloat64(z, y)
+		for i := range z {
+			if math.Abs(z[i]-x[i]) > 1e-12*float64(n) {
+				t.Fatal("length:", n, "inverse error at", i, z[i], x[i])
+			}
+		}
+
+		x32, y32 := make([]float32, n), make([]complex64, n/2+1)
+		for i, v := range x {
+			x32[i] = float32(v)
+		}
+		p.ForwardFloat32(y32, x32)
+		for i, v := range y32 {
+			y[i] = complex128(v)
+		}
+		if e := maxError(y, expected); e > 1e-5*float64(n) {
+			t.Error("length:", n, "float32 forward error:", e)
+		}
+		p.InverseFloat32(x32, y32)
+		for i := range x32 {
+			if math.Abs(float64(x32[i])-x[i]) > 1e-5 {
+				t.Fatal("length:", n, "float32 inverse error at", i, x32[i], x[i])
+			}
+		}
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	for _, n := range []int{1024, 1000, 1021} {
+		p, rp := NewPlan(n), NewRealPlan(n)
+		x := make([]complex128, n)
+		r := make([]float64, n)
+		allocs := testing.AllocsPerRun(10, func() {
+			p.ForwardComplex128(x, x)
+			p.InverseComplex128(x, x)
+			rp.ForwardFloat64(x, r)
+			rp.InverseFloat64(r, x)
+		})
+		if allocs != 0 {
+			t.Error("length:", n, "allocs:", allocs)
+		}
+	}
+}
+
+func BenchmarkPlan1024(b *testing.B) {
+	p := NewPlan(1024)
+	x := make([]complex128, 1024)
+	for i := 0; i < b.N; i++ {
+		p.ForwardComplex128(x, x)
+	}
+}
+
+func BenchmarkRealPlan1024(b *testing.B) {
+	p := NewRealPlan(1024)
+	x := make([]float64, 1024)
+	y := make([]complex128, 513)
+	for i := 0; i < b.N; i++ {
+		p.ForwardFloat64(y, x)
+	}
+}
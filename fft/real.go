@@ -0,0 +1,159 @@
+package fft
+
+// RealPlan is a precomputed transform of real input of a fixed length.
+// The spectrum of n real samples has n/2+1 complex bins.
+type RealPlan struct {
+	n    int
+	half *Plan // n/2 points for even n
+	full *Plan // n points for odd n
+	tw   []complex128
+	in   []complex128
+	out  []complex128
+	spec []complex128
+}
+
+// NewRealPlan returns a RealPlan for the transform of length n.
+func NewRealPlan(n int) *RealPlan {
+	if n < 1 {
+		panic("fft: invalid length")
+	}
+	p := &RealPlan{
+		n:    n,
+		spec: make([]complex128, n/2+1),
+	}
+	if n%2 != 0 {
+		p.full = NewPlan(n)
+		p.in = make([]complex128, n)
+		p.out = make([]complex128, n)
+		return p
+	}
+	h := n / 2
+	p.half = NewPlan(h)
+	p.tw = make([]complex128, h+1)
+	for k := range p.tw {
+		p.tw[k] = twiddle(k, n)
+	}
+	p.in = make([]complex128, h)
+	p.out = make([]complex128, h)
+	return p
+}
+
+// Len returns the number of real samples of the transform.
+func (p *RealPlan) Len() int {
+	return p.n
+}
+
+// forward computes n/2+1 bins to dst from the input in p.in.
+// For even n, p.in holds the even samples as real part and the odd samples as imaginary part.
+func (p *RealPlan) forward(dst []complex128) {
+	if p.full != nil {
+		p.full.transform(p.out, p.in)
+		copy(dst[:p.n/2+1], p.out)
+		return
+	}
+	h := p.n / 2
+	p.half.transform(p.out, p.in)
+	z := p.out
+	for k := 0; k <= h; k++ {
+		zk, zn := z[k%h], conj(z[(h-k)%h])
+		e := (zk + zn) * 0.5
+		o := (zk - zn) * complex(0, -0.5)
+		dst[k] = e + p.tw[k]*o
+	}
+}
+
+// inverse computes the output to p.out from n/2+1 bins of src.
+// For even n, p.out holds the even samples as real part and the odd samples as imaginary part.
+func (p *RealPlan) inverse(src []complex128) {
+	if p.full != nil {
+		h := p.n / 2
+		for k := 0; k <= h; k++ {
+			p.in[k] = conj(src[k])
+		}
+		for k := h + 1; k < p.n; k++ {
+			p.in[k] = src[p.n-k]
+		}
+		p.full.transform(p.out, p.in)
+		scale := 1 / float64(p.n)
+		for k, v := range p.out {
+			p.out[k] = complex(real(v)*scale, 0)
+		}
+		return
+	}
+	h := p.n / 2
+	for k := 0; k < h; k++ {
+		xk, xn := src[k], conj(src[h-k])
+		e := (xk + xn) * 0.5
+		o := (xk - xn) * 0.5 * conj(p.tw[k])
+		p.in[k] = conj(e + complex(-imag(o), real(o)))
+	}
+	p.half.transform(p.out, p.in)
+	scale := 1 / float64(h)
+	for k, v := range p.out {
+		p.out[k] = complex(real(v)*scale, -imag(v)*scale)
+	}
+}
+
+// ForwardFloat64 computes the spectrum of src and stores n/2+1 bins to dst.
+func (p *RealPlan) ForwardFloat64(dst []complex128, src []float64) {
+	if p.full != nil {
+		for i, v := range src[:p.n] {
+			p.in[i] = complex(v, 0)
+		}
+	} else {
+		for k := range p.in {
+			p.in[k] = complex(src[2*k], src[2*k+1])
+		}
+	}
+	p.forward(dst)
+}
+
+// InverseFloat64 computes n real samples from n/2+1 bins of src and stores them to dst.
+// The result is scaled by 1/n, so it restores the input of ForwardFloat64.
+func (p *RealPlan) InverseFloat64(dst []float64, src []complex128) {
+	p.inverse(src)
+	if p.full != nil {
+		for i, v := range p.out {
+			dst[i] = real(v)
+		}
+		return
+	}
+	for k, v := range p.out {
+		dst[2*k], dst[2*k+1] = real(v), imag(v)
+	}
+}
+
+// ForwardFloat32 computes the spectrum of src and stores n/2+1 bins to dst.
+func (p *RealPlan) ForwardFloat32(dst []complex64, src []float32) {
+	if p.full != nil {
+		for i, v := range src[:p.n] {
+			p.in[i] = complex(float64(v), 0)
+		}
+	} else {
+		for k := range p.in {
+			p.in[k] = complex(float64(src[2*k]), float64(src[2*k+1]))
+		}
+	}
+	p.forward(p.spec)
+	for i, v := range p.spec {
+		dst[i] = complex64(v)
+	}
+}
+
+// InverseFloat32 computes n real samples from n/2+1 bins of src and stores them to dst.
+// The result is scaled by 1/n, so it restores the input of ForwardFloat32.
+func (p *RealPlan) InverseFloat32(dst []float32, src []complex64) {
+	for i, v := range src[:len(p.spec)] {
+		p.spec[i] = complex128(v)
+	}
+	p.inverse(p.spec)
+	if p.full != nil {
+		for i, v := range p.out {
+			dst[i] = float32(real(v))
+		}
+		return
+	}
+	for k, v := range p.out {
+		dst[2*k], dst[2*k+1] = float32(real(v)), float32(imag(v))
+	}
+}
@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Coefficients is a second order section normalized so that a0 is 1.
+//
+//	H(z) = (B0 + B1 z^-1 + B2 z^-2) / (1 + A1 z^-1 + A2 z^-2)
+//
+// First order sections have B2 and A2 set to zero.
+type Coefficients struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+func normalize(b0, b1, b2, a0, a1, a2 float64) Coefficients {
+	return Coefficients{
+		B0: b0 / a0,
+		B1: b1 / a0,
+		B2: b2 / a0,
+		A1: a1 / a0,
+		A2: a2 / a0,
+	}
+}
+
+// Response returns the complex frequency response of the section at freq Hz.
+func (c Coefficients) Response(sampleRate int, freq float64) complex128 {
+	z1 := cmplx.Exp(complex(0, -2*math.Pi*freq/float64(sampleRate)))
+	z2 := z1 * z1
+	num := complex(c.B0, 0) + complex(c.B1, 0)*z1 + complex(c.B2, 0)*z2
+	den := 1 + complex(c.A1, 0)*z1 + complex(c.A2, 0)*z2
+	return num / den
+}
+
+// Response returns the complex frequency response of the cascade at freq Hz.
+func Response(sections []Coefficients, sampleRate int, freq float64) complex128 {
+	r := complex(1, 0)
+	for _, c := range sections {
+		r *= c.Response(sampleRate, freq)
+	}
+	return r
+}
+
+// rbj returns the intermediate values shared by the cookbook formulas.
+func rbj(sampleRate int, freq, q float64) (cosw, alpha float64) {
+	w := 2 * math.Pi * freq / float64(sampleRate)
+	return math.Cos(w), math.Sin(w) / (2 * q)
+}
+
+// Lowpass returns a second order lowpass section from the RBJ cookbook.
+func Lowpass(sampleRate int, freq, q float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	return normalize((1-c)/2, 1-c, (1-c)/2, 1+a, -2*c, 1-a)
+}
+
+// Highpass returns a second order highpass section from the RBJ cookbook.
+func Highpass(sampleRate int, freq, q float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	return normalize((1+c)/2, -(1 + c), (1+c)/2, 1+a, -2*c, 1-a)
+}
+
+// Bandpass returns a second order bandpass section with 0 dB peak gain from the RBJ cookbook.
+func Bandpass(sampleRate int, freq, q float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	return normalize(a, 0, -a, 1+a, -2*c, 1-a)
+}
+
+// Notch returns a second order notch section from the RBJ cookbook.
+func Notch(sampleRate int, freq, q float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	return normalize(1, -2*c, 1, 1+a, -2*c, 1-a)
+}
+
+// Allpass returns a second order allpass section from the RBJ cookbook.
+func Allpass(sampleRate int, freq, q float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	return normalize(1-a, -2*c, 1+a, 1+a, -2*c, 1-a)
+}
+
+// Peaking returns a peaking EQ section from the RBJ cookbook.
+// gain is in dB.
+func Peaking(sampleRate int, freq, q, gain float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	A := math.Pow(10, gain/40)
+	return normalize(1+a*A, -2*c, 1-a*A, 1+a/A, -2*c, 1-a/A)
+}
+
+// LowShelf returns a low shelf section from the RBJ cookbook.
+// gain is in dB.
+func LowShelf(sampleRate int, freq, q, gain float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	A := math.Pow(10, gain/40)
+	s := 2 * math.Sqrt(A) * a
+	return normalize(
+		A*((A+1)-(A-1)*c+s),
+		2*A*((A-1)-(A+1)*c),
+		A*((A+1)-(A-1)*c-s),
+		(A+1)+(A-1)*c+s,
+		-2*((A-1)+(A+1)*c),
+		(A+1)+(A-1)*c-s,
+	)
+}
+
+// HighShelf returns a high shelf section from the RBJ cookbook.
+// gain is in dB.
+func HighShelf(sampleRate int, freq, q, gain float64) Coefficients {
+	c, a := rbj(sampleRate, freq, q)
+	A := math.Pow(10, gain/40)
+	s := 2 * math.Sqrt(A) * a
+	return normalize(
+		A*((A+1)+(A-1)*c+s),
+		-2*A*((A-1)+(A+1)*c),
+		A*((A+1)+(A-1)*c-s),
+		(A+1)-(A-1)*c+s,
+		2*((A-1)-(A+1)*c),
+		(A+1)-(A-1)*c-s,
+	)
+}
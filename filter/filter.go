@@ -0,0 +1,119 @@
+// Package filter implements IIR filters built from cascaded second order sections.
+//
+// Section coefficients are designed with the functions in this package,
+// such as the RBJ cookbook designs or the Butterworth, Chebyshev and
+// Linkwitz-Riley cascades, and applied with a Filter which keeps
+// independent state for every channel.
+package filter
+
+// Form selects the structure used to evaluate each section.
+type Form int
+
+const (
+	// TransposedDirectFormII needs two state variables per section
+	// and has good numerical behaviour in floating point.
+	TransposedDirectFormII Form = iota
+	// DirectFormI needs four state variables per section
+	// and is robust against coefficient changes while running.
+	DirectFormI
+)
+
+// Filter is a stateful multi-channel cascade of second order sections.
+type Filter struct {
+	form     Form
+	sections []Coefficients
+	states   [][]float64
+}
+
+// New returns a Filter which applies sections in order.
+func New(channels int, form Form, sections ...Coefficients) *Filter {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	f := &Filter{
+		form:     form,
+		sections: append([]Coefficients(nil), sections...),
+		states:   make([][]float64, channels),
+	}
+	for i := range f.states {
+		f.states[i] = make([]float64, 4*len(sections))
+	}
+	return f
+}
+
+// Sections returns the coefficients of the filter.
+func (f *Filter) Sections() []Coefficients {
+	return f.sections
+}
+
+// SetSections replaces the coefficients without clearing the state.
+// The number of sections must not change.
+func (f *Filter) SetSections(sections ...Coefficients) {
+	if len(sections) != len(f.sections) {
+		panic("number of sections must not change")
+	}
+	copy(f.sections, sections)
+}
+
+// Reset clears the state of all channels.
+func (f *Filter) Reset() {
+	for _, st := range f.states {
+		for i := range st {
+			st[i] = 0
+		}
+	}
+}
+
+func (f *Filter) process(st []float64, x float64) float64 {
+	switch f.form {
+	case DirectFormI:
+		for i, c := range f.sections {
+			s := st[i*4 : i*4+4]
+			y := c.B0*x + c.B1*s[0] + c.B2*s[1] - c.A1*s[2] - c.A2*s[3]
+			s[1], s[0] = s[0], x
+			s[3], s[2] = s[2], y
+			x = y
+		}
+	default:
+		for i, c := range f.sections {
+			s := st[i*4 : i*4+2]
+			y := c.B0*x + s[0]
+			s[0] = c.B1*x - c.A1*y + s[1]
+			s[1] = c.B2*x - c.A2*y
+			x = y
+		}
+	}
+	return x
+}
+
+// ProcessFloat64 filters p of the channel in place.
+func (f *Filter) ProcessFloat64(channelIndex int, p []float64) {
+	st := f.states[channelIndex]
+	for i, s := range p {
+		p[i] = f.process(st, s)
+	}
+}
+
+// ProcessFloat32 filters p of the channel in place.
+func (f *Filter) ProcessFloat32(channelIndex int, p []float32) {
+	st := f.states[channelIndex]
+	for i, s := range p {
+		p[i] = float32(f.process(st, float64(s)))
+	}
+}
+
+// ProcessFloat64Interleaved filters all channels in place.
+// p[i] is the buffer of channel i.
+func (f *Filter) ProcessFloat64Interleaved(p [][]float64) {
+	for ch, b := range p {
+		f.ProcessFloat64(ch, b)
+	}
+}
+
+// ProcessFloat32Interleaved filters all channels in place.
+// p[i] is the buffer of channel i.
+func (f *Filter) ProcessFloat32Interleaved(p [][]float32) {
+	for ch, b := range p {
+		f.ProcessFloat32(ch, b)
+	}
+}
@@ -0,0 +1,153 @@
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+const sampleRate = 48000
+
+func dB(sections []Coefficients, freq float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(Response(sections, sampleRate, freq)))
+}
+
+func TestRBJ(t *testing.T) {
+	tests := []struct {
+		name     string
+		c        Coefficients
+		freq, dB float64
+	}{
+		{"lowpass dc", Lowpass(sampleRate, 1000, math.Sqrt2/2), 0, 0},
+		{"lowpass cutoff", Lowpass(sampleRate, 1000, math.Sqrt2/2), 1000, -3.0103},
+		{"highpass nyquist", Highpass(sampleRate, 1000, math.Sqrt2/2), sampleRate / 2, 0},
+		{"highpass cutoff", Highpass(sampleRate, 1000, math.Sqrt2/2), 1000, -3.0103},
+		{"bandpass center", Bandpass(sampleRate, 1000, 2), 1000, 0},
+		{"allpass", Allpass(sampleRate, 1000, 2), 3000, 0},
+		{"peaking center", Peaking(sampleRate, 1000, 1, 6), 1000, 6},
+		{"peaking dc", Peaking(sampleRate, 1000, 1, 6), 0, 0},
+		{"low shelf dc", LowShelf(sampleRate, 1000, math.Sqrt2/2, -12), 0, -12},
+		{"low shelf nyquist", LowShelf(sampleRate, 1000, math.Sqrt2/2, -12), sampleRate / 2, 0},
+		{"high shelf nyquist", HighShelf(sampleRate, 1000, math.Sqrt2/2, 9), sampleRate / 2, 9},
+		{"high shelf dc", HighShelf(sampleRate, 1000, math.Sqrt2/2, 9), 0, 0},
+	}
+	for _, tc := range tests {
+		if d := dB([]Coefficients{tc.c}, tc.freq); math.Abs(d-tc.dB) > 1e-3 {
+			t.Error(tc.name, "expected:", tc.dB, "got:", d)
+		}
+	}
+	if r := cmplx.Abs(Notch(sampleRate, 1000, 2).Response(sampleRate, 1000)); r > 1e-9 {
+		t.Error("notch center:", r)
+	}
+}
+
+func TestCascades(t *testing.T) {
+	for order := 1; order <= 8; order++ {
+		lp := ButterworthLowpass(order, sampleRate, 2000)
+		hp := ButterworthHighpass(order, sampleRate, 2000)
+		if len(lp) != (order+1)/2 {
+			t.Error("order:", order, "sections:", len(lp))
+		}
+		for _, tc := range []struct {
+			name     string
+			sections []Coefficients
+			freq, dB float64
+		}{
+			{"butterworth lowpass dc", lp, 0, 0},
+			{"butterworth lowpass cutoff", lp, 2000, -3.0103},
+			{"butterworth highpass nyquist", hp, sampleRate / 2, 0},
+			{"butterworth highpass cutoff", hp, 2000, -3.0103},
+			{"chebyshev1 lowpass edge", Chebyshev1Lowpass(order, sampleRate, 2000, 1), 2000, -1},
+			{"chebyshev1 highpass edge", Chebyshev1Highpass(order, sampleRate, 2000, 1), 2000, -1},
+			{"chebyshev2 lowpass dc", Chebyshev2Lowpass(order, sampleRate, 2000, 40), 0, 0},
+			{"chebyshev2 lowpass edge", Chebyshev2Lowpass(order, sampleRate, 2000, 40), 2000, -40},
+			{"chebyshev2 highpass nyquist", Chebyshev2Highpass(order, sampleRate, 2000, 40), sampleRate / 2, 0},
+			{"chebyshev2 highpass edge", Chebyshev2Highpass(order, sampleRate, 2000, 40), 2000, -40},
+		} {
+			if d := dB(tc.sections, tc.freq); math.Abs(d-tc.dB) > 1e-3 {
+				t.Error(tc.name, "order:", order, "expected:", tc.dB, "got:", d)
+			}
+		}
+		// passband ripple of chebyshev type I stays within [-ripple, 0]
+		c1 := Chebyshev1Lowpass(order, sampleRate, 2000, 1)
+		for f := 0.0; f < 2000; f += 10 {
+			if d := dB(c1, f); d > 1e-9 || d < -1-1e-9 {
+				t.Error("chebyshev1 ripple order:", order, "freq:", f, "dB:", d)
+				break
+			}
+		}
+	}
+
+	for order := 2; order <= 8; order += 2 {
+		lp := LinkwitzRileyLowpass(order, sampleRate, 2000)
+		hp := LinkwitzRileyHighpass(order, sampleRate, 2000)
+		if d := dB(lp, 2000); math.Abs(d - -6.0206) > 1e-3 {
+			t.Error("linkwitz-riley lowpass order:", order, "dB:", d)
+		}
+		if d := dB(hp, 2000); math.Abs(d - -6.0206) > 1e-3 {
+			t.Error("linkwitz-riley highpass order:", order, "dB:", d)
+		}
+		// the sum of both bands is allpass
+		for _, f := range []float64{100, 1000, 2000, 5000, 15000} {
+			s := Response(lp, sampleRate, f) + Response(hp, sampleRate, f)
+			if order%4 == 2 {
+				s = Response(lp, sampleRate, f) - Response(hp, sampleRate, f)
+			}
+			if math.Abs(cmplx.Abs(s)-1) > 1e-6 {
+				t.Error("linkwitz-riley sum order:", order, "freq:", f, "magnitude:", cmplx.Abs(s))
+			}
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	sections := Chebyshev1Lowpass(5, sampleRate, 3000, 0.5)
+	for _, form := range []Form{TransposedDirectFormII, DirectFormI} {
+		for _, freq := range []float64{500, 3000, 6000} {
+			f := New(2, form, sections...)
+			p := [][]float64{make([]float64, 9600), make([]float64, 9600)}
+			for i := range p[0] {
+				p[0][i] = math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+			}
+			f.ProcessFloat64Interleaved(p)
+			var sum float64
+			for _, s := range p[0][4800:] {
+				sum += s * s
+			}
+			amp := math.Sqrt(2 * sum / 4800)
+			if expected := cmplx.Abs(Response(sections, sampleRate, freq)); math.Abs(amp-expected) > 1e-3 {
+				t.Error("form:", form, "freq:", freq, "expected:", expected, "got:", amp)
+			}
+			for _, s := range p[1] {
+				if s != 0 {
+					t.Fatal("channels must be independent")
+				}
+			}
+		}
+	}
+}
+
+func TestFilter32(t *testing.T) {
+	sections := []Coefficients{Peaking(sampleRate, 1000, 1, 6), HighShelf(sampleRate, 8000, 0.7, -3)}
+	a, b := New(1, TransposedDirectFormII, sections...), New(1, DirectFormI, sections...)
+	p64 := make([]float64, 1024)
+	p32 := make([]float32, 1024)
+	p64[0], p32[0] = 1, 1
+	a.ProcessFloat64(0, p64)
+	b.ProcessFloat32(0, p32)
+	for i := range p64 {
+		if math.Abs(p64[i]-float64(p32[i])) > 1e-6 {
+			t.Fatal("impulse response mismatch at", i, p64[i], p32[i])
+		}
+	}
+
+	a.Reset()
+	q := make([]float64, 1024)
+	q[0] = 1
+	a.ProcessFloat64(0, q)
+	for i := range p64 {
+		if p64[i] != q[i] {
+			t.Fatal("reset mismatch at", i)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package filter
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// The prototypes below return the poles and zeros of an analog lowpass filter
+// normalized to 1 rad/s. poles[k] and poles[n-1-k] are complex conjugates,
+// and so are zeros[k] and zeros[n-1-k]. A zero at infinity is cmplx.Inf().
+
+func butterworthPrototype(n int) (poles, zeros []complex128) {
+	poles, zeros = make([]complex128, n), make([]complex128, n)
+	for k := range poles {
+		poles[k] = cmplx.Exp(complex(0, math.Pi*float64(2*k+n+1)/float64(2*n)))
+		zeros[k] = cmplx.Inf()
+	}
+	return poles, zeros
+}
+
+func chebyshevPoles(n int, eps float64) []complex128 {
+	mu := math.Asinh(1/eps) / float64(n)
+	poles := make([]complex128, n)
+	for k := range poles {
+		t := math.Pi * float64(2*k+1) / float64(2*n)
+		poles[k] = complex(-math.Sinh(mu)*math.Sin(t), math.Cosh(mu)*math.Cos(t))
+	}
+	return poles
+}
+
+func chebyshev1Prototype(n int, ripple float64) (poles, zeros []complex128) {
+	poles, zeros = chebyshevPoles(n, math.Sqrt(math.Pow(10, ripple/10)-1)), make([]complex128, n)
+	for k := range zeros {
+		zeros[k] = cmplx.Inf()
+	}
+	return poles, zeros
+}
+
+func chebyshev2Prototype(n int, attenuation float64) (poles, zeros []complex128) {
+	poles, zeros = chebyshevPoles(n, 1/math.Sqrt(math.Pow(10, attenuation/10)-1)), make([]complex128, n)
+	for k := range poles {
+		poles[k] = 1 / poles[k]
+		if n&1 == 1 && k == n/2 {
+			zeros[k] = cmplx.Inf()
+			continue
+		}
+		zeros[k] = complex(0, 1/math.Cos(math.Pi*float64(2*k+1)/float64(2*n)))
+	}
+	return poles, zeros
+}
+
+// bilinear maps the prototype to a digital lowpass or highpass filter with
+// the cutoff at freq Hz, and splits it into sections which have unity gain
+// at DC (lowpass) or Nyquist (highpass). gain is applied to the first section.
+func bilinear(poles, zeros []complex128, highpass bool, sampleRate int, freq, gain float64) []Coefficients {
+	fs2 := 2 * float64(sampleRate)
+	wc := complex(fs2*math.Tan(math.Pi*freq/float64(sampleRate)), 0)
+	digital := func(s complex128) complex128 {
+		switch {
+		case cmplx.IsInf(s) && highpass:
+			return 1
+		case cmplx.IsInf(s):
+			return -1
+		case highpass:
+			s = wc / s
+		default:
+			s *= wc
+		}
+		return (complex(fs2, 0) + s) / (complex(fs2, 0) - s)
+	}
+
+	n := len(poles)
+	ref := 1.0
+	if highpass {
+		ref = -1
+	}
+	sections := make([]Coefficients, 0, (n+1)/2)
+	add := func(b0, b1, b2, a1, a2 float64) {
+		g := (1 + a1*ref + a2) / (b0 + b1*ref + b2)
+		sections = append(sections, Coefficients{B0: b0 * g, B1: b1 * g, B2: b2 * g, A1: a1, A2: a2})
+	}
+	for k := 0; k < n/2; k++ {
+		p := digital(poles[k])
+		z0, z1 := digital(zeros[k]), digital(zeros[n-1-k])
+		add(1, -real(z0+z1), real(z0*z1), -2*real(p), real(p*cmplx.Conj(p)))
+	}
+	if n&1 == 1 {
+		p, z := digital(poles[n/2]), digital(zeros[n/2])
+		add(1, -real(z), 0, -real(p), 0)
+	}
+	sections[0].B0 *= gain
+	sections[0].B1 *= gain
+	sections[0].B2 *= gain
+	return sections
+}
+
+func checkOrder(order int) {
+	if order < 1 {
+		panic("order must be at least 1")
+	}
+}
+
+// ButterworthLowpass returns a Butterworth lowpass filter of the given order
+// with -3 dB at freq Hz.
+func ButterworthLowpass(order, sampleRate int, freq float64) []Coefficients {
+	checkOrder(order)
+	p, z := butterworthPrototype(order)
+	return bilinear(p, z, false, sampleRate, freq, 1)
+}
+
+// ButterworthHighpass returns a Butterworth highpass filter of the given order
+// with -3 dB at freq Hz.
+func ButterworthHighpass(order, sampleRate int, freq float64) []Coefficients {
+	checkOrder(order)
+	p, z := butterworthPrototype(order)
+	return bilinear(p, z, true, sampleRate, freq, 1)
+}
+
+func chebyshev1Gain(order int, ripple float64) float64 {
+	if order&1 == 1 {
+		return 1
+	}
+	return math.Pow(10, -ripple/20)
+}
+
+// Chebyshev1Lowpass returns a Chebyshev type I lowpass filter of the given order.
+// ripple is the passband ripple in dB, and the response falls below -ripple dB at freq Hz.
+func Chebyshev1Lowpass(order, sampleRate int, freq, ripple float64) []Coefficients {
+	checkOrder(order)
+	p, z := chebyshev1Prototype(order, ripple)
+	return bilinear(p, z, false, sampleRate, freq, chebyshev1Gain(order, ripple))
+}
+
+// Chebyshev1Highpass returns a Chebyshev type I highpass filter of the given order.
+// ripple is the passband ripple in dB, and the response falls below -ripple dB at freq Hz.
+func Chebyshev1Highpass(order, sampleRate int, freq, ripple float64) []Coefficients {
+	checkOrder(order)
+	p, z := chebyshev1Prototype(order, ripple)
+	return bilinear(p, z, true, sampleRate, freq, chebyshev1Gain(order, ripple))
+}
+
+// Chebyshev2Lowpass returns a Chebyshev type II lowpass filter of the given order.
+// attenuation is the minimum stopband attenuation in dB, and the stopband starts at freq Hz.
+func Chebyshev2Lowpass(order, sampleRate int, freq, attenuation float64) []Coefficients {
+	checkOrder(order)
+	p, z := chebyshev2Prototype(order, attenuation)
+	return bilinear(p, z, false, sampleRate, freq, 1)
+}
+
+// Chebyshev2Highpass returns a Chebyshev type II highpass filter of the given order.
+// attenuation is the minimum stopband attenuation in dB, and the stopband ends at freq Hz.
+func Chebyshev2Highpass(order, sampleRate int, freq, attenuation float64) []Coefficients {
+	checkOrder(order)
+	p, z := chebyshev2Prototype(order, attenuation)
+	return bilinear(p, z, true, sampleRate, freq, 1)
+}
+
+func checkLinkwitzRileyOrder(order int) {
+	if order < 2 || order&1 == 1 {
+		panic("order must be an even number")
+	}
+}
+
+// LinkwitzRileyLowpass returns a Linkwitz-Riley lowpass filter of the given even order
+// with -6 dB at freq Hz. It is built from two cascaded Butterworth filters of half the order.
+func LinkwitzRileyLowpass(order, sampleRate int, freq float64) []Coefficients {
+	checkLinkwitzRileyOrder(order)
+	s := ButterworthLowpass(order/2, sampleRate, freq)
+	return append(s, s...)
+}
+
+// LinkwitzRileyHighpass returns a Linkwitz-Riley highpass filter of the given even order
+// with -6 dB at freq Hz. It is built from two cascaded Butterworth filters of half the order.
+func LinkwitzRileyHighpass(order, sampleRate int, freq float64) []Coefficients {
+	checkLinkwitzRileyOrder(order)
+	s := ButterworthHighpass(order/2, sampleRate, freq)
+	return append(s, s...)
+}
@@ -0,0 +1,163 @@
+package fir
+
+import (
+	"github.com/oov/audio/fft"
+)
+
+// Convolver applies a long FIR kernel with uniformly partitioned overlap-save convolution.
+//
+// The kernel is split into partitions of BlockSize samples which are
+// transformed once, and every block of input is multiplied with all of them
+// in the frequency domain. The output is delayed by BlockSize samples.
+type Convolver struct {
+	size  int
+	plan  *fft.RealPlan
+	parts [][]complex128 // nil for partitions which contain only zeros
+	chs   []convolverChannel
+
+	spec []complex128
+	acc  []complex128
+	time []float64
+}
+
+type convolverChannel struct {
+	input  []float64      // last two blocks of input
+	output []float64      // output of the last block
+	fdl    [][]complex128 // spectra of the past input blocks
+	head   int
+	pos    int
+}
+
+// NewConvolver returns a Convolver which applies kernel with the block size.
+func NewConvolver(channels int, kernel []float64, blockSize int) *Convolver {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	if blockSize < 1 {
+		panic("block size must be at least 1")
+	}
+	n := (len(kernel) + blockSize - 1) / blockSize
+	if n == 0 {
+		n = 1
+	}
+	c := &Convolver{
+		size:  blockSize,
+		plan:  fft.NewRealPlan(2 * blockSize),
+		parts: make([][]complex128, n),
+		chs:   make([]convolverChannel, channels),
+		spec:  make([]complex128, blockSize+1),
+		acc:   make([]complex128, blockSize+1),
+		time:  make([]float64, 2*blockSize),
+	}
+	for i := range c.parts {
+		part := kernel[i*blockSize:]
+		if len(part) > blockSize {
+			part = part[:blockSize]
+		}
+		zero := true
+		for _, v := range part {
+			if v != 0 {
+				zero = false
+				break
+			}
+		}
+		if zero {
+			continue
+		}
+		for j := range c.time {
+			c.time[j] = 0
+		}
+		copy(c.time, part)
+		c.parts[i] = make([]complex128, blockSize+1)
+		c.plan.ForwardFloat64(c.parts[i], c.time)
+	}
+	for i := range c.chs {
+		ch := &c.chs[i]
+		ch.input = make([]float64, 2*blockSize)
+		ch.output = make([]float64, blockSize)
+		ch.fdl = make([][]complex128, n)
+		for j := range ch.fdl {
+			ch.fdl[j] = make([]complex128, blockSize+1)
+		}
+	}
+	return c
+}
+
+// BlockSize returns the partition size.
+func (c *Convolver) BlockSize() int {
+	return c.size
+}
+
+// Latency returns the delay of the output in samples.
+func (c *Convolver) Latency() int {
+	return c.size
+}
+
+// Reset clears the state of all channels.
+func (c *Convolver) Reset() {
+	for i := range c.chs {
+		ch := &c.chs[i]
+		for j := range ch.input {
+			ch.input[j] = 0
+		}
+		for j := range ch.output {
+			ch.output[j] = 0
+		}
+		for _, s := range ch.fdl {
+			for j := range s {
+				s[j] = 0
+			}
+		}
+		ch.head, ch.pos = 0, 0
+	}
+}
+
+// block convolves the input block which has been collected in ch.input[size:].
+func (c *Convolver) block(ch *convolverChannel) {
+	n := len(ch.fdl)
+	ch.head = (ch.head + n - 1) % n
+	c.plan.ForwardFloat64(ch.fdl[ch.head], ch.input)
+	for i := range c.acc {
+		c.acc[i] = 0
+	}
+	for i, h := range c.parts {
+		if h == nil {
+			continue
+		}
+		x := ch.fdl[(ch.head+i)%n]
+		for j, v := range h {
+			c.acc[j] += x[j] * v
+		}
+	}
+	c.plan.InverseFloat64(c.time, c.acc)
+	copy(ch.output, c.time[c.size:])
+	copy(ch.input, ch.input[c.size:])
+}
+
+// ProcessFloat64 convolves input of the channel and stores the same number of samples to output.
+// input and output may be the same slice.
+func (c *Convolver) ProcessFloat64(channelIndex int, input, output []float64) {
+	ch := &c.chs[channelIndex]
+	for i, s := range input {
+		ch.input[c.size+ch.pos] = s
+		output[i] = ch.output[ch.pos]
+		if ch.pos++; ch.pos == c.size {
+			c.block(ch)
+			ch.pos = 0
+		}
+	}
+}
+
+// ProcessFloat32 convolves input of the channel and stores the same number of samples to output.
+// input and output may be the same slice.
+func (c *Convolver) ProcessFloat32(channelIndex int, input, output []float32) {
+	ch := &c.chs[channelIndex]
+	for i, s := range input {
+		ch.input[c.size+ch.pos] = float64(s)
+		output[i] = float32(ch.output[ch.pos])
+		if ch.pos++; ch.pos == c.size {
+			c.block(ch)
+			ch.pos = 0
+		}
+	}
+}
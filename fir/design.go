@@ -0,0 +1,112 @@
+// Package fir implements linear phase FIR filter design and fast convolution.
+//
+// Filters are designed with the windowed sinc method, using the windows
+// from the window package or the Kaiser windows of the resampler
+// (resampler.Kaiser12 and so on), or with the Parks-McClellan algorithm. Long kernels are applied with
+// a partitioned convolver which works in the frequency domain.
+package fir
+
+import (
+	"math"
+
+	"github.com/oov/audio/window"
+)
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// windowedSinc returns the impulse response of an ideal lowpass filter
+// with the cutoff at fc cycles per sample, weighted by win.
+func windowedSinc(taps int, fc float64, win window.Func) []float64 {
+	if taps < 1 {
+		panic("you must have at least one tap")
+	}
+	if win == nil {
+		win = window.Hamming
+	}
+	h := window.New(win, taps, false)
+	m := float64(taps-1) / 2
+	for i := range h {
+		h[i] *= 2 * fc * sinc(2*fc*(float64(i)-m))
+	}
+	return h
+}
+
+// scale normalizes h so that the gain at f cycles per sample is 1.
+func scale(h []float64, f float64) []float64 {
+	var re, im float64
+	for i, v := range h {
+		re += v * math.Cos(2*math.Pi*f*float64(i))
+		im -= v * math.Sin(2*math.Pi*f*float64(i))
+	}
+	g := 1 / math.Hypot(re, im)
+	for i := range h {
+		h[i] *= g
+	}
+	return h
+}
+
+func checkOdd(taps int) {
+	if taps&1 == 0 {
+		panic("number of taps must be odd")
+	}
+}
+
+// Lowpass returns a linear phase lowpass filter designed by the windowed sinc method.
+// A nil win selects window.Hamming.
+func Lowpass(taps, sampleRate int, freq float64, win window.Func) []float64 {
+	return scale(windowedSinc(taps, freq/float64(sampleRate), win), 0)
+}
+
+// Highpass returns a linear phase highpass filter designed by the windowed sinc method.
+// taps must be odd. A nil win selects window.Hamming.
+func Highpass(taps, sampleRate int, freq float64, win window.Func) []float64 {
+	checkOdd(taps)
+	h := windowedSinc(taps, 0.5-freq/float64(sampleRate), win)
+	for i := range h {
+		if (i-taps/2)&1 == 1 {
+			h[i] = -h[i]
+		}
+	}
+	return scale(h, 0.5)
+}
+
+// Bandpass returns a linear phase bandpass filter which passes low to high Hz,
+// designed by the windowed sinc method. A nil win selects window.Hamming.
+func Bandpass(taps, sampleRate int, low, high float64, win window.Func) []float64 {
+	fl, fh := low/float64(sampleRate), high/float64(sampleRate)
+	h := windowedSinc(taps, (fh-fl)/2, win)
+	m := float64(taps-1) / 2
+	for i := range h {
+		h[i] *= 2 * math.Cos(2*math.Pi*(fl+fh)/2*(float64(i)-m))
+	}
+	return scale(h, (fl+fh)/2)
+}
+
+// Bandstop returns a linear phase bandstop filter which rejects low to high Hz,
+// designed by the windowed sinc method. taps must be odd. A nil win selects window.Hamming.
+func Bandstop(taps, sampleRate int, low, high float64, win window.Func) []float64 {
+	checkOdd(taps)
+	h := Bandpass(taps, sampleRate, low, high, win)
+	for i := range h {
+		h[i] = -h[i]
+	}
+	h[taps/2]++
+	return scale(h, 0)
+}
+
+// KaiserTaps returns the number of taps of a windowed sinc filter that achieves
+// the stopband attenuation in dB with the transition band width in Hz
+// when used with window.Kaiser(window.KaiserBeta(attenuation)).
+// The result is always odd.
+func KaiserTaps(sampleRate int, attenuation, transition float64) int {
+	n := int(math.Ceil((attenuation-7.95)/(14.36*transition/float64(sampleRate)))) + 1
+	if n < 1 {
+		n = 1
+	}
+	return n | 1
+}
@@ -0,0 +1,218 @@
+package fir
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/oov/audio/resampler"
+	"github.com/oov/audio/window"
+)
+
+const sampleRate = 48000
+
+func response(h []float64, freq float64) float64 {
+	var r complex128
+	for i, v := range h {
+		r += complex(v, 0) * cmplx.Exp(complex(0, -2*math.Pi*freq/sampleRate*float64(i)))
+	}
+	return cmplx.Abs(r)
+}
+
+func dB(v float64) float64 {
+	return 20 * math.Log10(v)
+}
+
+func symmetric(t *testing.T, name string, h []float64) {
+	for i := range h {
+		if math.Abs(h[i]-h[len(h)-1-i]) > 1e-12 {
+			t.Error(name, "is not symmetric at", i)
+			return
+		}
+	}
+}
+
+func TestWindowedSinc(t *testing.T) {
+	const attenuation = 80
+	taps := KaiserTaps(sampleRate, attenuation, 1000)
+	win := window.Kaiser(window.KaiserBeta(attenuation))
+	if taps&1 != 1 {
+		t.Fatal("taps:", taps)
+	}
+
+	tests := []struct {
+		name string
+		h    []float64
+		pass []float64
+		stop []float64
+	}{
+		{"lowpass", Lowpass(taps, sampleRate, 5000, win), []float64{0, 4000}, []float64{6000, 24000}},
+		{"highpass", Highpass(taps, sampleRate, 5000, win), []float64{6000, 24000}, []float64{0, 4000}},
+		{"bandpass", Bandpass(taps, sampleRate, 5000, 10000, win), []float64{6000, 9000}, []float64{0, 4000, 11000, 24000}},
+		{"bandstop", Bandstop(taps, sampleRate, 5000, 10000, win), []float64{0, 4000, 11000, 24000}, []float64{6000, 9000}},
+	}
+	for _, tc := range tests {
+		symmetric(t, tc.name, tc.h)
+		for b := 0; b < len(tc.pass); b += 2 {
+			for f := tc.pass[b]; f <= tc.pass[b+1]; f += 100 {
+				if d := dB(response(tc.h, f)); math.Abs(d) > 0.01 {
+					t.Error(tc.name, "passband", f, "Hz:", d, "dB")
+					break
+				}
+			}
+		}
+		for b := 0; b < len(tc.stop); b += 2 {
+			for f := tc.stop[b]; f <= tc.stop[b+1]; f += 100 {
+				if d := dB(response(tc.h, f)); d > -attenuation+1 {
+					t.Error(tc.name, "stopband", f, "Hz:", d, "dB")
+					break
+				}
+			}
+		}
+	}
+
+	if d := dB(response(Lowpass(63, sampleRate, 5000, nil), 0)); math.Abs(d) > 1e-9 {
+		t.Error("default window dc:", d)
+	}
+}
+
+func TestResamplerWindow(t *testing.T) {
+	tests := []struct {
+		win         window.Func
+		attenuation float64
+	}{
+		{resampler.Kaiser12, 120},
+		{resampler.Kaiser10, 100},
+		{resampler.Kaiser8, 90},
+		{resampler.Kaiser6, 75},
+	}
+	for i, tc := range tests {
+		w := window.New(tc.win, 63, false)
+		symmetric(t, "window", w)
+		if w[31] != 1 || w[0] > 0.02 {
+			t.Errorf("tests[%d] window: peak %v edge %v", i, w[31], w[0])
+		}
+		h := Lowpass(255, sampleRate, 5000, tc.win)
+		for f := 8000.0; f <= 24000; f += 100 {
+			if d := dB(response(h, f)); d > -tc.attenuation {
+				t.Errorf("tests[%d] stopband %v Hz: %v dB", i, f, d)
+				break
+			}
+		}
+	}
+}
+
+func TestRemez(t *testing.T) {
+	for _, taps := range []int{51, 52} {
+		h, err := Remez(taps, sampleRate, []float64{0, 8000, 10000, 24000}, []float64{1, 0}, []float64{1, 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(h) != taps {
+			t.Fatal("taps:", len(h))
+		}
+		symmetric(t, "remez", h)
+
+		// equiripple: the passband error is 10 times the stopband error
+		var pass, stop float64
+		for f := 0.0; f <= 8000; f += 10 {
+			pass = math.Max(pass, math.Abs(response(h, f)-1))
+		}
+		for f := 10000.0; f < 24000; f += 10 {
+			stop = math.Max(stop, response(h, f))
+		}
+		t.Log("taps:", taps, "passband ripple:", pass, "stopband:", dB(stop), "dB")
+		if stop > 3e-3 || math.Abs(pass/stop-10) > 0.5 {
+			t.Error("taps:", taps, "passband:", pass, "stopband:", stop)
+		}
+	}
+
+	h, err := Remez(41, sampleRate, []float64{0, 4000, 6000, 12000, 14000, 24000}, []float64{0, 1, 0}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := dB(response(h, 9000)); math.Abs(d) > 0.5 {
+		t.Error("bandpass center:", d)
+	}
+
+	if _, err = Remez(40, sampleRate, []float64{0, 8000, 10000, 24000}, []float64{0, 1}, nil); err == nil {
+		t.Error("highpass with even taps must fail")
+	}
+	if _, err = Remez(41, sampleRate, []float64{0, 8000, 7000, 24000}, []float64{1, 0}, nil); err == nil {
+		t.Error("overlapped bands must fail")
+	}
+}
+
+func directConvolve(x, h []float64) []float64 {
+	y := make([]float64, len(x))
+	for i := range y {
+		for j, v := range h {
+			if i-j >= 0 {
+				y[i] += x[i-j] * v
+			}
+		}
+	}
+	return y
+}
+
+func TestConvolver(t *testing.T) {
+	kernel := make([]float64, 1000)
+	for i := range kernel {
+		kernel[i] = math.Exp(-float64(i)/200) * math.Sin(float64(i)*0.37)
+	}
+	for i := 300; i < 500; i++ {
+		kernel[i] = 0 // a partition which contains only zeros
+	}
+	x := make([]float64, 5000)
+	for i := range x {
+		x[i] = math.Sin(float64(i)*0.05) + 0.3*math.Cos(float64(i)*1.3)
+	}
+	expected := directConvolve(x, kernel)
+
+	for _, bs := range []int{64, 100, 256} {
+		c := NewConvolver(2, kernel, bs)
+		out := make([]float64, len(x))
+		// feed odd sized chunks
+		for i := 0; i < len(x); i += 77 {
+			end := i + 77
+			if end > len(x) {
+				end = len(x)
+			}
+			c.ProcessFloat64(0, x[i:end], out[i:end])
+		}
+		for i := c.Latency(); i < len(x); i++ {
+			if math.Abs(out[i]-expected[i-c.Latency()]) > 1e-9 {
+				t.Fatal("block size:", bs, "mismatch at", i, out[i], expected[i-c.Latency()])
+			}
+		}
+
+		out32 := make([]float32, bs)
+		in32 := make([]float32, bs)
+		c.ProcessFloat32(1, in32, out32)
+		for _, v := range out32 {
+			if v != 0 {
+				t.Fatal("channels must be independent")
+			}
+		}
+	}
+}
+
+func TestConvolverAllocs(t *testing.T) {
+	c := NewConvolver(1, Lowpass(4095, sampleRate, 1000, nil), 512)
+	buf := make([]float64, 512)
+	allocs := testing.AllocsPerRun(10, func() {
+		c.ProcessFloat64(0, buf, buf)
+	})
+	if allocs != 0 {
+		t.Error("allocs:", allocs)
+	}
+}
+
+func BenchmarkConvolver(b *testing.B) {
+	c := NewConvolver(1, Lowpass(48001, sampleRate, 1000, nil), 1024)
+	buf := make([]float64, 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.ProcessFloat64(0, buf, buf)
+	}
+}
@@ -0,0 +1,239 @@
+package fir
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	remezGridDensity = 16
+	remezMaxIter     = 40
+)
+
+var (
+	errInvalidBands = errors.New("fir: invalid bands")
+	errNoConverge   = errors.New("fir: remez exchange did not converge")
+)
+
+// Remez designs a linear phase filter with the Parks-McClellan algorithm.
+//
+// bands holds the pairs of edge frequencies in Hz in ascending order,
+// desired holds the gain of each band and weight holds the relative weight
+// of the error in each band. A nil weight weights all bands equally.
+// An even number of taps cannot have a nonzero gain at the Nyquist frequency.
+func Remez(taps, sampleRate int, bands, desired, weight []float64) ([]float64, error) {
+	nb := len(bands) / 2
+	if taps < 3 || nb < 1 || len(bands) != nb*2 || len(desired) != nb || (weight != nil && len(weight) != nb) {
+		return nil, errInvalidBands
+	}
+	if weight == nil {
+		weight = make([]float64, nb)
+		for i := range weight {
+			weight[i] = 1
+		}
+	}
+	odd := taps&1 == 1
+	r := taps / 2
+	if odd {
+		r++
+	}
+
+	// build the dense grid in cycles per sample
+	delta := 0.5 / float64(remezGridDensity*r)
+	var grid, des, wt []float64
+	var band []int
+	prev := 0.0
+	for b := 0; b < nb; b++ {
+		lo, hi := bands[b*2]/float64(sampleRate), bands[b*2+1]/float64(sampleRate)
+		if lo < prev || hi < lo || hi > 0.5 || weight[b] <= 0 {
+			return nil, errInvalidBands
+		}
+		prev = hi
+		if !odd && hi > 0.5-delta {
+			// cos(w/2) is zero at the Nyquist frequency
+			if hi == 0.5 && desired[b] != 0 {
+				return nil, errInvalidBands
+			}
+			hi = 0.5 - delta
+			if hi < lo {
+				continue
+			}
+		}
+		n := int(math.Ceil((hi-lo)/delta)) + 1
+		for i := 0; i < n; i++ {
+			f := lo + (hi-lo)*float64(i)/float64(n-1)
+			if n == 1 {
+				f = lo
+			}
+			d, w := desired[b], weight[b]
+			if !odd {
+				c := math.Cos(math.Pi * f)
+				d, w = d/c, w*c
+			}
+			grid, des, wt, band = append(grid, f), append(des, d), append(wt, w), append(band, b)
+		}
+	}
+	if len(grid) < r+1 {
+		return nil, errInvalidBands
+	}
+
+	ext := make([]int, r+1)
+	for i := range ext {
+		ext[i] = i * (len(grid) - 1) / r
+	}
+	x := make([]float64, r+1)
+	ad := make([]float64, r+1)
+	y := make([]float64, r+1)
+	e := make([]float64, len(grid))
+	converged := false
+	for iter := 0; iter < remezMaxIter && !converged; iter++ {
+		for i, k := range ext {
+			x[i] = math.Cos(2 * math.Pi * grid[k])
+		}
+		for i := range ad {
+			p := 1.0
+			for j := range x {
+				if j != i {
+					p *= 2 * (x[i] - x[j])
+				}
+			}
+			ad[i] = 1 / p
+		}
+		var num, den float64
+		sign := 1.0
+		for i, k := range ext {
+			num += ad[i] * des[k]
+			den += sign * ad[i] / wt[k]
+			sign = -sign
+		}
+		dev := num / den
+		sign = 1
+		for i, k := range ext {
+			y[i] = des[k] - sign*dev/wt[k]
+			sign = -sign
+		}
+
+		// barycentric weights for the first r points interpolate the polynomial
+		bw := ad[:r]
+		for i := range bw {
+			bw[i] *= 2 * (x[i] - x[r])
+		}
+		for i, f := range grid {
+			e[i] = wt[i] * (des[i] - interpolate(math.Cos(2*math.Pi*f), x[:r], bw, y[:r]))
+		}
+
+		next := extrema(e, band, r+1)
+		if next == nil {
+			return nil, errNoConverge
+		}
+		converged = true
+		for i := range ext {
+			if ext[i] != next[i] {
+				converged = false
+			}
+		}
+		copy(ext, next)
+		if converged {
+			// evaluate the cosine series at the nodes of the DCT
+			a := make([]float64, r)
+			for j := range a {
+				w := math.Pi * (float64(j) + 0.5) / float64(r)
+				a[j] = interpolate(math.Cos(w), x[:r], bw, y[:r])
+			}
+			return impulse(a, odd), nil
+		}
+	}
+	return nil, errNoConverge
+}
+
+func interpolate(v float64, x, w, y []float64) float64 {
+	var num, den float64
+	for i := range x {
+		d := v - x[i]
+		if d == 0 {
+			return y[i]
+		}
+		num += w[i] / d * y[i]
+		den += w[i] / d
+	}
+	return num / den
+}
+
+// extrema returns n alternating extremal indices of the error e, or nil when there are not enough.
+// Band edges are always candidates.
+func extrema(e []float64, band []int, n int) []int {
+	var idx []int
+	for i, v := range e {
+		if v < 0 {
+			v = -v
+		}
+		sign := 1.0
+		if e[i] < 0 {
+			sign = -1
+		}
+		switch {
+		case i > 0 && band[i] == band[i-1] && sign*e[i-1] > v:
+		case i < len(e)-1 && band[i] == band[i+1] && sign*e[i+1] > v:
+		default:
+			idx = append(idx, i)
+		}
+	}
+	// keep only the largest of consecutive extrema of the same sign
+	j := 0
+	for _, i := range idx {
+		if j > 0 && (e[i] >= 0) == (e[idx[j-1]] >= 0) {
+			if math.Abs(e[i]) > math.Abs(e[idx[j-1]]) {
+				idx[j-1] = i
+			}
+			continue
+		}
+		idx[j] = i
+		j++
+	}
+	idx = idx[:j]
+	for len(idx) > n {
+		if math.Abs(e[idx[0]]) < math.Abs(e[idx[len(idx)-1]]) {
+			idx = idx[1:]
+		} else {
+			idx = idx[:len(idx)-1]
+		}
+	}
+	if len(idx) < n {
+		return nil
+	}
+	return idx
+}
+
+// impulse converts the amplitude response sampled at the DCT nodes to the filter taps.
+func impulse(a []float64, odd bool) []float64 {
+	r := len(a)
+	c := make([]float64, r)
+	for n := range c {
+		var s float64
+		for j, v := range a {
+			s += v * math.Cos(math.Pi*float64(n)*(float64(j)+0.5)/float64(r))
+		}
+		c[n] = 2 * s / float64(r)
+	}
+	c[0] /= 2
+	if odd {
+		h := make([]float64, 2*r-1)
+		h[r-1] = c[0]
+		for n := 1; n < r; n++ {
+			h[r-1-n], h[r-1+n] = c[n]/2, c[n]/2
+		}
+		return h
+	}
+	// multiply by cos(w/2) to get the series of cos((m+1/2)w)
+	b := make([]float64, r)
+	b[0] += c[0]
+	for n := 1; n < r; n++ {
+		b[n] += c[n] / 2
+		b[n-1] += c[n] / 2
+	}
+	h := make([]float64, 2*r)
+	for m, v := range b {
+		h[r-1-m], h[r+m] = v/2, v/2
+	}
+	return h
+}
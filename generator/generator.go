@@ -0,0 +1,306 @@
+// Package generator implements sources of test signals.
+//
+// A Generator produces the same signal on all channels for an exact number of frames,
+// and it works as both audio.Reader and audio.InterleavedReader,
+// so it can feed wave.Writer or any processing stage directly.
+// Levels are in dBFS, where 0 dBFS is the amplitude of 1.
+package generator
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Generator is a source of a test signal.
+type Generator struct {
+	next     func() float64
+	channels int
+	remain   int64
+}
+
+func newGenerator(channels int, frames int64, next func() float64) *Generator {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	return &Generator{
+		next:     next,
+		channels: channels,
+		remain:   frames,
+	}
+}
+
+// Frames returns the number of frames of d at sampleRate, rounded to the nearest frame.
+func Frames(sampleRate int, d time.Duration) int64 {
+	return int64(math.Floor(d.Seconds()*float64(sampleRate) + 0.5))
+}
+
+func amplitude(level float64) float64 {
+	return math.Pow(10, level/20)
+}
+
+// Channels returns the number of channels.
+func (g *Generator) Channels() int {
+	return g.channels
+}
+
+// Remaining returns the number of frames left, or a negative value if the signal is endless.
+func (g *Generator) Remaining() int64 {
+	return g.remain
+}
+
+// frames returns the number of frames which can be generated up to n.
+func (g *Generator) frames(n int) int {
+	if g.remain >= 0 && int64(n) > g.remain {
+		n = int(g.remain)
+	}
+	return n
+}
+
+func (g *Generator) advance(n int) {
+	if g.remain > 0 {
+		g.remain -= int64(n)
+	}
+}
+
+// ReadFloat32 reads interleaved samples, so len(p) should be a multiple of the number of channels.
+// It returns io.ErrShortBuffer if p cannot hold a frame.
+func (g *Generator) ReadFloat32(p []float32) (n int, err error) {
+	if g.remain == 0 {
+		return 0, io.EOF
+	}
+	if len(p) < g.channels {
+		return 0, io.ErrShortBuffer
+	}
+	frames := g.frames(len(p) / g.channels)
+	for i := 0; i < frames*g.channels; i += g.channels {
+		s := float32(g.next())
+		for ch := 0; ch < g.channels; ch++ {
+			p[i+ch] = s
+		}
+	}
+	g.advance(frames)
+	return frames * g.channels, nil
+}
+
+// ReadFloat64 reads interleaved samples, so len(p) should be a multiple of the number of channels.
+// It returns io.ErrShortBuffer if p cannot hold a frame.
+func (g *Generator) ReadFloat64(p []float64) (n int, err error) {
+	if g.remain == 0 {
+		return 0, io.EOF
+	}
+	if len(p) < g.channels {
+		return 0, io.ErrShortBuffer
+	}
+	frames := g.frames(len(p) / g.channels)
+	for i := 0; i < frames*g.channels; i += g.channels {
+		s := g.next()
+		for ch := 0; ch < g.channels; ch++ {
+			p[i+ch] = s
+		}
+	}
+	g.advance(frames)
+	return frames * g.channels, nil
+}
+
+func (g *Generator) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	if g.remain == 0 {
+		return 0, io.EOF
+	}
+	n = g.frames(len(p[0]))
+	for i := 0; i < n; i++ {
+		s := float32(g.next())
+		for _, c := range p {
+			c[i] = s
+		}
+	}
+	g.advance(n)
+	return n, nil
+}
+
+func (g *Generator) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if g.remain == 0 {
+		return 0, io.EOF
+	}
+	n = g.frames(len(p[0]))
+	for i := 0; i < n; i++ {
+		s := g.next()
+		for _, c := range p {
+			c[i] = s
+		}
+	}
+	g.advance(n)
+	return n, nil
+}
+
+// Silence returns a Generator of frames of zeros.
+// A negative frames makes the signal endless, and so do the other generators.
+func Silence(channels int, frames int64) *Generator {
+	return newGenerator(channels, frames, func() float64 { return 0 })
+}
+
+// DC returns a Generator of the constant value.
+func DC(channels int, value float64, frames int64) *Generator {
+	return newGenerator(channels, frames, func() float64 { return value })
+}
+
+// Impulse returns a Generator of impulses of level at frame 0 and then every interval frames.
+// If interval is 0, there is only the first impulse.
+func Impulse(channels int, level float64, interval, frames int64) *Generator {
+	amp := amplitude(level)
+	var pos int64
+	return newGenerator(channels, frames, func() float64 {
+		s := 0.0
+		if pos == 0 || (interval > 0 && pos%interval == 0) {
+			s = amp
+		}
+		pos++
+		return s
+	})
+}
+
+// Sine returns a Generator of a sine wave of freq Hz whose peak is level.
+// The wave starts at the phase 0.
+func Sine(channels, sampleRate int, freq, level float64, frames int64) *Generator {
+	amp := amplitude(level)
+	// the phase is computed from the position so it never drifts
+	w := 2 * math.Pi * freq / float64(sampleRate)
+	var pos int64
+	return newGenerator(channels, frames, func() float64 {
+		s := amp * math.Sin(w*float64(pos))
+		pos++
+		return s
+	})
+}
+
+// Multitone returns a Generator of the sum of sine waves of freqs.
+// Every tone has the same amplitude and the peak of the sum never exceeds level.
+// The tones use Schroeder phases to keep the crest factor low.
+func Multitone(channels, sampleRate int, freqs []float64, level float64, frames int64) *Generator {
+	n := len(freqs)
+	if n == 0 {
+		panic("generator: no frequencies")
+	}
+	amp := amplitude(level) / float64(n)
+	w, phase := make([]float64, n), make([]float64, n)
+	for k, f := range freqs {
+		w[k] = 2 * math.Pi * f / float64(sampleRate)
+		phase[k] = -math.Pi * float64(k*(k+1)) / float64(n)
+	}
+	var pos int64
+	return newGenerator(channels, frames, func() float64 {
+		s := 0.0
+		for k := range w {
+			s += math.Sin(w[k]*float64(pos) + phase[k])
+		}
+		pos++
+		return amp * s
+	})
+}
+
+// SweepMode selects how the frequency of a sweep changes.
+type SweepMode int
+
+const (
+	LogSweep    SweepMode = iota // exponential, the same duration per octave
+	LinearSweep                  // the same duration per Hz
+)
+
+// Sweep returns a Generator of a sine sweep of level from the frequency from to to Hz
+// over frames, which must not be negative. The sweep starts at the phase 0.
+func Sweep(channels, sampleRate int, from, to, level float64, mode SweepMode, frames int64) *Generator {
+	if frames < 0 {
+		panic("generator: a sweep must have a length")
+	}
+	amp := amplitude(level)
+	rate, length := float64(sampleRate), float64(frames)/float64(sampleRate)
+	var phase func(t float64) float64
+	if mode == LinearSweep || from == to || from <= 0 || to <= 0 {
+		k := (to - from) / length
+		phase = func(t float64) float64 {
+			return 2 * math.Pi * (from*t + k*t*t/2)
+		}
+	} else {
+		l := length / math.Log(to/from)
+		phase = func(t float64) float64 {
+			return 2 * math.Pi * from * l * (math.Exp(t/l) - 1)
+		}
+	}
+	var pos int64
+	return newGenerator(channels, frames, func() float64 {
+		s := amp * math.Sin(phase(float64(pos)/rate))
+		pos++
+		return s
+	})
+}
+
+// NoiseColor selects the spectrum of noise.
+type NoiseColor int
+
+const (
+	White NoiseColor = iota // flat
+	Pink                    // -3 dB per octave
+	Brown                   // -6 dB per octave
+)
+
+// pink filters white noise by Paul Kellett's refined method.
+type pink struct {
+	b [7]float64
+}
+
+func (p *pink) process(w float64) float64 {
+	b := &p.b
+	b[0] = 0.99886*b[0] + w*0.0555179
+	b[1] = 0.99332*b[1] + w*0.0750759
+	b[2] = 0.96900*b[2] + w*0.1538520
+	b[3] = 0.86650*b[3] + w*0.3104856
+	b[4] = 0.55000*b[4] + w*0.5329522
+	b[5] = -0.7616*b[5] - w*0.0168980
+	s := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + w*0.5362
+	b[6] = w * 0.115926
+	return s
+}
+
+// pinkGain is the RMS of the pink filter for white noise of the RMS 1,
+// which is the square root of the energy of its impulse response.
+var pinkGain = func() float64 {
+	var p pink
+	e := 0.0
+	for i, w := 0, 1.0; i < 1<<16; i, w = i+1, 0 {
+		s := p.process(w)
+		e += s * s
+	}
+	return math.Sqrt(e)
+}()
+
+// Noise returns a Generator of Gaussian noise of color whose RMS is level.
+// The same seed produces the same noise.
+func Noise(channels, sampleRate int, color NoiseColor, level float64, seed int64, frames int64) *Generator {
+	amp := amplitude(level)
+	rnd := rand.New(rand.NewSource(seed))
+	switch color {
+	case Pink:
+		var p pink
+		for i := 0; i < sampleRate; i++ {
+			// settle the filter
+			p.process(rnd.NormFloat64())
+		}
+		g := amp / pinkGain
+		return newGenerator(channels, frames, func() float64 {
+			return g * p.process(rnd.NormFloat64())
+		})
+	case Brown:
+		// leaky integrator whose corner is 10 Hz, y += (1-a)*x has the RMS sqrt((1-a)/(1+a))
+		a := math.Exp(-2 * math.Pi * 10 / float64(sampleRate))
+		g := amp / math.Sqrt((1-a)/(1+a))
+		y := rnd.NormFloat64() * math.Sqrt((1-a)/(1+a))
+		return newGenerator(channels, frames, func() float64 {
+			y = a*y + (1-a)*rnd.NormFloat64()
+			return g * y
+		})
+	}
+	return newGenerator(channels, frames, func() float64 {
+		return amp * rnd.NormFloat64()
+	})
+}
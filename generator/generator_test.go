@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oov/audio"
+)
+
+// readAll reads g in blocks of 1000 frames until io.EOF and returns the first channel.
+func readAll(t *testing.T, g audio.InterleavedReader, channels int) []float64 {
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, 1000)
+	}
+	var r []float64
+	for {
+		n, err := g.ReadFloat64Interleaved(p)
+		for ch := 1; ch < channels; ch++ {
+			for i := 0; i < n; i++ {
+				if p[ch][i] != p[0][i] {
+					t.Fatal("channels must have the same signal")
+				}
+			}
+		}
+		r = append(r, p[0][:n]...)
+		if err == io.EOF {
+			return r
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func rms(p []float64) float64 {
+	e := 0.0
+	for _, s := range p {
+		e += s * s
+	}
+	return math.Sqrt(e / float64(len(p)))
+}
+
+func peak(p []float64) float64 {
+	m := 0.0
+	for _, s := range p {
+		m = math.Max(m, math.Abs(s))
+	}
+	return m
+}
+
+// crossings returns the number of zero crossings from negative to positive.
+func crossings(p []float64) int {
+	n := 0
+	for i := 1; i < len(p); i++ {
+		if p[i-1] < 0 && p[i] >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDuration(t *testing.T) {
+	if n := Frames(44100, 1500*time.Millisecond); n != 66150 {
+		t.Error("want 66150 got", n)
+	}
+	gens := []*Generator{
+		Silence(2, 12345),
+		DC(2, 0.5, 12345),
+		Impulse(2, 0, 100, 12345),
+		Sine(2, 48000, 1000, -6, 12345),
+		Multitone(2, 48000, []float64{100, 1000}, -6, 12345),
+		Sweep(2, 48000, 20, 20000, -6, LogSweep, 12345),
+		Noise(2, 48000, Pink, -20, 1, 12345),
+	}
+	for i, g := range gens {
+		if p := readAll(t, g, 2); len(p) != 12345 || g.Remaining() != 0 {
+			t.Errorf("gens[%d] want 12345 frames got %d", i, len(p))
+		}
+	}
+
+	// endless signals never end
+	g := Sine(1, 48000, 1000, 0, -1)
+	p := [][]float64{make([]float64, 48000)}
+	for i := 0; i < 10; i++ {
+		if n, err := g.ReadFloat64Interleaved(p); n != 48000 || err != nil {
+			t.Fatal("n:", n, "err:", err)
+		}
+	}
+}
+
+func TestReadFloat64(t *testing.T) {
+	g := Impulse(2, 0, 3, 5)
+	p := make([]float64, 7)
+	n, err := g.ReadFloat64(p)
+	if n != 6 || err != nil {
+		t.Fatal("n:", n, "err:", err)
+	}
+	if p[0] != 1 || p[1] != 1 || p[2] != 0 || p[5] != 0 {
+		t.Error("unexpected samples:", p[:n])
+	}
+	n, err = g.ReadFloat64(p)
+	if n != 4 || err != nil || p[0] != 1 || p[1] != 1 || p[2] != 0 || p[3] != 0 {
+		t.Fatal("n:", n, "err:", err, "samples:", p[:n])
+	}
+	if n, err = g.ReadFloat64(p); n != 0 || err != io.EOF {
+		t.Fatal("n:", n, "err:", err)
+	}
+
+	// a buffer smaller than a frame must not return 0, nil forever
+	g = Impulse(2, 0, 3, 5)
+	if n, err = g.ReadFloat64(p[:1]); n != 0 || err != io.ErrShortBuffer {
+		t.Fatal("n:", n, "err:", err)
+	}
+	if n, err = g.ReadFloat32(make([]float32, 1)); n != 0 || err != io.ErrShortBuffer {
+		t.Fatal("n:", n, "err:", err)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	p := readAll(t, DC(1, -0.25, 100), 1)
+	if p[0] != -0.25 || p[99] != -0.25 {
+		t.Error("unexpected DC:", p[0], p[99])
+	}
+	p = readAll(t, Impulse(1, -6, 0, 1000), 1)
+	if math.Abs(p[0]-0.5012) > 1e-4 || peak(p[1:]) != 0 {
+		t.Error("unexpected impulse:", p[0])
+	}
+
+	p = readAll(t, Sine(1, 48000, 1000, -6, 48000), 1)
+	if n := crossings(p); n < 999 || n > 1000 {
+		t.Error("unexpected frequency:", n)
+	}
+	if math.Abs(peak(p)-0.5012) > 1e-3 || math.Abs(rms(p)-0.5012/math.Sqrt2) > 1e-3 || p[0] != 0 {
+		t.Error("unexpected sine:", peak(p), rms(p))
+	}
+
+	p = readAll(t, Multitone(1, 48000, []float64{100, 1000, 5000}, -3, 48000), 1)
+	if pk := peak(p); pk > 0.7080 || pk < 0.3 {
+		t.Error("unexpected multitone peak:", pk)
+	}
+
+	for _, mode := range []SweepMode{LogSweep, LinearSweep} {
+		p = readAll(t, Sweep(1, 48000, 100, 10000, 0, mode, 96000), 1)
+		// frequencies at the beginning and the end of the sweep
+		if n := crossings(p[:4800]); n < 5 || (mode == LogSweep && n > 20) {
+			t.Errorf("mode %d unexpected start frequency: %d", mode, n*10)
+		}
+		if n := crossings(p[len(p)-480:]); n < 90 || n > 101 {
+			t.Errorf("mode %d unexpected end frequency: %d", mode, n*100)
+		}
+		if peak(p) > 1 {
+			t.Errorf("mode %d unexpected peak: %f", mode, peak(p))
+		}
+	}
+}
+
+func TestNoise(t *testing.T) {
+	corr := make([]float64, 3)
+	for _, color := range []NoiseColor{White, Pink, Brown} {
+		p := readAll(t, Noise(1, 48000, color, -20, 42, 480000), 1)
+		if r := rms(p); math.Abs(20*math.Log10(r)+20) > 0.5 {
+			t.Errorf("color %d unexpected level: %f dBFS", color, 20*math.Log10(r))
+		}
+		q := readAll(t, Noise(1, 48000, color, -20, 42, 480000), 1)
+		for i := range p {
+			if p[i] != q[i] {
+				t.Fatalf("color %d the same seed must produce the same noise", color)
+			}
+		}
+		// lag-1 autocorrelation grows as the spectrum tilts
+		e, c := 0.0, 0.0
+		for i := 1; i < len(p); i++ {
+			e += p[i] * p[i]
+			c += p[i] * p[i-1]
+		}
+		corr[color] = c / e
+	}
+	if math.Abs(corr[White]) > 0.01 || corr[Pink] < 0.3 || corr[Brown] < 0.99 {
+		t.Error("unexpected autocorrelation:", corr)
+	}
+}
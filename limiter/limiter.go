@@ -0,0 +1,214 @@
+// Package limiter implements a look-ahead brickwall limiter.
+package limiter
+
+import (
+	"math"
+
+	"github.com/oov/audio/truepeak"
+)
+
+// Options configures a Limiter.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	Lookahead float64 // look-ahead time in seconds (default 0.005)
+	Attack    float64 // attack time in seconds, up to Lookahead (default Lookahead)
+	Release   float64 // release time in seconds (default 0.05)
+	Ceiling   float64 // output ceiling in dBFS (default 0)
+	Linked    bool    // apply the same gain to all channels
+	TruePeak  bool    // detect peaks on the 4x oversampled signal
+}
+
+// Limiter is a stateful multi-channel look-ahead limiter.
+type Limiter struct {
+	ceiling   float64
+	release   float64
+	lookahead int
+	linked    bool
+
+	detector *truepeak.Detector
+	groups   []gainState
+	delays   []delayLine
+	peaks    [][]float64
+}
+
+type gainState struct {
+	min     minWindow
+	avg     movingAverage
+	release float64
+}
+
+// New returns a Limiter.
+func New(channels, sampleRate int, opts Options) *Limiter {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	if opts.Lookahead <= 0 {
+		opts.Lookahead = 0.005
+	}
+	if opts.Attack <= 0 || opts.Attack > opts.Lookahead {
+		opts.Attack = opts.Lookahead
+	}
+	if opts.Release <= 0 {
+		opts.Release = 0.05
+	}
+
+	la := int(opts.Lookahead*float64(sampleRate) + 0.5)
+	at := int(opts.Attack*float64(sampleRate) + 0.5)
+	if at < 1 {
+		at = 1
+	}
+	if at > la+1 {
+		at = la + 1
+	}
+
+	l := &Limiter{
+		ceiling:   math.Pow(10, opts.Ceiling/20),
+		release:   1 - math.Exp(-1/(opts.Release*float64(sampleRate))),
+		lookahead: la,
+		linked:    opts.Linked,
+	}
+	if opts.TruePeak {
+		l.detector = truepeak.New(channels)
+	}
+
+	groups := channels
+	if l.linked {
+		groups = 1
+	}
+	l.groups = make([]gainState, groups)
+	for i := range l.groups {
+		l.groups[i].min.init(la + 1)
+		l.groups[i].avg.init(at)
+		l.groups[i].release = 1
+	}
+	l.delays = make([]delayLine, channels)
+	for i := range l.delays {
+		l.delays[i].init(l.Latency())
+	}
+	l.peaks = make([][]float64, channels)
+	return l
+}
+
+// Latency returns the delay of the output in samples.
+func (l *Limiter) Latency() int {
+	if l.detector != nil {
+		return l.lookahead + l.detector.Latency()
+	}
+	return l.lookahead
+}
+
+// Reset clears the state of all channels.
+func (l *Limiter) Reset() {
+	if l.detector != nil {
+		l.detector.Reset()
+	}
+	for i := range l.groups {
+		g := &l.groups[i]
+		g.min.init(len(g.min.vals))
+		g.avg.init(len(g.avg.vals))
+		g.release = 1
+	}
+	for i := range l.delays {
+		l.delays[i].init(len(l.delays[i].buf))
+	}
+}
+
+func (l *Limiter) detect(channelIndex int, n int) []float64 {
+	if n > len(l.peaks[channelIndex]) {
+		l.peaks[channelIndex] = make([]float64, n)
+	}
+	return l.peaks[channelIndex][:n]
+}
+
+func (l *Limiter) gain(g *gainState, peak float64) float64 {
+	req := 1.0
+	if peak > l.ceiling {
+		req = l.ceiling / peak
+	}
+	m := g.min.push(req)
+	if m < g.release {
+		g.release = m
+	} else {
+		g.release += (m - g.release) * l.release
+	}
+	return g.avg.push(g.release)
+}
+
+// ProcessFloat64 limits p in place. p[i] is the buffer of channel i.
+// The output is delayed by Latency samples.
+func (l *Limiter) ProcessFloat64(p [][]float64) {
+	frames := len(p[0])
+	for ch, s := range p {
+		peaks := l.detect(ch, frames)
+		if l.detector != nil {
+			l.detector.ProcessFloat64(ch, s, peaks)
+			continue
+		}
+		for i, v := range s {
+			peaks[i] = math.Abs(v)
+		}
+	}
+	for i := 0; i < frames; i++ {
+		if l.linked {
+			var peak float64
+			for ch := range p {
+				peak = math.Max(peak, l.peaks[ch][i])
+			}
+			g := l.gain(&l.groups[0], peak)
+			for ch, s := range p {
+				s[i] = l.limit(l.delays[ch].push(s[i]) * g)
+			}
+			continue
+		}
+		for ch, s := range p {
+			g := l.gain(&l.groups[ch], l.peaks[ch][i])
+			s[i] = l.limit(l.delays[ch].push(s[i]) * g)
+		}
+	}
+}
+
+// ProcessFloat32 limits p in place. p[i] is the buffer of channel i.
+// The output is delayed by Latency samples.
+func (l *Limiter) ProcessFloat32(p [][]float32) {
+	frames := len(p[0])
+	for ch, s := range p {
+		peaks := l.detect(ch, frames)
+		for i, v := range s {
+			peaks[i] = float64(v)
+		}
+		if l.detector != nil {
+			l.detector.ProcessFloat64(ch, peaks, peaks)
+			continue
+		}
+		for i, v := range peaks {
+			peaks[i] = math.Abs(v)
+		}
+	}
+	for i := 0; i < frames; i++ {
+		if l.linked {
+			var peak float64
+			for ch := range p {
+				peak = math.Max(peak, l.peaks[ch][i])
+			}
+			g := l.gain(&l.groups[0], peak)
+			for ch, s := range p {
+				s[i] = float32(l.limit(l.delays[ch].push(float64(s[i])) * g))
+			}
+			continue
+		}
+		for ch, s := range p {
+			g := l.gain(&l.groups[ch], l.peaks[ch][i])
+			s[i] = float32(l.limit(l.delays[ch].push(float64(s[i])) * g))
+		}
+	}
+}
+
+func (l *Limiter) limit(s float64) float64 {
+	switch {
+	case s > l.ceiling:
+		return l.ceiling
+	case s < -l.ceiling:
+		return -l.ceiling
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oov/audio/truepeak"
+)
+
+func sine(n int, freq, amp, phase float64) []float64 {
+	p := make([]float64, n)
+	for i := range p {
+		p[i] = amp * math.Sin(2*math.Pi*freq*float64(i)+phase)
+	}
+	return p
+}
+
+func TestCeiling(t *testing.T) {
+	for _, linked := range []bool{false, true} {
+		l := New(2, 48000, Options{Ceiling: -1, Linked: linked})
+		p := [][]float64{sine(48000, 0.01, 2, 0), sine(48000, 0.013, 0.5, 0)}
+		for i := 0; i < len(p[0]); i += 1000 {
+			l.ProcessFloat64([][]float64{p[0][i : i+1000], p[1][i : i+1000]})
+		}
+		ceiling := math.Pow(10, -1.0/20)
+		var peak float64
+		for _, s := range p[0] {
+			peak = math.Max(peak, math.Abs(s))
+		}
+		if peak > ceiling+1e-9 || peak < ceiling*0.95 {
+			t.Error("linked:", linked, "peak:", peak)
+		}
+	}
+}
+
+func TestLatency(t *testing.T) {
+	l := New(1, 48000, Options{Lookahead: 0.002})
+	p := make([]float32, 1000)
+	p[10] = 0.5
+	l.ProcessFloat32([][]float32{p})
+	if l.Latency() != 96 || p[10+l.Latency()] != 0.5 {
+		t.Error("latency:", l.Latency(), "sample:", p[10+l.Latency()])
+	}
+}
+
+func TestGainRamp(t *testing.T) {
+	l := New(1, 48000, Options{Lookahead: 0.001, Release: 0.01})
+	p := make([]float64, 4800)
+	for i := 1000; i < 1100; i++ {
+		p[i] = 4
+	}
+	for i := 2000; i < len(p); i++ {
+		p[i] = 0.5
+	}
+	l.ProcessFloat64([][]float64{p})
+	if math.Abs(p[1000+l.Latency()]-1) > 1e-9 {
+		t.Error("gain at the beginning of the burst:", p[1000+l.Latency()]/4)
+	}
+	if v := p[1000+l.Latency()-l.Latency()/2]; v != 0 {
+		t.Error("sample before burst:", v)
+	}
+	if v := p[len(p)-1]; math.Abs(v-0.5) > 1e-3 {
+		t.Error("release:", v)
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// every sample peak is 0.85 but the true peak is 1.2
+	in := sine(48000, 0.25, 1.2, math.Pi/4)
+	l := New(1, 48000, Options{Ceiling: -1, TruePeak: true})
+	l.ProcessFloat64([][]float64{in})
+
+	d := truepeak.New(1)
+	out := make([]float64, len(in))
+	d.ProcessFloat64(0, in, out)
+	var peak float64
+	for _, s := range out {
+		peak = math.Max(peak, s)
+	}
+	if db := 20 * math.Log10(peak); db > -0.95 {
+		t.Error("true peak:", db, "dBTP")
+	}
+}
@@ -0,0 +1,92 @@
+package limiter
+
+// minWindow is a sliding window minimum.
+type minWindow struct {
+	vals  []float64
+	idx   []int
+	head  int
+	count int
+	n     int
+}
+
+func (w *minWindow) init(size int) {
+	if len(w.vals) != size {
+		w.vals = make([]float64, size)
+		w.idx = make([]int, size)
+	}
+	w.head, w.count, w.n = 0, 0, 0
+}
+
+func (w *minWindow) push(v float64) float64 {
+	size := len(w.vals)
+	for w.count > 0 && w.vals[(w.head+w.count-1)%size] >= v {
+		w.count--
+	}
+	if w.count > 0 && w.idx[w.head] <= w.n-size {
+		w.head = (w.head + 1) % size
+		w.count--
+	}
+	pos := (w.head + w.count) % size
+	w.vals[pos], w.idx[pos] = v, w.n
+	w.count++
+	w.n++
+	return w.vals[w.head]
+}
+
+// movingAverage is a box filter initialised with unity.
+type movingAverage struct {
+	vals []float64
+	pos  int
+	sum  float64
+}
+
+func (a *movingAverage) init(size int) {
+	if len(a.vals) != size {
+		a.vals = make([]float64, size)
+	}
+	for i := range a.vals {
+		a.vals[i] = 1
+	}
+	a.pos, a.sum = 0, float64(size)
+}
+
+func (a *movingAverage) push(v float64) float64 {
+	a.sum += v - a.vals[a.pos]
+	a.vals[a.pos] = v
+	if a.pos++; a.pos == len(a.vals) {
+		// recalculate to cancel accumulated rounding errors
+		a.pos, a.sum = 0, 0
+		for _, v := range a.vals {
+			a.sum += v
+		}
+	}
+	return a.sum / float64(len(a.vals))
+}
+
+// delayLine delays samples by its length.
+type delayLine struct {
+	buf []float64
+	pos int
+}
+
+func (d *delayLine) init(size int) {
+	if len(d.buf) != size {
+		d.buf = make([]float64, size)
+	}
+	for i := range d.buf {
+		d.buf[i] = 0
+	}
+	d.pos = 0
+}
+
+func (d *delayLine) push(v float64) float64 {
+	if len(d.buf) == 0 {
+		return v
+	}
+	r := d.buf[d.pos]
+	d.buf[d.pos] = v
+	if d.pos++; d.pos == len(d.buf) {
+		d.pos = 0
+	}
+	return r
+}
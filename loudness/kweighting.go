@@ -0,0 +1,56 @@
+package loudness
+
+import (
+	"math"
+)
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+}
+
+// kWeighting returns the pre-filter and the RLB filter of ITU-R BS.1770
+// designed for the sample rate.
+func kWeighting(sampleRate int) [2]biquad {
+	fs := float64(sampleRate)
+
+	// high shelf
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// high pass
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	hp := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return [2]biquad{shelf, hp}
+}
+
+// kState is the transposed direct form II state of the two K-weighting stages.
+type kState [2][2]float64
+
+func (st *kState) process(f *[2]biquad, x float64) float64 {
+	for i := range f {
+		c, z := &f[i], &st[i]
+		y := c.b0*x + z[0]
+		z[0] = c.b1*x - c.a1*y + z[1]
+		z[1] = c.b2*x - c.a2*y
+		x = y
+	}
+	return x
+}
@@ -0,0 +1,330 @@
+// Package loudness implements loudness measurement as described in
+// ITU-R BS.1770 and EBU R128 (EBU Tech 3341 and 3342).
+package loudness
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/truepeak"
+	"github.com/oov/audio/wave"
+)
+
+const (
+	absoluteGate    = -70.0
+	relativeGate    = -10.0
+	lraRelativeGate = -20.0
+
+	subBlocksPerMomentary = 4  // 400ms
+	subBlocksPerShortTerm = 30 // 3s
+)
+
+// ChannelWeights returns the BS.1770 channel weights for the channel mask.
+// The low frequency channel is ignored and surround channels are weighted by 1.41.
+// Channels which are not described by mask get the weight 1.
+func ChannelWeights(mask wave.WFESpeaker, channels int) []float64 {
+	w := make([]float64, channels)
+	ch := 0
+	for bit := wave.WFESpeaker(1); bit != 0 && ch < channels; bit <<= 1 {
+		if mask&bit == 0 {
+			continue
+		}
+		switch bit {
+		case wave.SPEAKER_LOW_FREQUENCY:
+			w[ch] = 0
+		case wave.SPEAKER_BACK_LEFT, wave.SPEAKER_BACK_RIGHT, wave.SPEAKER_SIDE_LEFT, wave.SPEAKER_SIDE_RIGHT:
+			w[ch] = 1.41
+		default:
+			w[ch] = 1
+		}
+		ch++
+	}
+	for ; ch < channels; ch++ {
+		w[ch] = 1
+	}
+	return w
+}
+
+// Meter measures the loudness of a stream.
+//
+// Meter implements audio.InterleavedWriter, so planar blocks can be written to it directly.
+type Meter struct {
+	weights []float64
+	filter  [2]biquad
+	states  []kState
+
+	subBlockLen int
+	subBlockPos int
+	sums        []float64
+	subBlocks   []float64 // weighted mean square of every 100ms sub-block
+
+	detector   *truepeak.Detector
+	peakBuf    []float64
+	buf        [][]float64
+	samplePeak float64
+	truePeak   float64
+}
+
+// New returns a Meter which uses the default channel layout for the number of channels.
+func New(channels, sampleRate int) *Meter {
+	return NewWithChannelMask(channels, sampleRate, wave.DefaultChannelMask(channels))
+}
+
+// NewWithChannelMask returns a Meter which weights channels according to mask.
+func NewWithChannelMask(channels, sampleRate int, mask wave.WFESpeaker) *Meter {
+	return NewWithWeights(sampleRate, ChannelWeights(mask, channels))
+}
+
+// NewWithWeights returns a Meter with custom channel weights.
+func NewWithWeights(sampleRate int, weights []float64) *Meter {
+	if len(weights) < 1 {
+		panic("you must have at least one channel")
+	}
+	return &Meter{
+		weights:     append([]float64(nil), weights...),
+		filter:      kWeighting(sampleRate),
+		states:      make([]kState, len(weights)),
+		subBlockLen: sampleRate / 10,
+		sums:        make([]float64, len(weights)),
+		detector:    truepeak.New(len(weights)),
+	}
+}
+
+// Reset discards all measured data.
+func (m *Meter) Reset() {
+	for i := range m.states {
+		m.states[i] = kState{}
+		m.sums[i] = 0
+	}
+	m.subBlockPos = 0
+	m.subBlocks = m.subBlocks[:0]
+	m.detector.Reset()
+	m.samplePeak, m.truePeak = 0, 0
+}
+
+func (m *Meter) endSubBlock() {
+	var z float64
+	for ch, sum := range m.sums {
+		z += m.weights[ch] * sum / float64(m.subBlockLen)
+		m.sums[ch] = 0
+	}
+	m.subBlocks = append(m.subBlocks, z)
+	m.subBlockPos = 0
+}
+
+func (m *Meter) write(p [][]float64) {
+	frames := len(p[0])
+	if frames > len(m.peakBuf) {
+		m.peakBuf = make([]float64, frames)
+	}
+	for ch, s := range p {
+		for _, v := range s {
+			if v < 0 {
+				v = -v
+			}
+			if v > m.samplePeak {
+				m.samplePeak = v
+			}
+		}
+		m.detector.ProcessFloat64(ch, s, m.peakBuf[:frames])
+		for _, v := range m.peakBuf[:frames] {
+			if v > m.truePeak {
+				m.truePeak = v
+			}
+		}
+	}
+
+	for i := 0; i < frames; {
+		n := frames - i
+		if rest := m.subBlockLen - m.subBlockPos; n > rest {
+			n = rest
+		}
+		for ch, s := range p {
+			st, sum := &m.states[ch], m.sums[ch]
+			for _, v := range s[i : i+n] {
+				y := st.process(&m.filter, v)
+				sum += y * y
+			}
+			m.sums[ch] = sum
+		}
+		i += n
+		if m.subBlockPos += n; m.subBlockPos == m.subBlockLen {
+			m.endSubBlock()
+		}
+	}
+}
+
+// WriteFloat64Interleaved measures p. p[i] is the buffer of channel i.
+func (m *Meter) WriteFloat64Interleaved(p [][]float64) (n int, err error) {
+	m.write(p)
+	return len(p[0]), nil
+}
+
+// WriteFloat32Interleaved measures p. p[i] is the buffer of channel i.
+func (m *Meter) WriteFloat32Interleaved(p [][]float32) (n int, err error) {
+	if m.buf == nil {
+		m.buf = make([][]float64, len(p))
+	}
+	for ch, s := range p {
+		if len(s) > cap(m.buf[ch]) {
+			m.buf[ch] = make([]float64, len(s))
+		}
+		m.buf[ch] = m.buf[ch][:len(s)]
+		for i, v := range s {
+			m.buf[ch][i] = float64(v)
+		}
+	}
+	m.write(m.buf)
+	return len(p[0]), nil
+}
+
+// Measure reads r until io.EOF and measures all of the data.
+// It returns the number of frames read.
+func (m *Meter) Measure(r audio.InterleavedReader) (frames int64, err error) {
+	buf := make([][]float64, len(m.weights))
+	for ch := range buf {
+		buf[ch] = make([]float64, 4096)
+	}
+	in := make([][]float64, len(buf))
+	for {
+		n, rerr := r.ReadFloat64Interleaved(buf)
+		if n > 0 {
+			for ch := range buf {
+				in[ch] = buf[ch][:n]
+			}
+			m.write(in)
+			frames += int64(n)
+		}
+		if rerr == io.EOF {
+			return frames, nil
+		}
+		if rerr != nil {
+			return frames, rerr
+		}
+	}
+}
+
+// MeasureWave measures the whole waveform audio data from r.
+// The channel mask of WAVE_FORMAT_EXTENSIBLE is used when it is available.
+func MeasureWave(r io.Reader) (*Meter, error) {
+	ar, wfext, err := wave.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	channels := int(wfext.Format.Channels)
+	mask := wfext.ChannelMask
+	if mask == 0 {
+		mask = wave.DefaultChannelMask(channels)
+	}
+	m := NewWithChannelMask(channels, int(wfext.Format.SamplesPerSec), mask)
+	if _, err = m.Measure(ar); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func energyToLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+func (m *Meter) windowEnergy(subBlocks int) float64 {
+	if len(m.subBlocks) < subBlocks {
+		return 0
+	}
+	var z float64
+	for _, v := range m.subBlocks[len(m.subBlocks)-subBlocks:] {
+		z += v
+	}
+	return z / float64(subBlocks)
+}
+
+// blocks returns the energies of the overlapping windows of subBlocks length.
+func (m *Meter) blocks(subBlocks int) []float64 {
+	if len(m.subBlocks) < subBlocks {
+		return nil
+	}
+	r := make([]float64, 0, len(m.subBlocks)-subBlocks+1)
+	var z float64
+	for i, v := range m.subBlocks {
+		z += v
+		if i >= subBlocks {
+			z -= m.subBlocks[i-subBlocks]
+		}
+		if i >= subBlocks-1 {
+			r = append(r, math.Max(z, 0)/float64(subBlocks))
+		}
+	}
+	return r
+}
+
+// gate returns the energies which pass the absolute gate and the relative gate.
+func gate(blocks []float64, relative float64) []float64 {
+	abs := math.Pow(10, (absoluteGate+0.691)/10)
+	var sum float64
+	passed := blocks[:0:0]
+	for _, z := range blocks {
+		if z > abs {
+			sum += z
+			passed = append(passed, z)
+		}
+	}
+	if len(passed) == 0 {
+		return nil
+	}
+	rel := sum / float64(len(passed)) * math.Pow(10, relative/10)
+	r := passed[:0]
+	for _, z := range passed {
+		if z > rel {
+			r = append(r, z)
+		}
+	}
+	return r
+}
+
+// Momentary returns the momentary loudness of the last 400ms in LUFS.
+func (m *Meter) Momentary() float64 {
+	return energyToLoudness(m.windowEnergy(subBlocksPerMomentary))
+}
+
+// ShortTerm returns the short-term loudness of the last 3s in LUFS.
+func (m *Meter) ShortTerm() float64 {
+	return energyToLoudness(m.windowEnergy(subBlocksPerShortTerm))
+}
+
+// Integrated returns the gated integrated loudness in LUFS.
+func (m *Meter) Integrated() float64 {
+	blocks := gate(m.blocks(subBlocksPerMomentary), relativeGate)
+	if len(blocks) == 0 {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for _, z := range blocks {
+		sum += z
+	}
+	return energyToLoudness(sum / float64(len(blocks)))
+}
+
+// LoudnessRange returns the loudness range (LRA) in LU.
+func (m *Meter) LoudnessRange() float64 {
+	blocks := gate(m.blocks(subBlocksPerShortTerm), lraRelativeGate)
+	if len(blocks) == 0 {
+		return 0
+	}
+	sort.Float64s(blocks)
+	percentile := func(p float64) float64 {
+		return energyToLoudness(blocks[int(p*float64(len(blocks)-1)+0.5)])
+	}
+	return percentile(0.95) - percentile(0.10)
+}
+
+// SamplePeak returns the maximum sample peak in dBFS.
+func (m *Meter) SamplePeak() float64 {
+	return 20 * math.Log10(m.samplePeak)
+}
+
+// TruePeak returns the maximum true peak in dBTP.
+func (m *Meter) TruePeak() float64 {
+	return 20 * math.Log10(m.truePeak)
+}
@@ -0,0 +1,162 @@
+package loudness
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/oov/audio/wave"
+)
+
+type segment struct {
+	level   float64 // dBFS
+	seconds float64
+}
+
+// measure feeds a stereo 1kHz sine as used by EBU Tech 3341 and 3342.
+func measure(segments []segment) *Meter {
+	const rate = 48000
+	m := New(2, rate)
+	buf := [][]float64{make([]float64, 4800), make([]float64, 4800)}
+	var t int
+	for _, seg := range segments {
+		amp := math.Pow(10, seg.level/20)
+		for n := int(seg.seconds * rate); n > 0; {
+			ln := len(buf[0])
+			if ln > n {
+				ln = n
+			}
+			for i := 0; i < ln; i++ {
+				s := amp * math.Sin(2*math.Pi*1000*float64(t)/rate)
+				buf[0][i], buf[1][i] = s, s
+				t++
+			}
+			m.WriteFloat64Interleaved([][]float64{buf[0][:ln], buf[1][:ln]})
+			n -= ln
+		}
+	}
+	return m
+}
+
+func TestTech3341(t *testing.T) {
+	tests := []struct {
+		segments   []segment
+		integrated float64
+	}{
+		{[]segment{{-23, 20}}, -23},
+		{[]segment{{-33, 20}}, -33},
+		{[]segment{{-36, 10}, {-23, 60}, {-36, 10}}, -23},
+		{[]segment{{-72, 10}, {-36, 10}, {-23, 60}, {-36, 10}, {-72, 10}}, -23},
+	}
+	for i, test := range tests {
+		m := measure(test.segments)
+		if v := m.Integrated(); math.Abs(v-test.integrated) > 0.1 {
+			t.Error("case", i+1, "integrated:", v)
+		}
+	}
+
+	m := measure([]segment{{-23, 20}})
+	if v := m.Momentary(); math.Abs(v - -23) > 0.1 {
+		t.Error("momentary:", v)
+	}
+	if v := m.ShortTerm(); math.Abs(v - -23) > 0.1 {
+		t.Error("short-term:", v)
+	}
+	if v := m.SamplePeak(); math.Abs(v - -23) > 0.01 {
+		t.Error("sample peak:", v)
+	}
+	if v := m.TruePeak(); math.Abs(v - -23) > 0.1 {
+		t.Error("true peak:", v)
+	}
+}
+
+func TestTech3342(t *testing.T) {
+	tests := []struct {
+		segments []segment
+		lra      float64
+	}{
+		{[]segment{{-20, 20}, {-30, 20}}, 10},
+		{[]segment{{-20, 20}, {-15, 20}}, 5},
+		{[]segment{{-40, 20}, {-20, 20}}, 20},
+		{[]segment{{-50, 20}, {-35, 20}, {-20, 20}, {-35, 20}, {-50, 20}}, 15},
+	}
+	for i, test := range tests {
+		m := measure(test.segments)
+		if v := m.LoudnessRange(); math.Abs(v-test.lra) > 1 {
+			t.Error("case", i+1, "loudness range:", v)
+		}
+	}
+}
+
+func TestChannelWeights(t *testing.T) {
+	w := ChannelWeights(0x3f, 7)
+	expected := []float64{1, 1, 1, 0, 1.41, 1.41, 1}
+	for i := range expected {
+		if w[i] != expected[i] {
+			t.Fatal("weights:", w)
+		}
+	}
+}
+
+func TestWriteFloat32(t *testing.T) {
+	m := New(1, 44100)
+	p := make([]float32, 44100*5)
+	for i := range p {
+		p[i] = float32(0.1 * math.Sin(2*math.Pi*997*float64(i)/44100))
+	}
+	m.WriteFloat32Interleaved([][]float32{p})
+	// mono 1kHz sine at -20dBFS: -20 - 3.01 (mean square) + 0.69 (K-weighting) - 0.691
+	if v := m.Integrated(); math.Abs(v - -23.01) > 0.1 {
+		t.Error("integrated:", v)
+	}
+}
+
+// TestMeasureWave measures a 5.1 WAVE_FORMAT_EXTENSIBLE file whose channel mask
+// puts the low frequency channel at the third position, unlike the default 5.1 layout.
+func TestMeasureWave(t *testing.T) {
+	const channels, rate = 6, 44100
+	wfext := &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_EXTENSIBLE,
+			Channels:       channels,
+			SamplesPerSec:  rate,
+			AvgBytesPerSec: rate * channels * 4,
+			BlockAlign:     channels * 4,
+			BitsPerSample:  32,
+			ExtSize:        22,
+		},
+		Samples: 32,
+		ChannelMask: wave.SPEAKER_FRONT_LEFT | wave.SPEAKER_FRONT_RIGHT | wave.SPEAKER_LOW_FREQUENCY |
+			wave.SPEAKER_BACK_LEFT | wave.SPEAKER_BACK_RIGHT | wave.SPEAKER_BACK_CENTER,
+		SubFormat: wave.KSDATAFORMAT_SUBTYPE_IEEE_FLOAT,
+	}
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, rate*5)
+	}
+	// the same sine in the low frequency channel and the back left channel
+	for i := range p[2] {
+		p[2][i] = 0.1 * math.Sin(2*math.Pi*997*float64(i)/rate)
+		p[3][i] = p[2][i]
+	}
+	var b bytes.Buffer
+	w, err := wave.NewWriter(&b, wfext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.WriteFloat64Interleaved(p); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MeasureWave(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the low frequency channel is ignored and the back left channel is weighted by 1.41 (+1.5dB)
+	if v := m.Integrated(); math.Abs(v-(-23.01+10*math.Log10(1.41))) > 0.1 {
+		t.Error("integrated:", v)
+	}
+}
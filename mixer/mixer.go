@@ -0,0 +1,233 @@
+// Package mixer implements a mixer which combines several inputs into one output.
+package mixer
+
+import (
+	"io"
+	"math"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/channel"
+	"github.com/oov/audio/resampler"
+	"github.com/oov/audio/wave"
+)
+
+// PanLaw selects how the pan position is converted to the gains of the left and right channels.
+type PanLaw int
+
+const (
+	ConstantPower PanLaw = iota // -3 dB at the center
+	Linear                      // -6 dB at the center
+)
+
+// Input describes an input of a Mixer.
+// Zero values select the defaults noted on each field.
+type Input struct {
+	Reader     audio.InterleavedReader
+	Channels   int     // number of channels of Reader
+	SampleRate int     // sample rate of Reader, resampled when it differs from the output (default the output sample rate)
+	Gain       float64 // gain in dB
+	Pan        float64 // pan position from -1 (left) to 1 (right), used for stereo output
+	PanLaw     PanLaw  // pan law
+	Offset     int64   // start position in frames of the output
+	Quality    int     // resampler quality (default 4)
+}
+
+type input struct {
+	r      audio.InterleavedReader
+	matrix channel.Matrix
+	offset int64
+	buf    [][]float64
+	tmp    [][]float64
+	done   bool
+}
+
+// Mixer is an InterleavedReader which mixes all inputs.
+// It returns io.EOF after all inputs are exhausted.
+type Mixer struct {
+	channels int
+	inputs   []*input
+	pos      int64
+	p64      [][]float64
+}
+
+// panGains returns the gains of the left and right channels.
+func panGains(pan float64, law PanLaw) (l, r float64) {
+	pan = math.Max(-1, math.Min(1, pan))
+	if law == Linear {
+		return (1 - pan) / 2, (1 + pan) / 2
+	}
+	t := (pan + 1) * math.Pi / 4
+	return math.Cos(t), math.Sin(t)
+}
+
+// matrix returns the mixing matrix of the input for the output channels.
+func (in *Input) matrix(channels int) channel.Matrix {
+	var m channel.Matrix
+	inMask, outMask := wave.DefaultChannelMask(in.Channels), wave.DefaultChannelMask(channels)
+	switch {
+	case in.Channels == 1 && channels == 2:
+		l, r := panGains(in.Pan, in.PanLaw)
+		m = channel.Matrix{{l}, {r}}
+	case in.Channels == 2 && channels == 2:
+		// balance keeps the unity gain at the center
+		l, r := panGains(in.Pan, in.PanLaw)
+		cl, cr := panGains(0, in.PanLaw)
+		m = channel.Matrix{{math.Min(1, l/cl), 0}, {0, math.Min(1, r/cr)}}
+	case in.Channels != channels && inMask != 0 && outMask != 0:
+		m, _ = channel.NewMatrix(inMask, outMask, channel.Options{})
+	default:
+		m = channel.NewIdentity(in.Channels, channels)
+	}
+	g := math.Pow(10, in.Gain/20)
+	for _, row := range m {
+		for j := range row {
+			row[j] *= g
+		}
+	}
+	return m
+}
+
+// New returns a Mixer which produces channels at sampleRate from inputs.
+func New(channels, sampleRate int, inputs ...Input) *Mixer {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	m := &Mixer{
+		channels: channels,
+	}
+	for i := range inputs {
+		in := &inputs[i]
+		if in.Channels < 1 {
+			panic("you must have at least one channel")
+		}
+		r := in.Reader
+		if in.SampleRate > 0 && in.SampleRate != sampleRate {
+			q := in.Quality
+			if q <= 0 {
+				q = 4
+			}
+			r = resampler.NewReader(r, in.Channels, in.SampleRate, sampleRate, q)
+		}
+		m.inputs = append(m.inputs, &input{
+			r:      r,
+			matrix: in.matrix(channels),
+			offset: in.Offset,
+			buf:    make([][]float64, in.Channels),
+			tmp:    make([][]float64, channels),
+		})
+	}
+	return m
+}
+
+func grow(p []float64, n int) []float64 {
+	if cap(p) < n {
+		return make([]float64, n)
+	}
+	return p[:n]
+}
+
+// maxEmptyReads is the number of consecutive empty reads after which an input is considered stuck.
+const maxEmptyReads = 100
+
+// read mixes len(p[0]) frames of the input into p from frame start,
+// and returns the number of frames written, which is fewer only at the end of the input.
+func (in *input) read(p [][]float64, start int) (n int, err error) {
+	ln := len(p[0]) - start
+	for ch := range in.buf {
+		in.buf[ch] = grow(in.buf[ch], ln)
+	}
+	empty := 0
+	for n < ln {
+		var rn int
+		for ch := range in.buf {
+			in.buf[ch] = in.buf[ch][:ln-n]
+		}
+		rn, err = in.r.ReadFloat64Interleaved(in.buf)
+		if rn > 0 {
+			for ch := range in.tmp {
+				in.tmp[ch] = grow(in.tmp[ch], rn)
+			}
+			for ch := range in.buf {
+				in.buf[ch] = in.buf[ch][:rn]
+			}
+			in.matrix.ProcessFloat64(in.tmp, in.buf)
+			for ch, t := range in.tmp {
+				d := p[ch][start+n:]
+				for i, s := range t {
+					d[i] += s
+				}
+			}
+			n += rn
+		}
+		if err != nil {
+			return n, err
+		}
+		if rn == 0 {
+			// a short read is not the end of the input, so the input must fill the block
+			if empty++; empty >= maxEmptyReads {
+				return n, io.ErrNoProgress
+			}
+			continue
+		}
+		empty = 0
+	}
+	return n, nil
+}
+
+func (m *Mixer) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	for ch := range p {
+		for i := range p[ch] {
+			p[ch][i] = 0
+		}
+	}
+	active := false
+	for _, in := range m.inputs {
+		if in.done {
+			continue
+		}
+		active = true
+		start := in.offset - m.pos
+		if start >= int64(ln) {
+			// this input starts later, so the output must cover this block
+			n = ln
+			continue
+		}
+		if start < 0 {
+			start = 0
+		}
+		rn, rerr := in.read(p, int(start))
+		if end := int(start) + rn; end > n {
+			n = end
+		}
+		if rerr == io.EOF {
+			in.done = true
+			continue
+		}
+		if rerr != nil && err == nil {
+			// the other inputs are still read, so that the block stays aligned with them
+			err = rerr
+		}
+	}
+	if !active {
+		return 0, io.EOF
+	}
+	m.pos += int64(n)
+	return n, err
+}
+
+func (m *Mixer) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	if len(m.p64) != len(p) {
+		m.p64 = make([][]float64, len(p))
+	}
+	for ch := range p {
+		m.p64[ch] = grow(m.p64[ch], len(p[ch]))
+	}
+	n, err = m.ReadFloat64Interleaved(m.p64)
+	for ch := range p {
+		for i, s := range m.p64[ch][:n] {
+			p[ch][i] = float32(s)
+		}
+	}
+	return
+}
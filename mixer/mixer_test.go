@@ -0,0 +1,225 @@
+package mixer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/converter"
+)
+
+type constReader struct {
+	values []float64
+	frames int
+}
+
+func (r *constReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *constReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	for n < len(p[0]) && r.frames > 0 {
+		for ch := range p {
+			p[ch][n] = r.values[ch]
+		}
+		n++
+		r.frames--
+	}
+	if r.frames == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+func readAll(t *testing.T, m *Mixer, channels, bufSize int) [][]float64 {
+	r := make([][]float64, channels)
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, bufSize)
+	}
+	for {
+		n, err := m.ReadFloat64Interleaved(p)
+		for ch := range p {
+			r[ch] = append(r[ch], p[ch][:n]...)
+		}
+		if err == io.EOF {
+			return r
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMix(t *testing.T) {
+	m := New(2, 48000,
+		Input{Reader: &constReader{values: []float64{1}, frames: 100}, Channels: 1, Pan: -1},
+		Input{Reader: &constReader{values: []float64{0.25, 0.5}, frames: 50}, Channels: 2, Offset: 70, Gain: -6.0206},
+		Input{Reader: &constReader{values: []float64{1}, frames: 10}, Channels: 1, Offset: 20, PanLaw: Linear},
+	)
+	r := readAll(t, m, 2, 32)
+	if len(r[0]) != 120 {
+		t.Fatal("frames:", len(r[0]))
+	}
+	check := func(i int, l, rr float64) {
+		if math.Abs(r[0][i]-l) > 1e-4 || math.Abs(r[1][i]-rr) > 1e-4 {
+			t.Error("frame:", i, "got:", r[0][i], r[1][i], "expected:", l, rr)
+		}
+	}
+	check(0, 1, 0)
+	check(19, 1, 0)
+	check(20, 1.5, 0.5)
+	check(29, 1.5, 0.5)
+	check(30, 1, 0)
+	check(70, 1.125, 0.25)
+	check(99, 1.125, 0.25)
+	check(100, 0.125, 0.25)
+	check(119, 0.125, 0.25)
+}
+
+// stutterReader returns no frames on every other read.
+type stutterReader struct {
+	audio.InterleavedReader
+	empty bool
+}
+
+func (r *stutterReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if r.empty = !r.empty; r.empty {
+		return 0, nil
+	}
+	return r.InterleavedReader.ReadFloat64Interleaved(p)
+}
+
+// TestShortRead mixes inputs which return fewer frames than requested.
+func TestShortRead(t *testing.T) {
+	const frames = 200
+	ramp := make([]float64, frames)
+	for i := range ramp {
+		ramp[i] = float64(i) / 1024
+	}
+	b := make([]byte, frames*2)
+	converter.Int16.FromFloat64(ramp, b)
+	newReader := func() audio.InterleavedReader {
+		return audio.NewInterleavedReader(converter.Int16, iotest.OneByteReader(bytes.NewReader(b)))
+	}
+	for i, r := range []audio.InterleavedReader{
+		newReader(),
+		&stutterReader{InterleavedReader: newReader()},
+	} {
+		m := New(1, 48000,
+			Input{Reader: r, Channels: 1},
+			Input{Reader: &constReader{values: []float64{0}, frames: 300}, Channels: 1},
+		)
+		out := readAll(t, m, 1, 64)
+		if len(out[0]) != 300 {
+			t.Fatalf("readers[%d] frames: %d", i, len(out[0]))
+		}
+		for j, s := range out[0] {
+			want := 0.0
+			if j < frames {
+				want = ramp[j]
+			}
+			if math.Abs(s-want) > 1e-4 {
+				t.Fatalf("readers[%d] frame %d want %v got %v", i, j, want, s)
+			}
+		}
+	}
+}
+
+var errFail = errors.New("fail")
+
+// failReader returns an error after the frames of constReader, and io.EOF after that.
+type failReader struct {
+	constReader
+	failed bool
+}
+
+func (r *failReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if r.failed {
+		return 0, io.EOF
+	}
+	n, err = r.constReader.ReadFloat64Interleaved(p)
+	if err == io.EOF {
+		r.failed, err = true, errFail
+	}
+	return
+}
+
+// TestReadError mixes an input which fails partway through the block.
+func TestReadError(t *testing.T) {
+	m := New(1, 48000,
+		Input{Reader: &constReader{values: []float64{0.25}, frames: 100}, Channels: 1},
+		Input{Reader: &failReader{constReader: constReader{values: []float64{0.5}, frames: 10}}, Channels: 1},
+	)
+	p := [][]float64{make([]float64, 64)}
+	n, err := m.ReadFloat64Interleaved(p)
+	if n != 64 || err != errFail {
+		t.Fatalf("want 64, %v got %d, %v", errFail, n, err)
+	}
+	for i, s := range p[0][:n] {
+		want := 0.25
+		if i < 10 {
+			want = 0.75
+		}
+		if s != want {
+			t.Fatalf("frame %d want %v got %v", i, want, s)
+		}
+	}
+	if out := readAll(t, m, 1, 64); len(out[0]) != 36 {
+		t.Errorf("frames after the error want 36 got %d", len(out[0]))
+	}
+}
+
+func TestPan(t *testing.T) {
+	for _, tc := range []struct {
+		pan  float64
+		law  PanLaw
+		l, r float64
+	}{
+		{0, ConstantPower, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{1, ConstantPower, 0, 1},
+		{0, Linear, 0.5, 0.5},
+		{-0.5, Linear, 0.75, 0.25},
+	} {
+		l, r := panGains(tc.pan, tc.law)
+		if math.Abs(l-tc.l) > 1e-12 || math.Abs(r-tc.r) > 1e-12 {
+			t.Error(tc, "got:", l, r)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	m := New(1, 48000,
+		Input{Reader: &constReader{values: []float64{0.5}, frames: 2400}, Channels: 1, SampleRate: 24000},
+		Input{Reader: &constReader{values: []float64{0.25}, frames: 4800}, Channels: 1},
+	)
+	r := readAll(t, m, 1, 1000)
+	if len(r[0]) != 4800 {
+		t.Fatal("frames:", len(r[0]))
+	}
+	if v := r[0][2400]; math.Abs(v-0.75) > 1e-2 {
+		t.Error("unexpected value:", v)
+	}
+}
+
+func TestUpmix(t *testing.T) {
+	m := New(6, 48000, Input{Reader: &constReader{values: []float64{1, 0.5}, frames: 10}, Channels: 2})
+	var p [6][]float32
+	for ch := range p {
+		p[ch] = make([]float32, 16)
+	}
+	n, err := m.ReadFloat32Interleaved(p[:])
+	if n != 10 || err != nil {
+		t.Fatal(n, err)
+	}
+	if p[0][9] != 1 || p[1][9] != 0.5 || p[2][9] != 0 {
+		t.Error("unexpected values:", p[0][9], p[1][9], p[2][9])
+	}
+	if n, err = m.ReadFloat32Interleaved(p[:]); n != 0 || err != io.EOF {
+		t.Error(n, err)
+	}
+}
@@ -0,0 +1,166 @@
+// Package normalize implements two-pass loudness and peak normalization of waveform audio files.
+package normalize
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+
+	"github.com/oov/audio/loudness"
+	"github.com/oov/audio/wave"
+)
+
+// Mode selects the measurement used to calculate the gain.
+type Mode int
+
+const (
+	Loudness Mode = iota // integrated loudness in LUFS
+	Peak                 // sample peak in dBFS
+	TruePeak             // true peak in dBTP
+)
+
+// Options configures the normalization.
+type Options struct {
+	Mode   Mode
+	Target float64 // target level in LUFS, dBFS or dBTP according to Mode
+
+	// LimitTruePeak reduces the gain so that the true peak does not exceed Ceiling.
+	LimitTruePeak bool
+	Ceiling       float64 // true peak ceiling in dBTP
+
+	// Format is the format of the output file, the input format is used if nil.
+	// The number of channels and the sample rate must be the same as the input.
+	Format *wave.WaveFormatExtensible
+}
+
+// Result reports the measurement and the applied gain.
+type Result struct {
+	Gain float64 // applied gain in dB
+
+	Integrated float64 // integrated loudness of the input in LUFS
+	SamplePeak float64 // sample peak of the input in dBFS
+	TruePeak   float64 // true peak of the input in dBTP
+
+	PredictedSamplePeak float64 // sample peak of the output in dBFS
+	PredictedTruePeak   float64 // true peak of the output in dBTP
+	ClippedSamples      int64   // number of output samples which exceeded full scale
+}
+
+// Clipped reports whether the output was predicted to exceed full scale.
+func (r *Result) Clipped() bool {
+	return r.PredictedSamplePeak > 0
+}
+
+// Analyze measures the waveform audio data from r and calculates the gain.
+func Analyze(r io.Reader, opts Options) (*Result, error) {
+	m, err := loudness.MeasureWave(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Integrated: m.Integrated(),
+		SamplePeak: m.SamplePeak(),
+		TruePeak:   m.TruePeak(),
+	}
+	switch opts.Mode {
+	case Loudness:
+		res.Gain = opts.Target - res.Integrated
+	case Peak:
+		res.Gain = opts.Target - res.SamplePeak
+	case TruePeak:
+		res.Gain = opts.Target - res.TruePeak
+	default:
+		return nil, errors.New("normalize: unknown mode")
+	}
+	if math.IsInf(res.Gain, 0) || math.IsNaN(res.Gain) {
+		// silence
+		res.Gain = 0
+	}
+	if opts.LimitTruePeak && res.TruePeak+res.Gain > opts.Ceiling {
+		res.Gain = opts.Ceiling - res.TruePeak
+	}
+	res.PredictedSamplePeak = res.SamplePeak + res.Gain
+	res.PredictedTruePeak = res.TruePeak + res.Gain
+	return res, nil
+}
+
+// Wave normalizes the waveform audio data from r and writes it to w.
+//
+// r is read twice; the first pass measures the input and the second pass
+// applies the gain. Samples exceeding full scale are saturated when the
+// output format is integer PCM.
+func Wave(w io.Writer, r io.ReadSeeker, opts Options) (*Result, error) {
+	start, err := r.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Analyze(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = r.Seek(start, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	ar, wfext, err := wave.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	format := opts.Format
+	if format == nil {
+		format = wfext
+	}
+	if format.Format.Channels != wfext.Format.Channels {
+		return nil, errors.New("normalize: the number of channels must not be changed")
+	}
+	if format.Format.SamplesPerSec != wfext.Format.SamplesPerSec {
+		return nil, errors.New("normalize: the sample rate must not be changed")
+	}
+	aw, err := wave.NewWriter(w, format)
+	if err != nil {
+		return nil, err
+	}
+	saturate := format.SampleFormatTag() != wave.WAVE_FORMAT_IEEE_FLOAT
+
+	gain := math.Pow(10, res.Gain/20)
+	buf := make([][]float64, wfext.Format.Channels)
+	for ch := range buf {
+		buf[ch] = make([]float64, 4096)
+	}
+	p := make([][]float64, len(buf))
+	for {
+		n, rerr := ar.ReadFloat64Interleaved(buf)
+		if rerr != nil && rerr != io.EOF {
+			return nil, rerr
+		}
+		if n > 0 {
+			for ch := range buf {
+				p[ch] = buf[ch][:n]
+				for i, s := range p[ch] {
+					s *= gain
+					if s > 1 || s < -1 {
+						res.ClippedSamples++
+						if saturate {
+							s = math.Max(-1, math.Min(1, s))
+						}
+					}
+					p[ch][i] = s
+				}
+			}
+			if _, err = aw.WriteFloat64Interleaved(p); err != nil {
+				return nil, err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+	}
+	if err = aw.Close(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
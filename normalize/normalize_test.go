@@ -0,0 +1,133 @@
+package normalize
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/oov/audio/loudness"
+	"github.com/oov/audio/wave"
+)
+
+func makeWave(t *testing.T, amp float64) *bytes.Reader {
+	wfext := &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_IEEE_FLOAT,
+			Channels:       2,
+			SamplesPerSec:  48000,
+			AvgBytesPerSec: 48000 * 8,
+			BlockAlign:     8,
+			BitsPerSample:  32,
+		},
+	}
+	var buf bytes.Buffer
+	w, err := wave.NewWriter(&buf, wfext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := [][]float64{make([]float64, 48000*5), make([]float64, 48000*5)}
+	for i := range p[0] {
+		p[0][i] = amp * math.Sin(2*math.Pi*1000*float64(i)/48000)
+		p[1][i] = p[0][i]
+	}
+	if _, err = w.WriteFloat64Interleaved(p); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestLoudness(t *testing.T) {
+	var out bytes.Buffer
+	res, err := Wave(&out, makeWave(t, 0.01), Options{Mode: Loudness, Target: -23})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Gain-(-23-res.Integrated)) > 1e-9 || res.Clipped() || res.ClippedSamples != 0 {
+		t.Error("result:", res)
+	}
+
+	m, err := loudness.MeasureWave(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Integrated(); math.Abs(v - -23) > 0.05 {
+		t.Error("integrated:", v)
+	}
+}
+
+func TestCeiling(t *testing.T) {
+	var out bytes.Buffer
+	res, err := Wave(&out, makeWave(t, 0.5), Options{Mode: Loudness, Target: 0, LimitTruePeak: true, Ceiling: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.PredictedTruePeak - -1) > 1e-9 {
+		t.Error("predicted true peak:", res.PredictedTruePeak)
+	}
+}
+
+func TestClipping(t *testing.T) {
+	var out bytes.Buffer
+	format := &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_PCM,
+			Channels:       2,
+			SamplesPerSec:  48000,
+			AvgBytesPerSec: 48000 * 4,
+			BlockAlign:     4,
+			BitsPerSample:  16,
+		},
+	}
+	res, err := Wave(&out, makeWave(t, 0.5), Options{Mode: Peak, Target: 3, Format: format})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Clipped() || res.ClippedSamples == 0 {
+		t.Error("clipping must be reported:", res)
+	}
+
+	m, err := loudness.MeasureWave(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := m.SamplePeak(); v > 0 {
+		t.Error("sample peak:", v)
+	}
+
+	// the samples are not resampled
+	format.Format.SamplesPerSec = 44100
+	format.Format.AvgBytesPerSec = 44100 * 4
+	if _, err = Wave(ioutil.Discard, makeWave(t, 0.5), Options{Mode: Peak, Target: 3, Format: format}); err == nil {
+		t.Error("a different sample rate must be rejected")
+	}
+
+	// floating point output is not clamped even if it is WAVE_FORMAT_EXTENSIBLE
+	out.Reset()
+	format = &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_EXTENSIBLE,
+			Channels:       2,
+			SamplesPerSec:  48000,
+			AvgBytesPerSec: 48000 * 8,
+			BlockAlign:     8,
+			BitsPerSample:  32,
+			ExtSize:        22,
+		},
+		Samples:     32,
+		ChannelMask: wave.SPEAKER_FRONT_LEFT | wave.SPEAKER_FRONT_RIGHT,
+		SubFormat:   wave.KSDATAFORMAT_SUBTYPE_IEEE_FLOAT,
+	}
+	if _, err = Wave(&out, makeWave(t, 0.5), Options{Mode: Peak, Target: 3, Format: format}); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = loudness.MeasureWave(bytes.NewReader(out.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if v := m.SamplePeak(); math.Abs(v-3) > 0.01 {
+		t.Error("sample peak of floating point output:", v)
+	}
+}
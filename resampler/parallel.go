@@ -0,0 +1,128 @@
+package resampler
+
+import (
+	"sync"
+)
+
+type parallel struct {
+	workers int
+	jobs    chan int
+	wg      sync.WaitGroup
+
+	in64, out64   [][]float64
+	in32, out32   [][]float32
+	read, written []int
+}
+
+func (p *parallel) work(r *Resampler) {
+	for ch := range p.jobs {
+		if p.in64 != nil {
+			p.read[ch], p.written[ch] = r.ProcessFloat64(ch, p.in64[ch], p.out64[ch])
+		} else {
+			p.read[ch], p.written[ch] = r.ProcessFloat32(ch, p.in32[ch], p.out32[ch])
+		}
+		p.wg.Done()
+	}
+}
+
+// SetParallelism sets the number of worker goroutines used by
+// ProcessFloat64Interleaved and ProcessFloat32Interleaved.
+//
+// n <= 1 disables parallel processing and stops the workers.
+// The workers keep running until they are stopped, so Close or SetParallelism(0)
+// must be called before the resampler is discarded, otherwise the goroutines leak.
+func (r *Resampler) SetParallelism(n int) {
+	if r.par != nil {
+		close(r.par.jobs)
+		r.par = nil
+	}
+	if n <= 1 {
+		return
+	}
+	if n > len(r.channels) {
+		n = len(r.channels)
+	}
+
+	p := &parallel{
+		workers: n,
+		jobs:    make(chan int, len(r.channels)),
+		read:    make([]int, len(r.channels)),
+		written: make([]int, len(r.channels)),
+	}
+	for i := 0; i < n; i++ {
+		go p.work(r)
+	}
+	r.par = p
+}
+
+// Close stops the worker goroutines started by SetParallelism.
+// The resampler can still be used without parallel processing.
+func (r *Resampler) Close() error {
+	r.SetParallelism(0)
+	return nil
+}
+
+func (r *Resampler) prepareInterleaved() {
+	if r.skipZeros {
+		r.applySkipZeros()
+	}
+	r.started = true
+}
+
+// ProcessFloat64Interleaved resamples all channels at once.
+// in[i] and out[i] are the buffers of channel i.
+//
+// Channels are processed concurrently when SetParallelism has been called.
+// The results are the same as calling ProcessFloat64 for each channel in order.
+// It returns the counts of channel 0; channels fed with the same input length always advance equally.
+func (r *Resampler) ProcessFloat64Interleaved(in [][]float64, out [][]float64) (read int, written int) {
+	r.prepareInterleaved()
+	p := r.par
+	if p == nil {
+		for ch := range r.channels {
+			rn, wn := r.ProcessFloat64(ch, in[ch], out[ch])
+			if ch == 0 {
+				read, written = rn, wn
+			}
+		}
+		return
+	}
+
+	p.in64, p.out64 = in, out
+	p.wg.Add(len(r.channels))
+	for ch := range r.channels {
+		p.jobs <- ch
+	}
+	p.wg.Wait()
+	p.in64, p.out64 = nil, nil
+	return p.read[0], p.written[0]
+}
+
+// ProcessFloat32Interleaved resamples all channels at once.
+// in[i] and out[i] are the buffers of channel i.
+//
+// Channels are processed concurrently when SetParallelism has been called.
+// The results are the same as calling ProcessFloat32 for each channel in order.
+// It returns the counts of channel 0; channels fed with the same input length always advance equally.
+func (r *Resampler) ProcessFloat32Interleaved(in [][]float32, out [][]float32) (read int, written int) {
+	r.prepareInterleaved()
+	p := r.par
+	if p == nil {
+		for ch := range r.channels {
+			rn, wn := r.ProcessFloat32(ch, in[ch], out[ch])
+			if ch == 0 {
+				read, written = rn, wn
+			}
+		}
+		return
+	}
+
+	p.in32, p.out32 = in, out
+	p.wg.Add(len(r.channels))
+	for ch := range r.channels {
+		p.jobs <- ch
+	}
+	p.wg.Wait()
+	p.in32, p.out32 = nil, nil
+	return p.read[0], p.written[0]
+}
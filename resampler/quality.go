@@ -32,6 +32,12 @@
 
 package resampler
 
+import (
+	"math"
+
+	"github.com/oov/audio/window"
+)
+
 type kaiserTable struct {
 	table      []float64
 	oversample int
@@ -187,3 +193,28 @@ var (
 		},
 	}
 )
+
+// Kaiser12, Kaiser10, Kaiser8 and Kaiser6 are the windows of the filters of the resampler
+// as window functions, so the fir package can design filters with the same windows.
+// They are interpolated from the tables of quality 9-10, 5-8, 3-4 and 0-2 respectively.
+var (
+	Kaiser12 window.Func = kaiser12.window
+	Kaiser10 window.Func = kaiser10.window
+	Kaiser8  window.Func = kaiser8.window
+	Kaiser6  window.Func = kaiser6.window
+)
+
+// window fills w with the window interpolated from the table.
+func (t *kaiserTable) window(w []float64, periodic bool) {
+	if len(w) == 1 {
+		w[0] = 1
+		return
+	}
+	d := float64(len(w) - 1)
+	if periodic {
+		d = float64(len(w))
+	}
+	for i := range w {
+		w[i] = computeFunc(math.Abs(2*float64(i)/d-1), t)
+	}
+}
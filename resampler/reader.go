@@ -0,0 +1,120 @@
+package resampler
+
+import (
+	"io"
+
+	"github.com/oov/audio"
+)
+
+type reader struct {
+	r     audio.InterleavedReader
+	rs    *Resampler
+	buf   [][]float64 // samples read from r
+	pos   int
+	end   int
+	eof   error
+	flush int // remaining samples of the filter tail
+	in    [][]float64
+	out   [][]float64
+	p64   [][]float64
+}
+
+// NewReader returns an InterleavedReader which reads from r at inSampleRate
+// and returns the samples resampled to outSampleRate.
+// The output is aligned with the input, and the filter tail is drained at the end of r.
+func NewReader(r audio.InterleavedReader, channels int, inSampleRate, outSampleRate int, quality int) audio.InterleavedReader {
+	rs := NewWithSkipZeros(channels, inSampleRate, outSampleRate, quality)
+	rd := &reader{
+		r:     r,
+		rs:    rs,
+		buf:   make([][]float64, channels),
+		flush: rs.OutputLatency(),
+		in:    make([][]float64, channels),
+		out:   make([][]float64, channels),
+	}
+	for ch := range rd.buf {
+		rd.buf[ch] = make([]float64, bufferSize)
+	}
+	return rd
+}
+
+// ReadCloser is an InterleavedReader which holds resources until it is closed.
+type ReadCloser interface {
+	audio.InterleavedReader
+	io.Closer
+}
+
+// NewParallelReader works as NewReader, and resamples the channels with n worker goroutines
+// as Resampler.SetParallelism does. The workers are stopped when the reader returns
+// io.EOF or an error, so Close is only needed if the reader is not read to the end.
+func NewParallelReader(r audio.InterleavedReader, channels int, inSampleRate, outSampleRate int, quality int, n int) ReadCloser {
+	rd := NewReader(r, channels, inSampleRate, outSampleRate, quality).(*reader)
+	rd.rs.SetParallelism(n)
+	return rd
+}
+
+// Close stops the worker goroutines of the resampler.
+func (r *reader) Close() error {
+	return r.rs.Close()
+}
+
+func (r *reader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	if len(r.p64) != len(p) {
+		r.p64 = make([][]float64, len(p))
+	}
+	for ch := range p {
+		if cap(r.p64[ch]) < len(p[ch]) {
+			r.p64[ch] = make([]float64, len(p[ch]))
+		}
+		r.p64[ch] = r.p64[ch][:len(p[ch])]
+	}
+	n, err = r.ReadFloat64Interleaved(r.p64)
+	for ch := range p {
+		for i, s := range r.p64[ch][:n] {
+			p[ch][i] = float32(s)
+		}
+	}
+	return
+}
+
+func (r *reader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	for n < ln {
+		if r.pos == r.end && r.eof == nil {
+			r.pos = 0
+			r.end, r.eof = r.r.ReadFloat64Interleaved(r.buf)
+			if r.end == 0 && r.eof == nil {
+				continue
+			}
+		}
+		if r.pos == r.end {
+			if r.eof != io.EOF || r.flush == 0 {
+				// the resampler is no longer used, so the workers started by NewParallelReader
+				// must not outlive the reader
+				r.Close()
+				if n == 0 {
+					err = r.eof
+				}
+				return
+			}
+			var written int
+			for ch := range p {
+				written = r.rs.FlushFloat64(ch, p[ch][n:imin(ln, n+r.flush)])
+			}
+			if written == 0 {
+				r.flush = 0
+			}
+			r.flush -= written
+			n += written
+			continue
+		}
+		for ch := range p {
+			r.in[ch] = r.buf[ch][r.pos:r.end]
+			r.out[ch] = p[ch][n:]
+		}
+		read, written := r.rs.ProcessFloat64Interleaved(r.in, r.out)
+		r.pos += read
+		n += written
+	}
+	return
+}
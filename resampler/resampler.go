@@ -36,6 +36,9 @@
 package resampler
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"math"
 )
 
@@ -59,13 +62,16 @@ type Resampler struct {
 	cutoff      float64
 	oversample  int
 
-	initialised bool
-	started     bool
-	skipZeros   bool
+	initialised    bool
+	started        bool
+	skipZeros      bool
+	resetSkipZeros bool
 
 	channels  []channelState
 	sincTable []float64
 	resampler func(channelIndex int, in []float64, out []float64) int
+
+	par *parallel
 }
 
 func Resample64(in []float64, inSampleRate int, out []float64, outSampleRate int, quality int) (read int, written int) {
@@ -80,24 +86,34 @@ func New(channels int, inSampleRate, outSampleRate int, quality int) *Resampler
 	if channels < 1 {
 		panic("you must have at least one channel")
 	}
-	r := &Resampler{
-		cutoff:   1.0,
-		channels: make([]channelState, channels),
-	}
-
-	r.setQuality(quality)
-	r.setSampleRate(inSampleRate, outSampleRate)
-	r.updateFilter()
-	r.initialised = true
+	r := &Resampler{}
+	r.init(channels, inSampleRate, outSampleRate, quality)
 	return r
 }
 
 func NewWithSkipZeros(channels int, inSampleRate, outSampleRate int, quality int) *Resampler {
 	r := New(channels, inSampleRate, outSampleRate, quality)
 	r.skipZeros = true
+	r.resetSkipZeros = true
 	return r
 }
 
+func (r *Resampler) init(channels int, inSampleRate, outSampleRate int, quality int) {
+	r.numRate, r.denRate = 0, 0
+	r.quality = nil
+	r.filtLen = 0
+	r.cutoff = 1.0
+	r.sincTable = nil
+	r.initialised, r.started = false, false
+	r.skipZeros, r.resetSkipZeros = false, false
+	r.channels = make([]channelState, channels)
+
+	r.setQuality(quality)
+	r.setSampleRate(inSampleRate, outSampleRate)
+	r.updateFilter()
+	r.initialised = true
+}
+
 // cannot change quality on running in now implementation.
 func (r *Resampler) setQuality(q int) {
 	if q < 0 || q > 10 {
@@ -159,10 +175,7 @@ func (r *Resampler) setSampleRate(in int, out int) {
 
 func (r *Resampler) ProcessFloat64(channelIndex int, in []float64, out []float64) (read int, written int) {
 	if r.skipZeros {
-		for i := range r.channels {
-			r.channels[i].lastSample = r.InputLatency()
-		}
-		r.skipZeros = false
+		r.applySkipZeros()
 	}
 
 	ch := &r.channels[channelIndex]
@@ -204,10 +217,7 @@ func (r *Resampler) ProcessFloat32(channelIndex int, in []float32, out []float32
 	var stack [stackSize]float64
 
 	if r.skipZeros {
-		for i := range r.channels {
-			r.channels[i].lastSample = r.InputLatency()
-		}
-		r.skipZeros = false
+		r.applySkipZeros()
 	}
 
 	ch := &r.channels[channelIndex]
@@ -255,9 +265,18 @@ func (r *Resampler) ProcessFloat32(channelIndex int, in []float32, out []float32
 	return
 }
 
+func (r *Resampler) applySkipZeros() {
+	for i := range r.channels {
+		r.channels[i].lastSample = r.InputLatency()
+	}
+	r.skipZeros = false
+}
+
 func (r *Resampler) processNative(channelIndex int, inLen int, out []float64) (inLenRet int, outLenRet int) {
 	ch := &r.channels[channelIndex]
-	r.started = true
+	if !r.started {
+		r.started = true
+	}
 
 	outLenRet = r.resampler(channelIndex, ch.mem[:inLen], out)
 	if ch.lastSample < inLen {
@@ -542,3 +561,172 @@ func (r *Resampler) InputLatency() int {
 func (r *Resampler) OutputLatency() int {
 	return ((r.filtLen>>1)*r.denRate + (r.numRate >> 1)) / r.numRate
 }
+
+// Reset clears the filter memory of all channels.
+// It should be called before processing an unrelated stream.
+func (r *Resampler) Reset() {
+	for i := range r.channels {
+		ch := &r.channels[i]
+		ch.lastSample = 0
+		ch.sampFracNum = 0
+		ch.magicSamples = 0
+		for j := range ch.mem {
+			ch.mem[j] = 0
+		}
+	}
+	r.skipZeros = r.resetSkipZeros
+}
+
+// FlushFloat64 drains the filter tail of the channel by feeding zeros.
+// It writes up to OutputLatency samples to out.
+func (r *Resampler) FlushFloat64(channelIndex int, out []float64) (written int) {
+	var zeros [bufferSize]float64
+	n := imin(r.OutputLatency(), len(out))
+	for written < n {
+		read, wn := r.ProcessFloat64(channelIndex, zeros[:], out[written:n])
+		if read == 0 && wn == 0 {
+			break
+		}
+		written += wn
+	}
+	return
+}
+
+// FlushFloat32 drains the filter tail of the channel by feeding zeros.
+// It writes up to OutputLatency samples to out.
+func (r *Resampler) FlushFloat32(channelIndex int, out []float32) (written int) {
+	var zeros [bufferSize]float32
+	n := imin(r.OutputLatency(), len(out))
+	for written < n {
+		read, wn := r.ProcessFloat32(channelIndex, zeros[:], out[written:n])
+		if read == 0 && wn == 0 {
+			break
+		}
+		written += wn
+	}
+	return
+}
+
+const stateVersion = 1
+
+type stateHeader struct {
+	Version  uint8
+	Flags    uint8
+	Quality  int32
+	NumRate  int32
+	DenRate  int32
+	Channels int32
+}
+
+type channelStateHeader struct {
+	LastSample   int32
+	SampFracNum  int32
+	MagicSamples int32
+	MemLen       int32
+}
+
+const (
+	stateFlagStarted = 1 << iota
+	stateFlagSkipZeros
+	stateFlagResetSkipZeros
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The encoded data contains the configuration and the running state of all channels.
+func (r *Resampler) MarshalBinary() ([]byte, error) {
+	var flags uint8
+	if r.started {
+		flags |= stateFlagStarted
+	}
+	if r.skipZeros {
+		flags |= stateFlagSkipZeros
+	}
+	if r.resetSkipZeros {
+		flags |= stateFlagResetSkipZeros
+	}
+
+	q := 0
+	for q < len(qualityMap) && &qualityMap[q] != r.quality {
+		q++
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, stateHeader{
+		Version:  stateVersion,
+		Flags:    flags,
+		Quality:  int32(q),
+		NumRate:  int32(r.numRate),
+		DenRate:  int32(r.denRate),
+		Channels: int32(len(r.channels)),
+	})
+	for i := range r.channels {
+		ch := &r.channels[i]
+		binary.Write(&buf, binary.LittleEndian, channelStateHeader{
+			LastSample:   int32(ch.lastSample),
+			SampFracNum:  int32(ch.sampFracNum),
+			MagicSamples: int32(ch.magicSamples),
+			MemLen:       int32(len(ch.mem)),
+		})
+		binary.Write(&buf, binary.LittleEndian, ch.mem)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It restores the configuration and the running state saved by MarshalBinary.
+func (r *Resampler) UnmarshalBinary(data []byte) error {
+	rd := bytes.NewReader(data)
+
+	var h stateHeader
+	if err := binary.Read(rd, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+	if h.Version != stateVersion {
+		return errors.New("resampler: unsupported state version")
+	}
+	if h.Quality < 0 || int(h.Quality) >= len(qualityMap) ||
+		h.NumRate < 1 || h.DenRate < 1 || h.Channels < 1 || int(h.Channels)*16 > rd.Len() {
+		return errors.New("resampler: invalid state")
+	}
+
+	chs := make([]channelStateHeader, h.Channels)
+	mems := make([][]float64, h.Channels)
+	for i := range chs {
+		if err := binary.Read(rd, binary.LittleEndian, &chs[i]); err != nil {
+			return err
+		}
+		if chs[i].MemLen < 0 || int(chs[i].MemLen)*8 > rd.Len() {
+			return errors.New("resampler: invalid state")
+		}
+		mems[i] = make([]float64, chs[i].MemLen)
+		if err := binary.Read(rd, binary.LittleEndian, mems[i]); err != nil {
+			return err
+		}
+	}
+
+	// the filter length depends on the header, so it is checked with a temporary Resampler
+	// to leave r untouched when the state is rejected.
+	var tmp Resampler
+	tmp.init(int(h.Channels), int(h.NumRate), int(h.DenRate), int(h.Quality))
+	for i := range tmp.channels {
+		if len(tmp.channels[i].mem) != len(mems[i]) {
+			return errors.New("resampler: invalid state")
+		}
+	}
+
+	r.init(int(h.Channels), int(h.NumRate), int(h.DenRate), int(h.Quality))
+	for i := range r.channels {
+		ch := &r.channels[i]
+		ch.lastSample = int(chs[i].LastSample)
+		ch.sampFracNum = int(chs[i].SampFracNum)
+		ch.magicSamples = int(chs[i].MagicSamples)
+		copy(ch.mem, mems[i])
+	}
+	if r.par != nil && len(r.par.read) != len(r.channels) {
+		r.SetParallelism(r.par.workers)
+	}
+	r.started = h.Flags&stateFlagStarted != 0
+	r.skipZeros = h.Flags&stateFlagSkipZeros != 0
+	r.resetSkipZeros = h.Flags&stateFlagResetSkipZeros != 0
+	return nil
+}
@@ -1,9 +1,13 @@
 package resampler
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"testing"
+
+	"github.com/oov/audio"
 )
 
 var (
@@ -180,3 +184,250 @@ func TestInterpolateUpSampling32(t *testing.T) {
 		}
 	}
 }
+
+func TestReset(t *testing.T) {
+	r := NewWithSkipZeros(1, 48000, 44100, 5)
+	in := make([]float64, 480)
+	copy(in, wave)
+	a := make([]float64, 441)
+	_, wa := r.ProcessFloat64(0, in, a)
+
+	r.Reset()
+	b := make([]float64, 441)
+	_, wb := r.ProcessFloat64(0, in, b)
+	if wa != wb {
+		t.Fatal("written mismatch:", wa, wb)
+	}
+	for i := range a[:wa] {
+		if a[i] != b[i] {
+			t.Fatal("sample mismatch at", i, a[i], b[i])
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	for _, rate := range []int{24000, 44100, 96000} {
+		r := NewWithSkipZeros(1, 48000, rate, 5)
+		in := make([]float64, 4800)
+		out := make([]float64, rate/10+r.OutputLatency())
+		read, written := r.ProcessFloat64(0, in, out)
+		if read != len(in) {
+			t.Fatal("read:", read)
+		}
+		written += r.FlushFloat64(0, out[written:])
+		if d := written - rate/10; d < -1 || d > 1 {
+			t.Error("samplerate:", rate, "written:", written, "expected:", rate/10)
+		}
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	in := make([]float64, 4800)
+	for i := range in {
+		in[i] = math.Sin(float64(i) * 0.01)
+	}
+
+	r := NewWithSkipZeros(2, 48000, 44100, 5)
+	expected := make([]float64, 4410)
+	_, we := r.ProcessFloat64(0, in, expected)
+
+	r = NewWithSkipZeros(2, 48000, 44100, 5)
+	out := make([]float64, 4410)
+	rn, wn := r.ProcessFloat64(0, in[:1234], out)
+	b, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r2 Resampler
+	if err = r2.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	rn2, wn2 := r2.ProcessFloat64(0, in[rn:], out[wn:])
+	if rn+rn2 != len(in) || wn+wn2 != we {
+		t.Fatal("read:", rn+rn2, "written:", wn+wn2, "expected:", we)
+	}
+	for i := range expected[:we] {
+		if expected[i] != out[i] {
+			t.Fatal("sample mismatch at", i, expected[i], out[i])
+		}
+	}
+
+	if err = r2.UnmarshalBinary(b[:len(b)-1]); err == nil {
+		t.Error("truncated state must be rejected")
+	}
+
+	// a state with a wrong memory length must not change the resampler
+	r3 := NewWithSkipZeros(2, 48000, 44100, 5)
+	r3.ProcessFloat64(0, in[:1234], make([]float64, 4410))
+	// the memory of the first channel is one sample shorter than the filter needs
+	memLen := len(r3.channels[0].mem)
+	off := binary.Size(stateHeader{}) + binary.Size(channelStateHeader{})
+	bad := append([]byte(nil), b[:off+memLen*8-8]...)
+	bad = append(bad, b[off+memLen*8:]...)
+	binary.LittleEndian.PutUint32(bad[off-4:], uint32(memLen-1))
+	if err = r3.UnmarshalBinary(bad); err == nil {
+		t.Error("state with a wrong memory length must be rejected")
+	}
+	out3 := make([]float64, 4410)
+	rn3, wn3 := r3.ProcessFloat64(0, in[rn:], out3)
+	if rn3 != rn2 || wn3 != wn2 {
+		t.Fatal("read:", rn3, "written:", wn3, "expected:", rn2, wn2)
+	}
+	for i := range out3[:wn3] {
+		if out3[i] != out[wn+i] {
+			t.Fatal("sample mismatch after a rejected state at", i)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	const channels = 16
+	in, serialOut, parallelOut := make([][]float64, channels), make([][]float64, channels), make([][]float64, channels)
+	for ch := range in {
+		in[ch] = make([]float64, 4800)
+		for i := range in[ch] {
+			in[ch][i] = math.Sin(float64(i*(ch+1)) * 0.01)
+		}
+		serialOut[ch] = make([]float64, 4410)
+		parallelOut[ch] = make([]float64, 4410)
+	}
+
+	serial := NewWithSkipZeros(channels, 48000, 44100, 5)
+	parallel := NewWithSkipZeros(channels, 48000, 44100, 5)
+	parallel.SetParallelism(4)
+	defer parallel.SetParallelism(0)
+
+	rs, ws := serial.ProcessFloat64Interleaved(in, serialOut)
+	rp, wp := parallel.ProcessFloat64Interleaved(in, parallelOut)
+	if rs != rp || ws != wp {
+		t.Fatal("serial:", rs, ws, "parallel:", rp, wp)
+	}
+	for ch := range serialOut {
+		for i := range serialOut[ch][:ws] {
+			if serialOut[ch][i] != parallelOut[ch][i] {
+				t.Fatal("sample mismatch at", ch, i)
+			}
+		}
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		parallel.ProcessFloat64Interleaved(in, parallelOut)
+	})
+	if allocs != 0 {
+		t.Error("allocs:", allocs)
+	}
+}
+
+func TestParallel32(t *testing.T) {
+	const channels = 8
+	in, out := make([][]float32, channels), make([][]float32, channels)
+	for ch := range in {
+		in[ch] = make([]float32, 480)
+		copy(in[ch], []float32{1, 1, 1, 1})
+		out[ch] = make([]float32, 960)
+	}
+
+	r := New(channels, 24000, 48000, 3)
+	r.SetParallelism(3)
+	defer r.SetParallelism(0)
+	read, written := r.ProcessFloat32Interleaved(in, out)
+	if read != 480 || written != 960 {
+		t.Fatal("read:", read, "written:", written)
+	}
+	for ch := range out[1:] {
+		for i := range out[0] {
+			if out[0][i] != out[ch+1][i] {
+				t.Fatal("sample mismatch at", ch+1, i)
+			}
+		}
+	}
+
+	// Close stops the workers, and the resampler keeps working serially
+	r.Close()
+	r.Close()
+	if r.par != nil {
+		t.Fatal("workers are not stopped")
+	}
+	if read, _ := r.ProcessFloat32Interleaved(in, out); read != 480 {
+		t.Error("read after Close:", read)
+	}
+}
+
+type sliceReader struct {
+	p   []float64
+	max int
+}
+
+func (r *sliceReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *sliceReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if len(r.p) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(p[0][:imin(len(p[0]), r.max)], r.p)
+	copy(p[1], p[0][:n])
+	r.p = r.p[n:]
+	return n, nil
+}
+
+func TestReader(t *testing.T) {
+	in := make([]float64, 4800)
+	for i := range in {
+		in[i] = math.Sin(float64(i) * 0.01)
+	}
+	for _, rate := range []int{24000, 44100, 96000} {
+		expected := make([]float64, rate/10+100)
+		r := NewWithSkipZeros(1, 48000, rate, 4)
+		_, we := r.ProcessFloat64(0, in, expected)
+		we += r.FlushFloat64(0, expected[we:])
+
+		for _, workers := range []int{0, 2} {
+			var rd audio.InterleavedReader
+			if workers > 0 {
+				rd = NewParallelReader(&sliceReader{p: in, max: 333}, 2, 48000, rate, 4, workers)
+			} else {
+				rd = NewReader(&sliceReader{p: in, max: 333}, 2, 48000, rate, 4)
+			}
+			var out []float64
+			p := [][]float32{make([]float32, 100), make([]float32, 100)}
+			for {
+				n, err := rd.ReadFloat32Interleaved(p)
+				for i := range p[0][:n] {
+					out = append(out, float64(p[0][i]))
+				}
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(out) != we {
+				t.Fatal("samplerate:", rate, "read:", len(out), "expected:", we)
+			}
+			for i := range out {
+				if math.Abs(out[i]-expected[i]) > 1e-6 {
+					t.Fatal("samplerate:", rate, "mismatch at", i, out[i], expected[i])
+				}
+			}
+			if rd.(*reader).rs.par != nil {
+				t.Fatal("samplerate:", rate, "the workers must be stopped at the end")
+			}
+		}
+	}
+
+	// a reader which is not read to the end is closed
+	rd := NewParallelReader(&sliceReader{p: in, max: 333}, 2, 48000, 44100, 4, 2)
+	if _, err := rd.ReadFloat64Interleaved([][]float64{make([]float64, 100), make([]float64, 100)}); err != nil {
+		t.Fatal(err)
+	}
+	if rd.(*reader).rs.par == nil {
+		t.Fatal("the workers must run until the end")
+	}
+	if err := rd.Close(); err != nil || rd.(*reader).rs.par != nil {
+		t.Fatal("the workers must be stopped by Close:", err)
+	}
+}
@@ -0,0 +1,60 @@
+package reverb
+
+import (
+	"github.com/oov/audio/fir"
+)
+
+const (
+	partitionsPerSegment = 4
+	maxBlockSize         = 16384
+)
+
+// segmentedConvolver is a non-uniformly partitioned convolver.
+//
+// The head of the kernel is handled by small blocks for low latency,
+// and every following segment doubles the block size so that long kernels
+// are processed with few large transforms. The output of every segment is
+// delayed by the latency of the first one.
+type segmentedConvolver struct {
+	segments []*fir.Convolver
+	tmp      []float64
+}
+
+func newSegmentedConvolver(kernel []float64, blockSize int) *segmentedConvolver {
+	c := &segmentedConvolver{}
+	for offset, bs := 0, blockSize; ; bs *= 2 {
+		n := bs * partitionsPerSegment
+		if bs >= maxBlockSize || offset+n+2*n > len(kernel) {
+			// the last segment takes the rest
+			n = len(kernel) - offset
+		}
+		// the segment starts at offset, but its convolver adds bs samples of latency
+		k := make([]float64, offset+blockSize-bs+n)
+		copy(k[offset+blockSize-bs:], kernel[offset:offset+n])
+		c.segments = append(c.segments, fir.NewConvolver(1, k, bs))
+		if offset += n; offset >= len(kernel) {
+			break
+		}
+	}
+	return c
+}
+
+func (c *segmentedConvolver) reset() {
+	for _, s := range c.segments {
+		s.Reset()
+	}
+}
+
+// process convolves input and adds the result to output.
+func (c *segmentedConvolver) process(input, output []float64) {
+	if cap(c.tmp) < len(input) {
+		c.tmp = make([]float64, len(input))
+	}
+	tmp := c.tmp[:len(input)]
+	for _, s := range c.segments {
+		s.ProcessFloat64(0, input, tmp)
+		for i, v := range tmp {
+			output[i] += v
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package reverb implements a multi-channel convolution reverb.
+//
+// Impulse responses are applied with non-uniformly partitioned convolution,
+// so multi-second responses run with the latency of a small block.
+package reverb
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/oov/audio/resampler"
+	"github.com/oov/audio/wave"
+)
+
+// Options configures a Reverb.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	Dry       float64 // gain of the dry signal in dB, math.Inf(-1) mutes it
+	Wet       float64 // gain of the wet signal in dB, math.Inf(-1) mutes it
+	PreDelay  float64 // delay of the wet signal in seconds
+	BlockSize int     // size of the first partition in samples (default 128)
+	Quality   int     // resampler quality used when the impulse response has another sample rate (default 10)
+}
+
+var errChannels = errors.New("reverb: unsupported number of impulse response channels")
+
+// Reverb is a stateful multi-channel convolution reverb.
+type Reverb struct {
+	latency int
+	dry     float64
+	wet     float64
+	// paths[out] holds the convolvers which feed output channel out
+	paths [][]path
+	delay [][]float64 // delayed dry signal
+	pos   int
+
+	in  [][]float64
+	out [][]float64
+}
+
+type path struct {
+	input int
+	conv  *segmentedConvolver
+}
+
+// New returns a Reverb which applies the impulse response ir recorded at irSampleRate.
+// ir[i] is the response of channel i and is resampled when irSampleRate differs from sampleRate,
+// in which case the response is delayed by the output latency of the resampler.
+//
+// ir can have one channel, which is applied to every channel, or the same
+// number of channels as the input. For stereo input, four channels are
+// treated as a true stereo response in the order L to L, L to R, R to L and R to R.
+func New(channels, sampleRate int, ir [][]float64, irSampleRate int, opts Options) (*Reverb, error) {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = 128
+	}
+	if opts.Quality <= 0 {
+		opts.Quality = 10
+	}
+	var routes [][2]int // pairs of input and output channels for every ir channel
+	switch {
+	case len(ir) == 1:
+		for ch := 0; ch < channels; ch++ {
+			routes = append(routes, [2]int{ch, ch})
+		}
+	case len(ir) == channels:
+		for ch := range ir {
+			routes = append(routes, [2]int{ch, ch})
+		}
+	case len(ir) == 4 && channels == 2:
+		routes = [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	default:
+		return nil, errChannels
+	}
+
+	preDelay := int(opts.PreDelay*float64(sampleRate) + 0.5)
+	kernels := make([][]float64, len(ir))
+	for i, h := range ir {
+		if irSampleRate != sampleRate {
+			h = resample(h, irSampleRate, sampleRate, opts.Quality)
+		}
+		kernels[i] = make([]float64, preDelay+len(h))
+		copy(kernels[i][preDelay:], h)
+	}
+
+	r := &Reverb{
+		latency: opts.BlockSize,
+		dry:     math.Pow(10, opts.Dry/20),
+		wet:     math.Pow(10, opts.Wet/20),
+		paths:   make([][]path, channels),
+		delay:   make([][]float64, channels),
+		in:      make([][]float64, channels),
+		out:     make([][]float64, channels),
+	}
+	for i, rt := range routes {
+		k := kernels[0]
+		if len(kernels) > 1 {
+			k = kernels[i]
+		}
+		r.paths[rt[1]] = append(r.paths[rt[1]], path{
+			input: rt[0],
+			conv:  newSegmentedConvolver(k, opts.BlockSize),
+		})
+	}
+	for ch := range r.delay {
+		r.delay[ch] = make([]float64, r.latency)
+	}
+	return r, nil
+}
+
+// NewWave returns a Reverb which applies the impulse response read from the waveform audio data of irWave.
+func NewWave(channels, sampleRate int, irWave io.Reader, opts Options) (*Reverb, error) {
+	ar, wfext, err := wave.NewReader(irWave)
+	if err != nil {
+		return nil, err
+	}
+	ir := make([][]float64, wfext.Format.Channels)
+	buf := make([][]float64, len(ir))
+	for ch := range buf {
+		buf[ch] = make([]float64, 4096)
+	}
+	for {
+		n, rerr := ar.ReadFloat64Interleaved(buf)
+		for ch := range ir {
+			ir[ch] = append(ir[ch], buf[ch][:n]...)
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+	return New(channels, sampleRate, ir, int(wfext.Format.SamplesPerSec), opts)
+}
+
+// resample converts h to outSampleRate and scales it so that the gain of the convolution is kept.
+// The leading zeros of the resampler are kept, because they hold the pre-ringing of the filter.
+func resample(h []float64, inSampleRate, outSampleRate, quality int) []float64 {
+	rs := resampler.New(1, inSampleRate, outSampleRate, quality)
+	out := make([]float64, int(int64(len(h))*int64(outSampleRate)/int64(inSampleRate))+2*rs.OutputLatency()+2)
+	var written int
+	for len(h) > 0 && written < len(out) {
+		read, w := rs.ProcessFloat64(0, h, out[written:])
+		h, written = h[read:], written+w
+	}
+	written += rs.FlushFloat64(0, out[written:])
+	out = out[:written]
+	g := float64(inSampleRate) / float64(outSampleRate)
+	for i := range out {
+		out[i] *= g
+	}
+	return out
+}
+
+// Latency returns the delay of the output in samples.
+// The dry signal is delayed by the same amount so that it stays aligned with the wet signal.
+func (r *Reverb) Latency() int {
+	return r.latency
+}
+
+// Reset clears the state of all channels.
+func (r *Reverb) Reset() {
+	for _, paths := range r.paths {
+		for _, p := range paths {
+			p.conv.reset()
+		}
+	}
+	for _, d := range r.delay {
+		for i := range d {
+			d[i] = 0
+		}
+	}
+	r.pos = 0
+}
+
+func grow(p []float64, n int) []float64 {
+	if cap(p) < n {
+		return make([]float64, n)
+	}
+	return p[:n]
+}
+
+// process reads r.in and writes r.out. All buffers have the same length.
+func (r *Reverb) process() {
+	for ch, paths := range r.paths {
+		out := r.out[ch]
+		for i := range out {
+			out[i] = 0
+		}
+		for _, p := range paths {
+			p.conv.process(r.in[p.input], out)
+		}
+	}
+	pos := r.pos
+	for ch, out := range r.out {
+		d, in := r.delay[ch], r.in[ch]
+		pos = r.pos
+		for i, s := range in {
+			out[i] = out[i]*r.wet + d[pos]*r.dry
+			d[pos] = s
+			if pos++; pos == len(d) {
+				pos = 0
+			}
+		}
+	}
+	r.pos = pos
+}
+
+// ProcessFloat64 processes p in place. p[i] is the buffer of channel i.
+func (r *Reverb) ProcessFloat64(p [][]float64) {
+	if len(p) == 0 {
+		return
+	}
+	n := len(p[0])
+	for ch := range r.in {
+		r.in[ch] = grow(r.in[ch], n)
+		r.out[ch] = grow(r.out[ch], n)
+		copy(r.in[ch], p[ch])
+	}
+	r.process()
+	for ch := range p {
+		copy(p[ch], r.out[ch])
+	}
+}
+
+// ProcessFloat32 processes p in place. p[i] is the buffer of channel i.
+func (r *Reverb) ProcessFloat32(p [][]float32) {
+	if len(p) == 0 {
+		return
+	}
+	n := len(p[0])
+	for ch := range r.in {
+		r.in[ch] = grow(r.in[ch], n)
+		r.out[ch] = grow(r.out[ch], n)
+		for i, s := range p[ch] {
+			r.in[ch][i] = float64(s)
+		}
+	}
+	r.process()
+	for ch := range p {
+		for i, s := range r.out[ch] {
+			p[ch][i] = float32(s)
+		}
+	}
+}
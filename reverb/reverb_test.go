@@ -0,0 +1,177 @@
+package reverb
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/oov/audio/wave"
+)
+
+func directConvolve(x, h []float64) []float64 {
+	y := make([]float64, len(x))
+	for i := range y {
+		for j, v := range h {
+			if i-j < 0 {
+				break
+			}
+			y[i] += x[i-j] * v
+		}
+	}
+	return y
+}
+
+func TestReverb(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ir := make([]float64, 30000)
+	for i := range ir {
+		ir[i] = rnd.NormFloat64() * math.Exp(-float64(i)/5000)
+	}
+	x := make([]float64, 40000)
+	for i := range x {
+		x[i] = rnd.Float64()*2 - 1
+	}
+	expected := directConvolve(x, ir)
+
+	r, err := New(1, 48000, [][]float64{ir}, 48000, Options{Dry: math.Inf(-1), BlockSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]float64, len(x))
+	copy(out, x)
+	// feed odd sized chunks
+	for i := 0; i < len(out); i += 333 {
+		end := i + 333
+		if end > len(out) {
+			end = len(out)
+		}
+		r.ProcessFloat64([][]float64{out[i:end]})
+	}
+	l := r.Latency()
+	for i := l; i < len(out); i++ {
+		if math.Abs(out[i]-expected[i-l]) > 1e-8 {
+			t.Fatal("mismatch at", i, out[i], expected[i-l])
+		}
+	}
+}
+
+func TestDryWet(t *testing.T) {
+	// an impulse response of a half gain impulse at 10 samples
+	ir := make([]float64, 11)
+	ir[10] = 0.5
+	r, err := New(2, 48000, [][]float64{ir}, 48000, Options{Wet: -6.0206, PreDelay: 0.0005, BlockSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := [][]float32{make([]float32, 200), make([]float32, 200)}
+	p[0][0], p[1][1] = 1, 1
+	r.ProcessFloat32(p)
+	l := r.Latency()
+	for ch := range p {
+		for i, v := range p[ch] {
+			var expected float64
+			switch i {
+			case l + ch:
+				expected = 1
+			case l + ch + 10 + 24:
+				expected = 0.25
+			}
+			if math.Abs(float64(v)-expected) > 1e-4 {
+				t.Fatal("channel:", ch, "sample:", i, "expected:", expected, "got:", v)
+			}
+		}
+	}
+}
+
+func TestTrueStereo(t *testing.T) {
+	ir := make([][]float64, 4)
+	for i := range ir {
+		ir[i] = make([]float64, 4)
+		ir[i][i] = float64(i + 1)
+	}
+	r, err := New(2, 48000, ir, 48000, Options{Dry: math.Inf(-1), BlockSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := r.Latency()
+	p := [][]float64{make([]float64, 32), make([]float64, 32)}
+	p[0][0], p[1][16] = 1, 1
+	r.ProcessFloat64(p)
+	// L to L at 0, L to R at 1, R to L at 2, R to R at 3
+	for _, tc := range []struct{ ch, i int }{{0, l}, {1, l + 1}, {0, l + 16 + 2}, {1, l + 16 + 3}} {
+		if p[tc.ch][tc.i] == 0 {
+			t.Error("missing response at", tc.ch, tc.i)
+		}
+	}
+	if p[0][l]+p[1][l+1]+p[0][l+18]+p[1][l+19] != 1+2+3+4 {
+		t.Error("unexpected gain")
+	}
+
+	if _, err = New(3, 48000, ir, 48000, Options{}); err == nil {
+		t.Error("4 channels impulse response must be rejected for 3 channels")
+	}
+}
+
+func TestNewWave(t *testing.T) {
+	ir := []float64{0.5, 0.25, 0.125, 0.0625, 0.03125}
+	var sum float64
+	for _, v := range ir {
+		sum += v
+	}
+
+	var buf bytes.Buffer
+	w, err := wave.NewWriter(&buf, &wave.WaveFormatExtensible{
+		Format: wave.WaveFormatEx{
+			FormatTag:      wave.WAVE_FORMAT_IEEE_FLOAT,
+			Channels:       1,
+			SamplesPerSec:  24000,
+			AvgBytesPerSec: 24000 * 8,
+			BlockAlign:     8,
+			BitsPerSample:  64,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.WriteFloat64Interleaved([][]float64{ir}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewWave(1, 48000, &buf, Options{Dry: math.Inf(-1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the gain at DC must be kept after resampling
+	p := [][]float64{make([]float64, 4800)}
+	for i := range p[0] {
+		p[0][i] = 1
+	}
+	r.ProcessFloat64(p)
+	if v := p[0][len(p[0])-1]; math.Abs(v-sum) > 1e-2 {
+		t.Error("expected:", sum, "got:", v)
+	}
+}
+
+func BenchmarkReverb(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	ir := make([][]float64, 4)
+	for ch := range ir {
+		ir[ch] = make([]float64, 48000*3)
+		for i := range ir[ch] {
+			ir[ch][i] = rnd.NormFloat64() * math.Exp(-float64(i)/48000)
+		}
+	}
+	r, err := New(2, 48000, ir, 48000, Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := [][]float64{make([]float64, 512), make([]float64, 512)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ProcessFloat64(p)
+	}
+}
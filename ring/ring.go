@@ -0,0 +1,283 @@
+// Package ring implements a lock-free ring buffer of audio frames
+// between a single producer and a single consumer.
+//
+// The producer writes through audio.InterleavedWriter and the consumer reads
+// through audio.InterleavedReader, typically from different goroutines such as
+// a decoder and a sound card callback. Only the positions are shared by atomic
+// operations, so neither side ever waits for a lock held by the other.
+package ring
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+var (
+	// ErrOverrun is returned by a non-blocking write which dropped frames because the buffer was full.
+	ErrOverrun = errors.New("ring: buffer overrun")
+	// ErrClosed is returned by writes after Close.
+	ErrClosed = errors.New("ring: buffer closed")
+)
+
+// Mode selects what happens when the buffer cannot satisfy an operation.
+type Mode int
+
+const (
+	// NonBlocking reads return the frames which are available and writes drop the frames which do not fit.
+	NonBlocking Mode = iota
+	// Blocking reads wait for at least one frame and writes wait until all frames are written.
+	Blocking
+)
+
+// Buffer is a ring buffer of frames for a single producer and a single consumer.
+// Read methods must be called from one goroutine at a time, and so must write methods.
+type Buffer struct {
+	// 64-bit atomic fields come first for the alignment on 32-bit platforms
+	read      int64 // frames read so far
+	write     int64 // frames written so far
+	underruns int64
+	overruns  int64
+	closed    int32
+
+	mode     Mode
+	size     int64
+	data     [][]float64
+	readable chan struct{}
+	writable chan struct{}
+}
+
+// New returns a Buffer of channels which holds up to frames.
+func New(channels, frames int, mode Mode) *Buffer {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	if frames < 1 {
+		panic("ring: invalid size")
+	}
+	data := make([][]float64, channels)
+	for ch := range data {
+		data[ch] = make([]float64, frames)
+	}
+	return &Buffer{
+		mode:     mode,
+		size:     int64(frames),
+		data:     data,
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+// Channels returns the number of channels.
+func (b *Buffer) Channels() int {
+	return len(b.data)
+}
+
+// Cap returns the number of frames the buffer can hold.
+func (b *Buffer) Cap() int {
+	return int(b.size)
+}
+
+// Len returns the number of frames which can be read.
+func (b *Buffer) Len() int {
+	return int(atomic.LoadInt64(&b.write) - atomic.LoadInt64(&b.read))
+}
+
+// Free returns the number of frames which can be written.
+func (b *Buffer) Free() int {
+	return int(b.size) - b.Len()
+}
+
+// Underruns returns the number of non-blocking reads which got fewer frames than requested.
+// The final reads after Close are not counted.
+func (b *Buffer) Underruns() int64 {
+	return atomic.LoadInt64(&b.underruns)
+}
+
+// Overruns returns the number of writes which dropped frames.
+func (b *Buffer) Overruns() int64 {
+	return atomic.LoadInt64(&b.overruns)
+}
+
+// Close ends the stream. Reads return the remaining frames and then io.EOF,
+// and blocked operations on both sides return.
+func (b *Buffer) Close() error {
+	atomic.StoreInt32(&b.closed, 1)
+	signal(b.readable)
+	signal(b.writable)
+	return nil
+}
+
+func (b *Buffer) isClosed() bool {
+	return atomic.LoadInt32(&b.closed) != 0
+}
+
+// signal wakes up the other side, a pending signal is enough as it only means "check again".
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// available returns the number of frames up to n which can be read now,
+// it waits for them in the Blocking mode.
+func (b *Buffer) available(n int) (int, error) {
+	for {
+		avail := imin(n, b.Len())
+		if avail > 0 || n == 0 {
+			if avail < n && b.mode == NonBlocking && !b.isClosed() {
+				atomic.AddInt64(&b.underruns, 1)
+			}
+			return avail, nil
+		}
+		if b.isClosed() {
+			// the last frames may have been written just before Close
+			if b.Len() > 0 {
+				continue
+			}
+			return 0, io.EOF
+		}
+		if b.mode == NonBlocking {
+			atomic.AddInt64(&b.underruns, 1)
+			return 0, nil
+		}
+		<-b.readable
+	}
+}
+
+// space returns the number of frames up to n which can be written now,
+// it waits for some space in the Blocking mode.
+func (b *Buffer) space(n int) (int, error) {
+	for {
+		if b.isClosed() {
+			return 0, ErrClosed
+		}
+		free := imin(n, b.Free())
+		if free > 0 || b.mode == NonBlocking {
+			return free, nil
+		}
+		<-b.writable
+	}
+}
+
+// segments returns the ranges of the storage of n frames from the position pos.
+func (b *Buffer) segments(pos int64, n int) (i0, n0, n1 int) {
+	i0 = int(pos % b.size)
+	n0 = imin(n, int(b.size)-i0)
+	return i0, n0, n - n0
+}
+
+// ReadFloat64Interleaved reads frames to p which has a slice per channel.
+// In the NonBlocking mode it returns the frames which are available, which may be 0,
+// and a read which gets fewer frames than requested is counted as an underrun.
+func (b *Buffer) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	n, err = b.available(len(p[0]))
+	if n == 0 {
+		return 0, err
+	}
+	pos := atomic.LoadInt64(&b.read)
+	i0, n0, n1 := b.segments(pos, n)
+	for ch, c := range b.data {
+		copy(p[ch][:n0], c[i0:])
+		copy(p[ch][n0:n], c[:n1])
+	}
+	atomic.StoreInt64(&b.read, pos+int64(n))
+	signal(b.writable)
+	return n, nil
+}
+
+// ReadFloat32Interleaved reads frames to p which has a slice per channel.
+// It works as ReadFloat64Interleaved.
+func (b *Buffer) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	n, err = b.available(len(p[0]))
+	if n == 0 {
+		return 0, err
+	}
+	pos := atomic.LoadInt64(&b.read)
+	i0, n0, n1 := b.segments(pos, n)
+	for ch, c := range b.data {
+		d := p[ch]
+		for i, s := range c[i0 : i0+n0] {
+			d[i] = float32(s)
+		}
+		for i, s := range c[:n1] {
+			d[n0+i] = float32(s)
+		}
+	}
+	atomic.StoreInt64(&b.read, pos+int64(n))
+	signal(b.writable)
+	return n, nil
+}
+
+// WriteFloat64Interleaved writes frames of p which has a slice per channel.
+// In the NonBlocking mode the frames which do not fit are dropped, then it returns ErrOverrun
+// and counts an overrun. In the Blocking mode it waits until all frames are written.
+func (b *Buffer) WriteFloat64Interleaved(p [][]float64) (n int, err error) {
+	ln := len(p[0])
+	for n < ln {
+		var m int
+		if m, err = b.space(ln - n); err != nil {
+			return n, err
+		}
+		if m == 0 {
+			break
+		}
+		pos := atomic.LoadInt64(&b.write)
+		i0, n0, n1 := b.segments(pos, m)
+		for ch, c := range b.data {
+			s := p[ch][n : n+m]
+			copy(c[i0:i0+n0], s)
+			copy(c[:n1], s[n0:])
+		}
+		atomic.StoreInt64(&b.write, pos+int64(m))
+		signal(b.readable)
+		n += m
+	}
+	if n < ln {
+		atomic.AddInt64(&b.overruns, 1)
+		return n, ErrOverrun
+	}
+	return n, nil
+}
+
+// WriteFloat32Interleaved writes frames of p which has a slice per channel.
+// It works as WriteFloat64Interleaved.
+func (b *Buffer) WriteFloat32Interleaved(p [][]float32) (n int, err error) {
+	ln := len(p[0])
+	for n < ln {
+		var m int
+		if m, err = b.space(ln - n); err != nil {
+			return n, err
+		}
+		if m == 0 {
+			break
+		}
+		pos := atomic.LoadInt64(&b.write)
+		i0, n0, _ := b.segments(pos, m)
+		for ch, c := range b.data {
+			s := p[ch][n : n+m]
+			for i, v := range s[:n0] {
+				c[i0+i] = float64(v)
+			}
+			for i, v := range s[n0:] {
+				c[i] = float64(v)
+			}
+		}
+		atomic.StoreInt64(&b.write, pos+int64(m))
+		signal(b.readable)
+		n += m
+	}
+	if n < ln {
+		atomic.AddInt64(&b.overruns, 1)
+		return n, ErrOverrun
+	}
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package ring
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func frames(channels, n int, start float64) [][]float64 {
+	p := make([][]float64, channels)
+	for ch := range p {
+		p[ch] = make([]float64, n)
+		for i := range p[ch] {
+			p[ch][i] = start + float64(i) + float64(ch)*0.5
+		}
+	}
+	return p
+}
+
+func TestNonBlocking(t *testing.T) {
+	b := New(2, 8, NonBlocking)
+	p := [][]float64{make([]float64, 4), make([]float64, 4)}
+	if n, err := b.ReadFloat64Interleaved(p); n != 0 || err != nil || b.Underruns() != 1 {
+		t.Fatalf("empty read want 0 <nil> and an underrun got %d %v %d", n, err, b.Underruns())
+	}
+	if n, err := b.WriteFloat64Interleaved(frames(2, 6, 0)); n != 6 || err != nil {
+		t.Fatalf("want 6 <nil> got %d %v", n, err)
+	}
+	if n, err := b.ReadFloat64Interleaved(p); n != 4 || err != nil || p[0][3] != 3 || p[1][0] != 0.5 {
+		t.Fatalf("unexpected read %d %v %v", n, err, p)
+	}
+	// wraps around
+	if n, err := b.WriteFloat64Interleaved(frames(2, 8, 6)); n != 6 || err != ErrOverrun || b.Overruns() != 1 {
+		t.Fatalf("want 6 %v and an overrun got %d %v %d", ErrOverrun, n, err, b.Overruns())
+	}
+	if b.Len() != 8 || b.Free() != 0 {
+		t.Fatalf("unexpected length %d free %d", b.Len(), b.Free())
+	}
+	q := [][]float32{make([]float32, 10), make([]float32, 10)}
+	n, err := b.ReadFloat32Interleaved(q)
+	if n != 8 || err != nil || b.Underruns() != 2 {
+		t.Fatalf("want 8 <nil> and an underrun got %d %v %d", n, err, b.Underruns())
+	}
+	for i := 0; i < n; i++ {
+		if q[0][i] != float32(4+i) || q[1][i] != float32(4.5+float64(i)) {
+			t.Fatalf("unexpected frames %v", q)
+		}
+	}
+
+	if n, err := b.WriteFloat32Interleaved([][]float32{{1, 2}, {3, 4}}); n != 2 || err != nil {
+		t.Fatalf("want 2 <nil> got %d %v", n, err)
+	}
+	b.Close()
+	if n, err := b.WriteFloat64Interleaved(p); n != 0 || err != ErrClosed {
+		t.Fatalf("want 0 %v got %d %v", ErrClosed, n, err)
+	}
+	if n, err := b.ReadFloat64Interleaved(p); n != 2 || err != nil || p[0][1] != 2 || p[1][1] != 4 {
+		t.Fatalf("remaining frames must be read: %d %v %v", n, err, p)
+	}
+	if n, err := b.ReadFloat64Interleaved(p); n != 0 || err != io.EOF {
+		t.Fatalf("want 0 EOF got %d %v", n, err)
+	}
+	if b.Underruns() != 2 {
+		t.Errorf("reads after Close must not be underruns: %d", b.Underruns())
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	const total, block = 100000, 37
+	b := New(2, 64, Blocking)
+	go func() {
+		for pos := 0; pos < total; pos += block {
+			n := block
+			if pos+n > total {
+				n = total - pos
+			}
+			if _, err := b.WriteFloat64Interleaved(frames(2, n, float64(pos))); err != nil {
+				panic(err)
+			}
+		}
+		b.Close()
+	}()
+
+	p := [][]float32{make([]float32, 50), make([]float32, 50)}
+	pos := 0
+	for {
+		n, err := b.ReadFloat32Interleaved(p)
+		if err == io.EOF {
+			break
+		}
+		if err != nil || n == 0 {
+			t.Fatalf("unexpected read %d %v", n, err)
+		}
+		for i := 0; i < n; i++ {
+			if p[0][i] != float32(pos+i) || p[1][i] != float32(float64(pos+i)+0.5) {
+				t.Fatalf("frame %d unexpected %v %v", pos+i, p[0][i], p[1][i])
+			}
+		}
+		pos += n
+	}
+	if pos != total || b.Underruns() != 0 || b.Overruns() != 0 {
+		t.Errorf("want %d frames got %d, underruns %d overruns %d", total, pos, b.Underruns(), b.Overruns())
+	}
+}
+
+func TestClose(t *testing.T) {
+	w, r := New(1, 4, Blocking), New(1, 4, Blocking)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	var rn int
+	var werr, rerr error
+	go func() {
+		defer wg.Done()
+		// blocks because nobody reads
+		_, werr = w.WriteFloat64Interleaved(frames(1, 8, 0))
+	}()
+	go func() {
+		defer wg.Done()
+		// blocks because nobody writes
+		rn, rerr = r.ReadFloat64Interleaved(frames(1, 4, 0))
+	}()
+	for w.Free() != 0 {
+		runtime.Gosched()
+	}
+	w.Close()
+	r.Close()
+	wg.Wait()
+	if werr != ErrClosed || rn != 0 || rerr != io.EOF {
+		t.Errorf("blocked operations must return: %v %d %v", werr, rn, rerr)
+	}
+}
@@ -0,0 +1,95 @@
+package saturator
+
+import (
+	"math"
+)
+
+// Curve is a saturation transfer function.
+type Curve interface {
+	Saturate32(s float32) float32
+	Saturate64(s float64) float64
+}
+
+var (
+	// Hard clips the signal at ±1.
+	Hard Curve = hard{}
+	// Tanh is the hyperbolic tangent curve.
+	Tanh Curve = tanh{}
+	// Cubic is the cubic soft clipper 1.5x - 0.5x^3, which reaches ±1 at ±1.
+	Cubic Curve = cubic{}
+	// Arctan is the arctangent curve scaled to have unity gain at zero.
+	Arctan Curve = arctan{}
+)
+
+type hard struct{}
+
+func (hard) Saturate32(s float32) float32 { return Saturate32(s) }
+func (hard) Saturate64(s float64) float64 { return Saturate64(s) }
+
+type tanh struct{}
+
+func (tanh) Saturate32(s float32) float32 { return float32(math.Tanh(float64(s))) }
+func (tanh) Saturate64(s float64) float64 { return math.Tanh(s) }
+
+type cubic struct{}
+
+func (cubic) Saturate32(s float32) float32 { return float32(cubic{}.Saturate64(float64(s))) }
+func (cubic) Saturate64(s float64) float64 {
+	switch {
+	case s > 1:
+		return 1
+	case s < -1:
+		return -1
+	}
+	return 1.5*s - 0.5*s*s*s
+}
+
+type arctan struct{}
+
+func (arctan) Saturate32(s float32) float32 { return float32(arctan{}.Saturate64(float64(s))) }
+func (arctan) Saturate64(s float64) float64 {
+	return 2 / math.Pi * math.Atan(s*math.Pi/2)
+}
+
+// Polynomial is a soft-knee clipper.
+// The signal passes unchanged below Knee and is bent by a quadratic curve up to ±1.
+// Knee must be in the range [0, 1]; 1 is the same as hard clipping.
+type Polynomial struct {
+	Knee float64
+}
+
+func (p Polynomial) Saturate32(s float32) float32 {
+	return float32(p.Saturate64(float64(s)))
+}
+
+func (p Polynomial) Saturate64(s float64) float64 {
+	a, sign := s, 1.0
+	if a < 0 {
+		a, sign = -a, -1.0
+	}
+	if a <= p.Knee {
+		return s
+	}
+	w := 1 - p.Knee
+	if a >= p.Knee+2*w {
+		return sign
+	}
+	d := a - p.Knee
+	return sign * (a - d*d/(4*w))
+}
+
+// Curve32Slice applies c to input and stores the result to output.
+// input and output may be the same slice.
+func Curve32Slice(c Curve, input, output []float32) {
+	for i, s := range input {
+		output[i] = c.Saturate32(s)
+	}
+}
+
+// Curve64Slice applies c to input and stores the result to output.
+// input and output may be the same slice.
+func Curve64Slice(c Curve, input, output []float64) {
+	for i, s := range input {
+		output[i] = c.Saturate64(s)
+	}
+}
@@ -0,0 +1,78 @@
+package saturator
+
+import (
+	"github.com/oov/audio/resampler"
+)
+
+// Oversampler applies a Curve at a multiple of the sample rate to suppress aliasing.
+type Oversampler struct {
+	factor int
+	curve  Curve
+	up     *resampler.Resampler
+	down   *resampler.Resampler
+	buf    []float64
+}
+
+// NewOversampler returns an Oversampler.
+// factor must be 2, 4 or 8, quality is the resampling quality(0-10).
+func NewOversampler(channels int, factor int, curve Curve, quality int) *Oversampler {
+	switch factor {
+	case 2, 4, 8:
+	default:
+		panic("invalid oversampling factor")
+	}
+	return &Oversampler{
+		factor: factor,
+		curve:  curve,
+		up:     resampler.New(channels, 1, factor, quality),
+		down:   resampler.New(channels, factor, 1, quality),
+	}
+}
+
+// Latency returns the delay in samples introduced by the oversampling filters.
+func (o *Oversampler) Latency() int {
+	return (o.up.OutputLatency() + o.down.InputLatency() + o.factor/2) / o.factor
+}
+
+// Reset clears the filter memory of all channels.
+func (o *Oversampler) Reset() {
+	o.up.Reset()
+	o.down.Reset()
+}
+
+func (o *Oversampler) process(channelIndex int, input, output []float64) {
+	ln := len(input) * o.factor
+	if ln > len(o.buf) {
+		o.buf = make([]float64, ln)
+	}
+	_, written := o.up.ProcessFloat64(channelIndex, input, o.buf[:ln])
+	Curve64Slice(o.curve, o.buf[:written], o.buf[:written])
+	o.down.ProcessFloat64(channelIndex, o.buf[:written], output)
+}
+
+// ProcessFloat64 saturates input of the channel and stores the result to output.
+// input and output may be the same slice; the output is delayed by Latency samples.
+func (o *Oversampler) ProcessFloat64(channelIndex int, input, output []float64) {
+	o.process(channelIndex, input, output)
+}
+
+// ProcessFloat32 saturates input of the channel and stores the result to output.
+// input and output may be the same slice; the output is delayed by Latency samples.
+func (o *Oversampler) ProcessFloat32(channelIndex int, input, output []float32) {
+	const stackSize = 256
+	var stack [stackSize]float64
+	for len(input) > 0 {
+		n := len(input)
+		if n > stackSize {
+			n = stackSize
+		}
+		for i, s := range input[:n] {
+			stack[i] = float64(s)
+		}
+		o.process(channelIndex, stack[:n], stack[:n])
+		for i, s := range stack[:n] {
+			output[i] = float32(s)
+		}
+		input, output = input[n:], output[n:]
+	}
+}
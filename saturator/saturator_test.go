@@ -0,0 +1,74 @@
+package saturator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurves(t *testing.T) {
+	curves := map[string]Curve{
+		"hard":       Hard,
+		"tanh":       Tanh,
+		"cubic":      Cubic,
+		"arctan":     Arctan,
+		"polynomial": Polynomial{Knee: 0.5},
+	}
+	for name, c := range curves {
+		prev := math.Inf(-1)
+		for x := -4.0; x <= 4.0; x += 0.01 {
+			y := c.Saturate64(x)
+			if y < prev-1e-12 || math.Abs(y) > 1 {
+				t.Fatal(name, "is not monotonic or exceeds ±1 at", x, y)
+			}
+			if math.Abs(c.Saturate64(-x)+y) > 1e-12 {
+				t.Fatal(name, "is not symmetric at", x)
+			}
+			prev = y
+		}
+		if d := c.Saturate64(1e-4) / 1e-4; name != "cubic" && math.Abs(d-1) > 0.01 {
+			t.Error(name, "gain at zero:", d)
+		}
+	}
+
+	p := Polynomial{Knee: 0.5}
+	if p.Saturate64(0.5) != 0.5 || p.Saturate64(1.5) != 1 || p.Saturate64(-2) != -1 {
+		t.Error("polynomial knee")
+	}
+	if (Polynomial{Knee: 1}).Saturate64(1.5) != 1 {
+		t.Error("polynomial hard knee")
+	}
+}
+
+func TestOversampler(t *testing.T) {
+	for _, factor := range []int{2, 4, 8} {
+		o := NewOversampler(1, factor, Hard, 5)
+		p := make([]float64, 1000)
+		p[100] = 0.5
+		o.ProcessFloat64(0, p, p)
+
+		peak := 0
+		for i, s := range p {
+			if math.Abs(s) > math.Abs(p[peak]) {
+				peak = i
+			}
+		}
+		if peak-100 != o.Latency() {
+			t.Error("factor:", factor, "peak:", peak-100, "latency:", o.Latency())
+		}
+		if math.Abs(p[peak]-0.5) > 0.05 {
+			t.Error("factor:", factor, "peak level:", p[peak])
+		}
+	}
+
+	o := NewOversampler(1, 4, Tanh, 5)
+	p := make([]float32, 1000)
+	for i := range p {
+		p[i] = float32(4 * math.Sin(float64(i)*0.05))
+	}
+	o.ProcessFloat32(0, p, p)
+	for _, s := range p {
+		if math.Abs(float64(s)) > 1.1 {
+			t.Fatal("not saturated:", s)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package spectrogram
+
+import (
+	"image/color"
+)
+
+// Colormap is a gradient through evenly spaced colors.
+type Colormap []color.RGBA
+
+var (
+	Gray = Colormap{
+		{0, 0, 0, 255},
+		{255, 255, 255, 255},
+	}
+	Heat = Colormap{
+		{0, 0, 0, 255},
+		{128, 0, 0, 255},
+		{255, 64, 0, 255},
+		{255, 200, 0, 255},
+		{255, 255, 255, 255},
+	}
+	Viridis = Colormap{
+		{0x44, 0x01, 0x54, 255},
+		{0x3b, 0x52, 0x8b, 255},
+		{0x21, 0x91, 0x8c, 255},
+		{0x5e, 0xc9, 0x62, 255},
+		{0xfd, 0xe7, 0x25, 255},
+	}
+	Magma = Colormap{
+		{0x00, 0x00, 0x04, 255},
+		{0x51, 0x12, 0x7c, 255},
+		{0xb7, 0x37, 0x79, 255},
+		{0xfc, 0x89, 0x61, 255},
+		{0xfc, 0xfd, 0xbf, 255},
+	}
+)
+
+// At returns the color of v in the range [0, 1].
+func (c Colormap) At(v float64) color.RGBA {
+	switch {
+	case v <= 0 || v != v:
+		return c[0]
+	case v >= 1:
+		return c[len(c)-1]
+	}
+	pos := v * float64(len(c)-1)
+	i := int(pos)
+	f := pos - float64(i)
+	a, b := c[i], c[i+1]
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*f + 0.5)
+	}
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), lerp(a.A, b.A)}
+}
@@ -0,0 +1,268 @@
+// Package spectrogram renders spectrograms of audio streams to images.
+package spectrogram
+
+import (
+	"errors"
+	"image"
+	"io"
+	"math"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/fft"
+	"github.com/oov/audio/wave"
+	"github.com/oov/audio/window"
+)
+
+// Scale is the frequency axis of the image.
+type Scale int
+
+const (
+	Linear Scale = iota
+	Log
+	Mel
+)
+
+// Mode selects how channels are rendered.
+type Mode int
+
+const (
+	Mixed      Mode = iota // average power of all channels
+	PerChannel             // channels are stacked from top to bottom
+)
+
+// Options configures Render.
+// Zero values select the defaults noted on each field.
+type Options struct {
+	Size     int         // FFT size (default 2048)
+	Hop      int         // samples between columns, at most Size (default Size/4)
+	Window   window.Func // analysis window (default window.Hann)
+	Height   int         // rows per channel (default Size/2)
+	Scale    Scale
+	MinFreq  float64  // lowest frequency in Hz (default 0, or 20 for Log)
+	MaxFreq  float64  // highest frequency in Hz (default Nyquist frequency)
+	MinDB    float64  // level mapped to the first color (default -120)
+	MaxDB    float64  // level mapped to the last color (default 0)
+	Colormap Colormap // (default Heat)
+	Mode     Mode
+}
+
+func mel(f float64) float64 {
+	return 2595 * math.Log10(1+f/700)
+}
+
+func invMel(m float64) float64 {
+	return 700 * (math.Pow(10, m/2595) - 1)
+}
+
+// frequency returns the frequency at u in the range [0, 1] from the bottom of the axis.
+func (o *Options) frequency(u float64) float64 {
+	switch o.Scale {
+	case Log:
+		return o.MinFreq * math.Pow(o.MaxFreq/o.MinFreq, u)
+	case Mel:
+		lo, hi := mel(o.MinFreq), mel(o.MaxFreq)
+		return invMel(lo + (hi-lo)*u)
+	}
+	return o.MinFreq + (o.MaxFreq-o.MinFreq)*u
+}
+
+func (o *Options) setDefaults(sampleRate int) error {
+	if o.Size <= 0 {
+		o.Size = 2048
+	}
+	if o.Hop <= 0 {
+		o.Hop = o.Size / 4
+	}
+	if o.Window == nil {
+		o.Window = window.Hann
+	}
+	if o.Height <= 0 {
+		o.Height = o.Size / 2
+	}
+	if o.MaxFreq <= 0 || o.MaxFreq > float64(sampleRate)/2 {
+		o.MaxFreq = float64(sampleRate) / 2
+	}
+	if o.Scale == Log && o.MinFreq <= 0 {
+		o.MinFreq = 20
+	}
+	if o.MinDB == 0 && o.MaxDB == 0 {
+		o.MinDB = -120
+	}
+	if o.Colormap == nil {
+		o.Colormap = Heat
+	}
+	if o.Hop > o.Size || o.MinFreq < 0 || o.MinFreq >= o.MaxFreq || o.MinDB >= o.MaxDB || len(o.Colormap) < 2 {
+		return errors.New("spectrogram: invalid options")
+	}
+	return nil
+}
+
+// rowMap maps every row to the range of bins, row 0 is the highest frequency.
+type rowMap struct {
+	lo, hi []float64
+}
+
+func newRowMap(o *Options, sampleRate int) *rowMap {
+	binWidth := float64(sampleRate) / float64(o.Size)
+	m := &rowMap{lo: make([]float64, o.Height), hi: make([]float64, o.Height)}
+	for y := range m.lo {
+		u := float64(o.Height-1-y) / float64(o.Height)
+		m.lo[y] = o.frequency(u) / binWidth
+		m.hi[y] = o.frequency(u+1/float64(o.Height)) / binWidth
+	}
+	return m
+}
+
+// sample returns the power of the row from the power spectrum.
+func (m *rowMap) sample(y int, power []float64) float64 {
+	lo, hi := m.lo[y], m.hi[y]
+	last := len(power) - 1
+	if hi-lo < 1 {
+		// narrower than a bin: interpolate at the center
+		c := (lo + hi) / 2
+		i := int(c)
+		if i >= last {
+			return power[last]
+		}
+		f := c - float64(i)
+		return power[i]*(1-f) + power[i+1]*f
+	}
+	var r float64
+	for i := int(math.Ceil(lo)); i <= int(hi) && i <= last; i++ {
+		r = math.Max(r, power[i])
+	}
+	return r
+}
+
+// Render reads r until io.EOF and renders the spectrogram.
+// Every column of the image is a frame advanced by Options.Hop samples.
+func Render(r audio.InterleavedReader, channels, sampleRate int, opts Options) (image.Image, error) {
+	if err := opts.setDefaults(sampleRate); err != nil {
+		return nil, err
+	}
+
+	size, hop := opts.Size, opts.Hop
+	plan := fft.NewRealPlan(size)
+	win := window.New(opts.Window, size, true)
+	var winSum float64
+	for _, w := range win {
+		winSum += w
+	}
+	// a full scale sine results in 0dB
+	powerScale := 4 / (winSum * winSum)
+
+	frames := make([][]float64, channels)
+	for ch := range frames {
+		frames[ch] = make([]float64, size)
+	}
+	tmp := make([]float64, size)
+	spec := make([]complex128, size/2+1)
+	power := make([][]float64, channels)
+	for ch := range power {
+		power[ch] = make([]float64, len(spec))
+	}
+	mixed := make([]float64, len(spec))
+
+	rows := newRowMap(&opts, sampleRate)
+	images := 1
+	if opts.Mode == PerChannel {
+		images = channels
+	}
+	var columns [][]float32
+
+	analyze := func() {
+		for ch, frame := range frames {
+			for i, w := range win {
+				tmp[i] = frame[i] * w
+			}
+			plan.ForwardFloat64(spec, tmp)
+			for i, v := range spec {
+				power[ch][i] = (real(v)*real(v) + imag(v)*imag(v)) * powerScale
+			}
+		}
+		col := make([]float32, opts.Height*images)
+		if opts.Mode == PerChannel {
+			for ch := range power {
+				for y := 0; y < opts.Height; y++ {
+					col[ch*opts.Height+y] = float32(rows.sample(y, power[ch]))
+				}
+			}
+		} else {
+			for i := range mixed {
+				var sum float64
+				for ch := range power {
+					sum += power[ch][i]
+				}
+				mixed[i] = sum / float64(channels)
+			}
+			for y := range col {
+				col[y] = float32(rows.sample(y, mixed))
+			}
+		}
+		columns = append(columns, col)
+	}
+
+	buf := make([][]float64, channels)
+	for ch := range buf {
+		buf[ch] = make([]float64, hop)
+	}
+	// every frame starts hop samples after the previous one,
+	// the first frame starts at the first sample.
+	filled, pending := 0, false
+	for {
+		n, err := r.ReadFloat64Interleaved(buf)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		for i := 0; i < n; {
+			ln := n - i
+			if rest := size - filled; ln > rest {
+				ln = rest
+			}
+			for ch := range frames {
+				copy(frames[ch][filled:], buf[ch][i:i+ln])
+			}
+			filled += ln
+			i += ln
+			pending = true
+			if filled == size {
+				analyze()
+				for ch := range frames {
+					copy(frames[ch], frames[ch][hop:])
+				}
+				filled -= hop
+				pending = filled > size-hop
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if pending {
+		for ch := range frames {
+			for i := filled; i < size; i++ {
+				frames[ch][i] = 0
+			}
+		}
+		analyze()
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, len(columns), opts.Height*images))
+	dbRange := opts.MaxDB - opts.MinDB
+	for x, col := range columns {
+		for y, p := range col {
+			db := 10 * math.Log10(float64(p)+1e-30)
+			img.SetRGBA(x, y, opts.Colormap.At((db-opts.MinDB)/dbRange))
+		}
+	}
+	return img, nil
+}
+
+// RenderWave renders the spectrogram of the waveform audio data from r.
+func RenderWave(r io.Reader, opts Options) (image.Image, error) {
+	ar, wfext, err := wave.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return Render(ar, int(wfext.Format.Channels), int(wfext.Format.SamplesPerSec), opts)
+}
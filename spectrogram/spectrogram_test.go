@@ -0,0 +1,124 @@
+package spectrogram
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+type sineReader struct {
+	freq, rate float64
+	pos, len   int
+}
+
+func (r *sineReader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *sineReader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	for n < len(p[0]) && r.pos < r.len {
+		s := math.Sin(2 * math.Pi * r.freq * float64(r.pos) / r.rate)
+		for ch := range p {
+			if ch == 1 {
+				// the second channel is silent
+				s = 0
+			}
+			p[ch][n] = s
+		}
+		n++
+		r.pos++
+	}
+	if r.pos == r.len {
+		err = io.EOF
+	}
+	return
+}
+
+// brightestRow returns the brightest row in the column.
+func brightestRow(img image.Image, x, top, height int) int {
+	row, max := -1, uint32(0)
+	for y := top; y < top+height; y++ {
+		r, g, b, _ := img.At(x, y).RGBA()
+		if v := r + g + b; v > max {
+			row, max = y-top, v
+		}
+	}
+	return row
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		scale Scale
+		row   func(o *Options) int
+	}{
+		{Linear, func(o *Options) int { return o.Height - 1 - int(1000/(24000/float64(o.Height))) }},
+		{Log, func(o *Options) int {
+			return o.Height - 1 - int(math.Log(1000/20)/math.Log(24000/20)*float64(o.Height))
+		}},
+		{Mel, func(o *Options) int { return o.Height - 1 - int(mel(1000)/mel(24000)*float64(o.Height)) }},
+	}
+	for _, test := range tests {
+		opts := Options{Size: 1024, Height: 200, Scale: test.scale, Colormap: Gray}
+		img, err := Render(&sineReader{freq: 1000, rate: 48000, len: 48000}, 2, 48000, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != (48000-1024+255)/256+1 || b.Dy() != 200 {
+			t.Fatal("bounds:", b)
+		}
+		opts.setDefaults(48000)
+		if row, expected := brightestRow(img, 10, 0, 200), test.row(&opts); row < expected-1 || row > expected+1 {
+			t.Error("scale:", test.scale, "row:", row, "expected:", expected)
+		}
+		if err = png.Encode(ioutil.Discard, img); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPerChannel(t *testing.T) {
+	img, err := Render(&sineReader{freq: 6000, rate: 48000, len: 10000}, 2, 48000, Options{Size: 512, Mode: PerChannel})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dy() != 512 {
+		t.Fatal("bounds:", b)
+	}
+	if row := brightestRow(img, 5, 0, 256); row != 255-64 && row != 256-64 {
+		t.Error("row:", row)
+	}
+	if r, _, _, _ := img.At(5, 256+128).RGBA(); r != 0 {
+		t.Error("silent channel must be black:", r)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	for i, opts := range []Options{
+		{Size: 256, Hop: 512},
+		{MinFreq: 30000},
+		{MinDB: 0, MaxDB: -10},
+	} {
+		if _, err := Render(&sineReader{freq: 1000, rate: 48000, len: 4096}, 1, 48000, opts); err == nil {
+			t.Errorf("options[%d] must be rejected: %+v", i, opts)
+		}
+	}
+}
+
+func TestRenderWave(t *testing.T) {
+	f, err := os.Open("../wave/48kHz2ch16bit.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := RenderWave(f, Options{Size: 8, Mode: PerChannel, Colormap: Viridis})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dy() != 8 || b.Dx() == 0 {
+		t.Error("bounds:", b)
+	}
+}
@@ -0,0 +1,165 @@
+// Package stft implements the short-time Fourier transform and its inverse.
+//
+// The inverse uses weighted overlap-add; every output sample is normalized by the
+// sum of the squared windows that overlap it, so the input is reconstructed
+// perfectly for any window that does not vanish, including all COLA windows.
+package stft
+
+import (
+	"io"
+
+	"github.com/oov/audio"
+	"github.com/oov/audio/fft"
+	"github.com/oov/audio/window"
+)
+
+func newWindow(size, hop int, win window.Func) []float64 {
+	if size < 1 || hop < 1 || hop > size {
+		panic("stft: invalid size or hop")
+	}
+	if win == nil {
+		win = window.Hann
+	}
+	return window.New(win, size, true)
+}
+
+// Analyzer reads samples from an audio.Reader and produces spectra of overlapping frames.
+//
+// The stream is padded with size-hop zeros at the beginning, and with zeros at the end
+// until the last input sample is covered by hop samples of a frame.
+type Analyzer struct {
+	r      audio.Reader
+	plan   *fft.RealPlan
+	window []float64
+	hop    int
+	buf    []float64
+	tmp    []float64
+
+	frames  int64
+	samples int64
+	eof     bool
+}
+
+// NewAnalyzer returns an Analyzer which uses frames of size samples advanced by hop samples.
+// win is applied as a periodic window, Hann is used if win is nil.
+func NewAnalyzer(r audio.Reader, size, hop int, win window.Func) *Analyzer {
+	return &Analyzer{
+		r:      r,
+		plan:   fft.NewRealPlan(size),
+		window: newWindow(size, hop, win),
+		hop:    hop,
+		buf:    make([]float64, size),
+		tmp:    make([]float64, size),
+	}
+}
+
+// Bins returns the number of bins in a spectrum.
+func (a *Analyzer) Bins() int {
+	return len(a.buf)/2 + 1
+}
+
+// Samples returns the number of samples read from the reader.
+func (a *Analyzer) Samples() int64 {
+	return a.samples
+}
+
+// Next stores the spectrum of the next frame to spec, which must have Bins elements.
+// It returns io.EOF when all input samples have been covered.
+func (a *Analyzer) Next(spec []complex128) error {
+	size, hop := len(a.buf), a.hop
+	if a.eof && a.frames*int64(hop) >= int64(size-hop)+a.samples {
+		return io.EOF
+	}
+
+	copy(a.buf, a.buf[hop:])
+	in := a.buf[size-hop:]
+	for len(in) > 0 && !a.eof {
+		n, err := a.r.ReadFloat64(in)
+		a.samples += int64(n)
+		in = in[n:]
+		if err == io.EOF {
+			a.eof = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i := range in {
+		in[i] = 0
+	}
+
+	for i, w := range a.window {
+		a.tmp[i] = a.buf[i] * w
+	}
+	a.plan.ForwardFloat64(spec, a.tmp)
+	a.frames++
+	return nil
+}
+
+// Synthesizer reconstructs samples from spectra by weighted overlap-add and writes them to an audio.Writer.
+//
+// It removes the padding that Analyzer inserts at the beginning, so the output starts
+// with the first input sample. At most hop-1 padding samples follow the last input sample.
+type Synthesizer struct {
+	w      audio.Writer
+	plan   *fft.RealPlan
+	window []float64
+	hop    int
+	acc    []float64
+	norm   []float64
+	tmp    []float64
+	skip   int
+}
+
+// NewSynthesizer returns a Synthesizer with the same parameters as NewAnalyzer.
+func NewSynthesizer(w audio.Writer, size, hop int, win window.Func) *Synthesizer {
+	return &Synthesizer{
+		w:      w,
+		plan:   fft.NewRealPlan(size),
+		window: newWindow(size, hop, win),
+		hop:    hop,
+		acc:    make([]float64, size),
+		norm:   make([]float64, size),
+		tmp:    make([]float64, size),
+		skip:   size - hop,
+	}
+}
+
+// WriteFrame adds the frame of spec and writes hop completed samples.
+func (s *Synthesizer) WriteFrame(spec []complex128) error {
+	s.plan.InverseFloat64(s.tmp, spec)
+	for i, w := range s.window {
+		s.acc[i] += s.tmp[i] * w
+		s.norm[i] += w * w
+	}
+
+	hop := s.hop
+	out := s.tmp[:hop]
+	for i := range out {
+		if s.norm[i] > 1e-10 {
+			out[i] = s.acc[i] / s.norm[i]
+		} else {
+			out[i] = 0
+		}
+	}
+	copy(s.acc, s.acc[hop:])
+	copy(s.norm, s.norm[hop:])
+	for i := len(s.acc) - hop; i < len(s.acc); i++ {
+		s.acc[i], s.norm[i] = 0, 0
+	}
+
+	if s.skip > 0 {
+		n := s.skip
+		if n > len(out) {
+			n = len(out)
+		}
+		s.skip -= n
+		out = out[n:]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := s.w.WriteFloat64(out)
+	return err
+}
@@ -0,0 +1,110 @@
+package stft
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/oov/audio/window"
+)
+
+type sliceReader struct {
+	p []float64
+}
+
+func (r *sliceReader) ReadFloat32(p []float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (r *sliceReader) ReadFloat64(p []float64) (n int, err error) {
+	if len(r.p) == 0 {
+		return 0, io.EOF
+	}
+	// short reads exercise the buffering of Analyzer
+	if len(p) > 100 {
+		p = p[:100]
+	}
+	n = copy(p, r.p)
+	r.p = r.p[n:]
+	return n, nil
+}
+
+type sliceWriter struct {
+	p []float64
+}
+
+func (w *sliceWriter) WriteFloat32(p []float32) (n int, err error) {
+	panic("not implemented")
+}
+
+func (w *sliceWriter) WriteFloat64(p []float64) (n int, err error) {
+	w.p = append(w.p, p...)
+	return len(p), nil
+}
+
+func TestReconstruction(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	in := make([]float64, 10000)
+	for i := range in {
+		in[i] = rnd.Float64()*2 - 1
+	}
+
+	tests := []struct {
+		name      string
+		win       window.Func
+		size, hop int
+	}{
+		{"hann", window.Hann, 512, 128},
+		{"hamming", window.Hamming, 512, 256},
+		{"blackman", window.Blackman, 1024, 256},
+		{"kaiser", window.Kaiser(8), 500, 100},
+		{"rectangular", window.Rectangular, 256, 256},
+	}
+	for _, test := range tests {
+		a := NewAnalyzer(&sliceReader{in}, test.size, test.hop, test.win)
+		w := &sliceWriter{}
+		s := NewSynthesizer(w, test.size, test.hop, test.win)
+		spec := make([]complex128, a.Bins())
+		for {
+			err := a.Next(spec)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = s.WriteFrame(spec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if a.Samples() != int64(len(in)) || len(w.p) < len(in) || len(w.p) >= len(in)+test.hop {
+			t.Fatal(test.name, "samples:", a.Samples(), "written:", len(w.p))
+		}
+		for i, v := range in {
+			if math.Abs(w.p[i]-v) > 1e-9 {
+				t.Fatal(test.name, "mismatch at", i, w.p[i], v)
+			}
+		}
+	}
+}
+
+func TestSpectrum(t *testing.T) {
+	in := make([]float64, 4096)
+	for i := range in {
+		in[i] = math.Sin(2 * math.Pi * 64 * float64(i) / 1024)
+	}
+	a := NewAnalyzer(&sliceReader{in}, 1024, 1024, window.Hann)
+	spec := make([]complex128, a.Bins())
+	a.Next(spec)
+	a.Next(spec)
+	peak := 0
+	for i, v := range spec {
+		if math.Hypot(real(v), imag(v)) > math.Hypot(real(spec[peak]), imag(spec[peak])) {
+			peak = i
+		}
+	}
+	if peak != 64 {
+		t.Error("peak bin:", peak)
+	}
+}
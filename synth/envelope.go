@@ -0,0 +1,178 @@
+package synth
+
+import (
+	"math"
+)
+
+// Segment is a part of an Envelope which moves from the previous level to Level.
+type Segment struct {
+	Level    float64 // level at the end of the segment
+	Duration float64 // in seconds
+	Curve    float64 // 0 is linear, positive values change fast first and negative values change slowly first
+}
+
+// shape maps the position x from 0 to 1 to the progress from 0 to 1.
+func shape(x, curve float64) float64 {
+	if curve == 0 {
+		return x
+	}
+	return (1 - math.Exp(-curve*x)) / (1 - math.Exp(-curve))
+}
+
+type gate struct {
+	frame int64
+	on    bool
+}
+
+// Envelope is a multi-segment envelope generator.
+// The gate can be switched at exact frames, frames are counted from the first rendered sample.
+type Envelope struct {
+	segments []Segment
+	sustain  int
+	rate     float64
+
+	stage  int // -1 while idle
+	held   bool
+	pos    int64
+	length int64
+	start  float64
+	level  float64
+	frame  int64
+	gates  []gate
+}
+
+// NewEnvelope returns an Envelope of segments which stays at the end of segments[sustain]
+// until the note is released, and then continues from segments[sustain+1].
+// A negative sustain makes a one-shot envelope which ignores the release.
+func NewEnvelope(sampleRate int, segments []Segment, sustain int) *Envelope {
+	if len(segments) == 0 {
+		panic("synth: no segments")
+	}
+	if sustain >= len(segments) {
+		panic("synth: sustain out of range")
+	}
+	return &Envelope{
+		segments: segments,
+		sustain:  sustain,
+		rate:     float64(sampleRate),
+		stage:    -1,
+	}
+}
+
+// NewADSR returns an Envelope of attack, decay and release in seconds and sustain level.
+// The attack is linear, the decay and release have the curve of an analog envelope.
+func NewADSR(sampleRate int, attack, decay, sustain, release float64) *Envelope {
+	return NewEnvelope(sampleRate, []Segment{
+		{Level: 1, Duration: attack},
+		{Level: sustain, Duration: decay, Curve: 5},
+		{Level: 0, Duration: release, Curve: 5},
+	}, 1)
+}
+
+// Level returns the level of the last rendered frame.
+func (e *Envelope) Level() float64 {
+	return e.level
+}
+
+// Active reports whether the envelope is running, it becomes false after the last segment.
+func (e *Envelope) Active() bool {
+	return e.stage >= 0
+}
+
+// Position returns the frame which is rendered next.
+func (e *Envelope) Position() int64 {
+	return e.frame
+}
+
+// NoteOn starts the envelope from the current level at the next frame.
+func (e *Envelope) NoteOn() {
+	e.NoteOnAt(e.frame)
+}
+
+// NoteOnAt starts the envelope from the current level at frame.
+func (e *Envelope) NoteOnAt(frame int64) {
+	e.insert(gate{frame: frame, on: true})
+}
+
+// NoteOff releases the note at the next frame.
+func (e *Envelope) NoteOff() {
+	e.NoteOffAt(e.frame)
+}
+
+// NoteOffAt releases the note at frame.
+func (e *Envelope) NoteOffAt(frame int64) {
+	e.insert(gate{frame: frame})
+}
+
+func (e *Envelope) insert(g gate) {
+	if g.frame < e.frame {
+		g.frame = e.frame
+	}
+	i := len(e.gates)
+	for i > 0 && e.gates[i-1].frame > g.frame {
+		i--
+	}
+	e.gates = append(e.gates, gate{})
+	copy(e.gates[i+1:], e.gates[i:])
+	e.gates[i] = g
+}
+
+// Reset stops the envelope at the level 0 and cancels all scheduled notes.
+func (e *Envelope) Reset() {
+	e.stage, e.held, e.level = -1, false, 0
+	e.gates = e.gates[:0]
+}
+
+func (e *Envelope) enter(stage int) {
+	if stage >= len(e.segments) {
+		e.stage = -1
+		return
+	}
+	e.stage, e.pos, e.start = stage, 0, e.level
+	e.length = int64(math.Floor(e.segments[stage].Duration*e.rate + 0.5))
+}
+
+func (e *Envelope) next() float64 {
+	for len(e.gates) > 0 && e.gates[0].frame <= e.frame {
+		if e.gates[0].on {
+			e.held = true
+			e.enter(0)
+		} else if e.held {
+			e.held = false
+			if e.sustain >= 0 && e.stage >= 0 && e.stage <= e.sustain {
+				e.enter(e.sustain + 1)
+			}
+		}
+		e.gates = e.gates[1:]
+	}
+	e.frame++
+
+	for e.stage >= 0 && e.pos >= e.length {
+		e.level = e.segments[e.stage].Level
+		if e.stage == e.sustain && e.held {
+			return e.level
+		}
+		e.enter(e.stage + 1)
+	}
+	if e.stage < 0 {
+		return e.level
+	}
+	seg := &e.segments[e.stage]
+	e.level = e.start + (seg.Level-e.start)*shape(float64(e.pos)/float64(e.length), seg.Curve)
+	e.pos++
+	return e.level
+}
+
+// ProcessFloat64 renders the following len(p) samples.
+func (e *Envelope) ProcessFloat64(p []float64) {
+	for i := range p {
+		p[i] = e.next()
+	}
+}
+
+// ProcessFloat32 renders the following len(p) samples.
+func (e *Envelope) ProcessFloat32(p []float32) {
+	for i := range p {
+		p[i] = float32(e.next())
+	}
+}
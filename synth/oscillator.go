@@ -0,0 +1,188 @@
+package synth
+
+import (
+	"math"
+)
+
+// Waveform selects the shape of an oscillator.
+type Waveform int
+
+const (
+	Sine Waveform = iota
+	Saw
+	Square
+	Triangle
+)
+
+// polyBLEP returns the residual of the band-limited step at phase t for the phase increment dt.
+func polyBLEP(t, dt float64) float64 {
+	switch {
+	case t < dt:
+		t /= dt
+		return t + t - t*t - 1
+	case t > 1-dt:
+		t = (t - 1) / dt
+		return t*t + t + t + 1
+	}
+	return 0
+}
+
+// polyBLAMP returns the residual of the band-limited ramp at phase t for the phase increment dt.
+func polyBLAMP(t, dt float64) float64 {
+	switch {
+	case t < dt:
+		t = t/dt - 1
+		return -t * t * t / 3
+	case t > 1-dt:
+		t = (t-1)/dt + 1
+		return t * t * t / 3
+	}
+	return 0
+}
+
+func wrap(t float64) float64 {
+	return t - math.Floor(t)
+}
+
+// Oscillator is an oscillator whose discontinuities are band-limited by PolyBLEP and PolyBLAMP.
+type Oscillator struct {
+	Frequency  Param // frequency in Hz
+	Amplitude  Param // linear gain (default 1)
+	PulseWidth Param // duty cycle of Square from 0 to 1 (default 0.5)
+
+	waveform Waveform
+	rate     float64
+	phase    float64 // from 0 to 1
+}
+
+// NewOscillator returns an Oscillator of waveform at freq Hz.
+func NewOscillator(sampleRate int, waveform Waveform, freq float64) *Oscillator {
+	o := &Oscillator{
+		waveform: waveform,
+		rate:     float64(sampleRate),
+	}
+	o.Frequency.Set(freq)
+	o.Amplitude.Set(1)
+	o.PulseWidth.Set(0.5)
+	return o
+}
+
+// Phase returns the current phase from 0 to 1.
+func (o *Oscillator) Phase() float64 {
+	return o.phase
+}
+
+// SetPhase changes the current phase, e.g. 0 for a hard sync.
+func (o *Oscillator) SetPhase(phase float64) {
+	o.phase = wrap(phase)
+}
+
+func (o *Oscillator) next() float64 {
+	dt := math.Min(math.Abs(o.Frequency.next())/o.rate, 0.5)
+	amp := o.Amplitude.next()
+	pw := o.PulseWidth.next()
+	t := o.phase
+
+	var s float64
+	switch o.waveform {
+	case Sine:
+		s = math.Sin(2 * math.Pi * t)
+	case Saw:
+		s = 2*t - 1 - polyBLEP(t, dt)
+	case Square:
+		pw = math.Max(dt, math.Min(1-dt, pw))
+		if t < pw {
+			s = 1
+		} else {
+			s = -1
+		}
+		s += polyBLEP(t, dt) - polyBLEP(wrap(t-pw), dt)
+	case Triangle:
+		// the slope changes by 8 per cycle at the top (t = 0) and the bottom (t = 0.5),
+		// polyBLAMP is the residual of the slope change of 2 per sample
+		s = 2*math.Abs(2*t-1) - 1
+		s += 4 * dt * (polyBLAMP(wrap(t+0.5), dt) - polyBLAMP(t, dt))
+	}
+
+	o.phase = wrap(t + dt)
+	return amp * s
+}
+
+// ProcessFloat64 renders the following len(p) samples.
+func (o *Oscillator) ProcessFloat64(p []float64) {
+	for i := range p {
+		p[i] = o.next()
+	}
+}
+
+// ProcessFloat32 renders the following len(p) samples.
+func (o *Oscillator) ProcessFloat32(p []float32) {
+	for i := range p {
+		p[i] = float32(o.next())
+	}
+}
+
+// LFO is a low frequency oscillator for modulation, which is not band-limited.
+type LFO struct {
+	Rate  Param // frequency in Hz
+	Depth Param // amplitude (default 1)
+
+	waveform Waveform
+	rate     float64
+	phase    float64
+}
+
+// NewLFO returns an LFO of waveform at rate Hz.
+func NewLFO(sampleRate int, waveform Waveform, rate float64) *LFO {
+	l := &LFO{
+		waveform: waveform,
+		rate:     float64(sampleRate),
+	}
+	l.Rate.Set(rate)
+	l.Depth.Set(1)
+	return l
+}
+
+// SetPhase changes the current phase from 0 to 1, e.g. 0 to restart it with a note.
+func (l *LFO) SetPhase(phase float64) {
+	l.phase = wrap(phase)
+}
+
+func (l *LFO) next() float64 {
+	dt := l.Rate.next() / l.rate
+	depth := l.Depth.next()
+	t := l.phase
+
+	var s float64
+	switch l.waveform {
+	case Sine:
+		s = math.Sin(2 * math.Pi * t)
+	case Saw:
+		s = 2*t - 1
+	case Square:
+		s = 1
+		if t >= 0.5 {
+			s = -1
+		}
+	case Triangle:
+		// starts at 0 and rises like Sine
+		s = 1 - math.Abs(4*wrap(t+0.75)-2)
+	}
+
+	l.phase = wrap(t + dt)
+	return depth * s
+}
+
+// ProcessFloat64 renders the following len(p) samples.
+func (l *LFO) ProcessFloat64(p []float64) {
+	for i := range p {
+		p[i] = l.next()
+	}
+}
+
+// ProcessFloat32 renders the following len(p) samples.
+func (l *LFO) ProcessFloat32(p []float32) {
+	for i := range p {
+		p[i] = float32(l.next())
+	}
+}
@@ -0,0 +1,231 @@
+// Package synth implements building blocks of sound synthesis:
+// band-limited oscillators, wavetable oscillators, envelopes and LFOs.
+//
+// Every block is a Source which renders mono blocks of samples.
+// Parameters are Params which can be changed at exact frames,
+// and NewReader turns a Source into an audio.Reader.
+package synth
+
+import (
+	"io"
+)
+
+// Source renders a signal, p is overwritten with the following len(p) samples.
+type Source interface {
+	ProcessFloat64(p []float64)
+	ProcessFloat32(p []float32)
+}
+
+type event struct {
+	frame int64
+	value float64
+	ramp  bool
+}
+
+// Param is a parameter which is automated with sample accuracy.
+// Frames are counted from the first sample rendered by the owner of the Param.
+type Param struct {
+	value   float64
+	pos     int64
+	from    int64 // start of the current ramp
+	fromVal float64
+	events  []event
+}
+
+// Value returns the value of the last rendered frame, or the value set by Set.
+func (p *Param) Value() float64 {
+	return p.value
+}
+
+// Position returns the frame which is rendered next.
+func (p *Param) Position() int64 {
+	return p.pos
+}
+
+// Set changes the value immediately and cancels all scheduled changes.
+func (p *Param) Set(v float64) {
+	p.value = v
+	p.events = p.events[:0]
+}
+
+// SetAt changes the value at frame.
+// A frame which has already been rendered means the next frame.
+func (p *Param) SetAt(frame int64, v float64) {
+	p.insert(event{frame: frame, value: v})
+}
+
+// RampTo changes the value linearly so that it becomes v at frame.
+// The ramp starts from the previous scheduled change, or from the next frame if there is none.
+func (p *Param) RampTo(frame int64, v float64) {
+	p.insert(event{frame: frame, value: v, ramp: true})
+}
+
+func (p *Param) insert(e event) {
+	if e.frame < p.pos {
+		e.frame = p.pos
+	}
+	i := len(p.events)
+	for i > 0 && p.events[i-1].frame > e.frame {
+		i--
+	}
+	if i == 0 {
+		p.from, p.fromVal = p.pos, p.value
+	}
+	p.events = append(p.events, event{})
+	copy(p.events[i+1:], p.events[i:])
+	p.events[i] = e
+}
+
+// next returns the value of the next frame.
+func (p *Param) next() float64 {
+	for len(p.events) > 0 && p.events[0].frame <= p.pos {
+		e := p.events[0]
+		p.value = e.value
+		p.from, p.fromVal = e.frame, e.value
+		p.events = p.events[1:]
+	}
+	if len(p.events) > 0 && p.events[0].ramp {
+		e := &p.events[0]
+		p.value = p.fromVal + (e.value-p.fromVal)*float64(p.pos-p.from)/float64(e.frame-p.from)
+	}
+	p.pos++
+	return p.value
+}
+
+type mul struct {
+	a, b  Source
+	buf64 []float64
+	buf32 []float32
+}
+
+// Mul returns a Source of the product of a and b, such as an oscillator shaped by an envelope.
+func Mul(a, b Source) Source {
+	return &mul{a: a, b: b}
+}
+
+func (m *mul) ProcessFloat64(p []float64) {
+	if cap(m.buf64) < len(p) {
+		m.buf64 = make([]float64, len(p))
+	}
+	buf := m.buf64[:len(p)]
+	m.a.ProcessFloat64(p)
+	m.b.ProcessFloat64(buf)
+	for i, s := range buf {
+		p[i] *= s
+	}
+}
+
+func (m *mul) ProcessFloat32(p []float32) {
+	if cap(m.buf32) < len(p) {
+		m.buf32 = make([]float32, len(p))
+	}
+	buf := m.buf32[:len(p)]
+	m.a.ProcessFloat32(p)
+	m.b.ProcessFloat32(buf)
+	for i, s := range buf {
+		p[i] *= s
+	}
+}
+
+// Reader is an audio.Reader and audio.InterleavedReader which renders a Source
+// to all channels.
+type Reader struct {
+	src      Source
+	channels int
+	remain   int64
+	buf64    []float64
+	buf32    []float32
+}
+
+// NewReader returns a Reader which renders frames of src, a negative frames makes it endless.
+func NewReader(src Source, channels int, frames int64) *Reader {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	return &Reader{
+		src:      src,
+		channels: channels,
+		remain:   frames,
+	}
+}
+
+// frames returns the number of frames which can be rendered up to n.
+func (r *Reader) frames(n int) int {
+	if r.remain >= 0 && int64(n) > r.remain {
+		n = int(r.remain)
+	}
+	if r.remain > 0 {
+		r.remain -= int64(n)
+	}
+	return n
+}
+
+func (r *Reader) render64(n int) []float64 {
+	if cap(r.buf64) < n {
+		r.buf64 = make([]float64, n)
+	}
+	buf := r.buf64[:n]
+	r.src.ProcessFloat64(buf)
+	return buf
+}
+
+func (r *Reader) render32(n int) []float32 {
+	if cap(r.buf32) < n {
+		r.buf32 = make([]float32, n)
+	}
+	buf := r.buf32[:n]
+	r.src.ProcessFloat32(buf)
+	return buf
+}
+
+// ReadFloat32 reads interleaved samples, so len(p) should be a multiple of the number of channels.
+func (r *Reader) ReadFloat32(p []float32) (n int, err error) {
+	if r.remain == 0 {
+		return 0, io.EOF
+	}
+	buf := r.render32(r.frames(len(p) / r.channels))
+	for i, s := range buf {
+		for ch := 0; ch < r.channels; ch++ {
+			p[i*r.channels+ch] = s
+		}
+	}
+	return len(buf) * r.channels, nil
+}
+
+// ReadFloat64 reads interleaved samples, so len(p) should be a multiple of the number of channels.
+func (r *Reader) ReadFloat64(p []float64) (n int, err error) {
+	if r.remain == 0 {
+		return 0, io.EOF
+	}
+	buf := r.render64(r.frames(len(p) / r.channels))
+	for i, s := range buf {
+		for ch := 0; ch < r.channels; ch++ {
+			p[i*r.channels+ch] = s
+		}
+	}
+	return len(buf) * r.channels, nil
+}
+
+func (r *Reader) ReadFloat32Interleaved(p [][]float32) (n int, err error) {
+	if r.remain == 0 {
+		return 0, io.EOF
+	}
+	n = r.frames(len(p[0]))
+	r.src.ProcessFloat32(p[0][:n])
+	for _, c := range p[1:] {
+		copy(c, p[0][:n])
+	}
+	return n, nil
+}
+
+func (r *Reader) ReadFloat64Interleaved(p [][]float64) (n int, err error) {
+	if r.remain == 0 {
+		return 0, io.EOF
+	}
+	n = r.frames(len(p[0]))
+	r.src.ProcessFloat64(p[0][:n])
+	for _, c := range p[1:] {
+		copy(c, p[0][:n])
+	}
+	return n, nil
+}
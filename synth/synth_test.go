@@ -0,0 +1,272 @@
+package synth
+
+import (
+	"io"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/oov/audio/fft"
+)
+
+func render(src Source, n int) []float64 {
+	p := make([]float64, n)
+	src.ProcessFloat64(p)
+	return p
+}
+
+func TestParam(t *testing.T) {
+	var p Param
+	p.Set(1)
+	p.SetAt(2, 5)
+	p.RampTo(6, 1)
+	p.SetAt(8, 0)
+	want := []float64{1, 1, 5, 4, 3, 2, 1, 1, 0, 0}
+	for i, w := range want {
+		if v := p.next(); v != w {
+			t.Errorf("frame %d want %v got %v", i, w, v)
+		}
+	}
+
+	// a ramp without a previous change starts from the next frame
+	p.RampTo(14, 4)
+	for i, w := range []float64{0, 1, 2, 3, 4, 4} {
+		if v := p.next(); math.Abs(v-w) > 1e-12 {
+			t.Errorf("ramp frame %d want %v got %v", i, w, v)
+		}
+	}
+	if p.Position() != 16 {
+		t.Errorf("position want 16 got %d", p.Position())
+	}
+
+	// a change in the past is applied to the next frame
+	p.SetAt(3, 7)
+	if v := p.next(); v != 7 {
+		t.Errorf("want 7 got %v", v)
+	}
+	p.SetAt(100, 1)
+	p.Set(2)
+	if v := p.next(); v != 2 || len(p.events) != 0 {
+		t.Errorf("Set must cancel scheduled changes: %v %v", v, p.events)
+	}
+}
+
+// spectrum returns the magnitudes of p whose length is the sample rate, so the bin k is k Hz.
+func spectrum(p []float64) []float64 {
+	spec := make([]complex128, len(p)/2+1)
+	fft.NewRealPlan(len(p)).ForwardFloat64(spec, p)
+	m := make([]float64, len(spec))
+	for i, c := range spec {
+		m[i] = cmplx.Abs(c)
+	}
+	return m
+}
+
+// aliasing returns the energy of bins which are not harmonics of freq relative to the total energy in dB.
+func aliasing(p []float64, freq int) float64 {
+	var alias, total float64
+	for k, m := range spectrum(p) {
+		total += m * m
+		if k%freq != 0 {
+			alias += m * m
+		}
+	}
+	return 10 * math.Log10(alias/total)
+}
+
+func TestOscillator(t *testing.T) {
+	const rate, freq = 48000, 1250
+	naive := map[Waveform]func(t float64) float64{
+		Saw: func(t float64) float64 { return 2*t - 1 },
+		Square: func(t float64) float64 {
+			if t < 0.5 {
+				return 1
+			}
+			return -1
+		},
+		Triangle: func(t float64) float64 { return 2*math.Abs(2*t-1) - 1 },
+	}
+	for _, w := range []Waveform{Sine, Saw, Square, Triangle} {
+		o := NewOscillator(rate, w, freq)
+		p := render(o, rate)
+		spec := spectrum(p)
+		peak := 0
+		for k := range spec {
+			if spec[k] > spec[peak] {
+				peak = k
+			}
+		}
+		if peak != freq {
+			t.Errorf("waveform %d fundamental want %d Hz got %d Hz", w, freq, peak)
+		}
+		for _, s := range p {
+			if math.Abs(s) > 1.1 {
+				t.Errorf("waveform %d out of range: %v", w, s)
+				break
+			}
+		}
+		a := aliasing(p, freq)
+		if w == Sine {
+			if a > -200 {
+				t.Errorf("sine must not alias: %v dB", a)
+			}
+			continue
+		}
+		n := make([]float64, rate)
+		for i := range n {
+			n[i] = naive[w](math.Mod(float64(i)*freq/rate, 1))
+		}
+		if na := aliasing(n, freq); a > na-10 {
+			t.Errorf("waveform %d aliasing %.1f dB is not lower than naive %.1f dB", w, a, na)
+		}
+	}
+
+	o := NewOscillator(rate, Saw, 100)
+	o.Amplitude.Set(0.5)
+	o.Frequency.SetAt(10, 200)
+	p := render(o, 20)
+	if math.Abs(p[2]-p[1]-0.5*2*100/rate) > 1e-12 || math.Abs(p[11]-p[10]-0.5*2*200/rate) > 1e-12 {
+		t.Errorf("unexpected automation: %v", p)
+	}
+}
+
+func TestWavetable(t *testing.T) {
+	const rate = 48000
+	amps := make([]float64, 1023)
+	for k := range amps {
+		amps[k] = 1 / float64(k+1)
+	}
+	table := NewWavetableFromHarmonics(2048, amps)
+	if table.Len() != 2048 || len(table.levels) != 10 || table.harmonics[0] != 1023 || table.harmonics[9] != 1 {
+		t.Fatalf("unexpected levels: %v", table.harmonics)
+	}
+	for _, freq := range []int{50, 1250, 7000, 20000} {
+		o := NewWavetableOscillator(rate, table, float64(freq))
+		spec := spectrum(render(o, rate))
+		var h float64
+		for k := freq; k < len(spec); k += freq {
+			h += spec[k] * spec[k]
+		}
+		if h == 0 || (freq*2 < len(spec) && spec[freq] < spec[freq*2]) {
+			t.Errorf("%d Hz unexpected harmonics", freq)
+		}
+		if a := aliasing(render(NewWavetableOscillator(rate, table, float64(freq)), rate), freq); a > -40 {
+			t.Errorf("%d Hz aliasing %.1f dB", freq, a)
+		}
+	}
+
+	// the fundamental only
+	o := NewWavetableOscillator(rate, table, 20000)
+	p := render(o, 4)
+	for i, s := range p {
+		if w := math.Sin(2 * math.Pi * 20000 * float64(i) / rate); math.Abs(s-w) > 0.01 {
+			t.Errorf("frame %d want %v got %v", i, w, s)
+		}
+	}
+}
+
+func TestADSR(t *testing.T) {
+	const rate = 1000
+	e := NewADSR(rate, 0.01, 0.02, 0.5, 0.05)
+	if e.Active() {
+		t.Error("envelope must be idle before a note")
+	}
+	e.NoteOnAt(5)
+	e.NoteOffAt(100)
+	p := render(e, 200)
+	for i := 0; i < 5; i++ {
+		if p[i] != 0 {
+			t.Fatalf("frame %d must be silent: %v", i, p[i])
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if w := float64(i) / 10; math.Abs(p[5+i]-w) > 1e-12 {
+			t.Errorf("attack frame %d want %v got %v", i, w, p[5+i])
+		}
+	}
+	if p[15] != 1 || p[16] >= 1 || p[35] != 0.5 || p[99] != 0.5 {
+		t.Errorf("unexpected decay: %v", p[15:36])
+	}
+	if p[100] != 0.5 || p[101] >= 0.5 || p[150] != 0 || e.Active() {
+		t.Errorf("unexpected release: %v", p[100:151])
+	}
+	for i := 1; i < 50; i++ {
+		if p[100+i] > p[99+i] {
+			t.Fatalf("release must decrease: %v", p[100:151])
+		}
+	}
+
+	// a release during the attack continues from the current level, and so does a retrigger
+	e.Reset()
+	e.NoteOn()
+	e.NoteOffAt(e.Position() + 5)
+	e.NoteOnAt(e.Position() + 10)
+	p = render(e, 15)
+	if p[5] != p[4] || p[6] >= p[5] || p[10] != p[9] || p[11] <= p[10] {
+		t.Errorf("unexpected retrigger: %v", p)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	e := NewEnvelope(100, []Segment{
+		{Level: 1, Duration: 0},
+		{Level: 0.5, Duration: 0.04, Curve: -3},
+		{Level: 0.5, Duration: 0.02},
+		{Level: 0, Duration: 0.01},
+	}, -1)
+	e.NoteOn()
+	e.NoteOff()
+	p := render(e, 10)
+	if p[0] != 1 {
+		t.Errorf("frame 0 want 1 got %v", p[0])
+	}
+	// negative curves change slowly first
+	if !(p[1] > 0.875 && p[1] > p[2] && p[2] > p[3] && p[3] > 0.5) {
+		t.Errorf("unexpected curve: %v", p[:4])
+	}
+	for i, w := range []float64{0.5, 0.5, 0.5, 0, 0, 0} {
+		if p[4+i] != w {
+			t.Errorf("frame %d want %v got %v", 4+i, w, p[4+i])
+		}
+	}
+	if e.Active() {
+		t.Error("one-shot envelope must finish")
+	}
+}
+
+func TestLFO(t *testing.T) {
+	for _, w := range []Waveform{Sine, Saw, Square, Triangle} {
+		l := NewLFO(1000, w, 10)
+		l.Depth.Set(0.5)
+		p := render(l, 1000)
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, s := range p {
+			min, max = math.Min(min, s), math.Max(max, s)
+		}
+		if min < -0.5-1e-12 || max > 0.5+1e-12 || max-min < 0.99 {
+			t.Errorf("waveform %d unexpected range [%v, %v]", w, min, max)
+		}
+		if w != Square && w != Saw && p[0] != 0 {
+			t.Errorf("waveform %d must start at 0: %v", w, p[0])
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	env := NewADSR(1000, 0, 0, 1, 0)
+	env.NoteOn()
+	r := NewReader(Mul(NewOscillator(1000, Square, 100), env), 2, 5)
+	p := make([]float32, 8)
+	n, err := r.ReadFloat32(p)
+	if n != 8 || err != nil || p[2] != p[3] || p[2] == 0 {
+		t.Fatalf("unexpected read: %d %v %v", n, err, p)
+	}
+	q := [][]float64{make([]float64, 4), make([]float64, 4)}
+	n, err = r.ReadFloat64Interleaved(q)
+	if n != 1 || err != nil || q[0][0] != q[1][0] {
+		t.Fatalf("unexpected read: %d %v %v", n, err, q)
+	}
+	if n, err = r.ReadFloat64Interleaved(q); n != 0 || err != io.EOF {
+		t.Fatalf("want EOF got %d %v", n, err)
+	}
+}
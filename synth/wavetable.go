@@ -0,0 +1,130 @@
+package synth
+
+import (
+	"math"
+
+	"github.com/oov/audio/fft"
+)
+
+// Wavetable is a single cycle waveform with band-limited copies for higher frequencies.
+// Level k keeps the harmonics up to ((size-1)/2)>>k, the last level is the fundamental only.
+type Wavetable struct {
+	size      int
+	harmonics []int
+	levels    [][]float64 // size+1 samples, the last one is a copy of the first one
+}
+
+// NewWavetable returns a Wavetable of a cycle, len(cycle) must be at least 4.
+func NewWavetable(cycle []float64) *Wavetable {
+	size := len(cycle)
+	if size < 4 {
+		panic("synth: wavetable too short")
+	}
+	plan := fft.NewRealPlan(size)
+	spec := make([]complex128, size/2+1)
+	plan.ForwardFloat64(spec, cycle)
+	// remove the DC offset, the Nyquist bin of an even size is never kept either
+	spec[0] = 0
+
+	w := &Wavetable{size: size}
+	tmp := make([]complex128, len(spec))
+	for h := (size - 1) / 2; ; h >>= 1 {
+		copy(tmp, spec)
+		for k := h + 1; k < len(tmp); k++ {
+			tmp[k] = 0
+		}
+		l := make([]float64, size+1)
+		plan.InverseFloat64(l[:size], tmp)
+		l[size] = l[0]
+		w.harmonics = append(w.harmonics, h)
+		w.levels = append(w.levels, l)
+		if h <= 1 {
+			break
+		}
+	}
+	return w
+}
+
+// NewWavetableFromHarmonics returns a Wavetable of size samples which is
+// the sum of sine waves, amplitudes[k] is the amplitude of the harmonic k+1.
+func NewWavetableFromHarmonics(size int, amplitudes []float64) *Wavetable {
+	cycle := make([]float64, size)
+	for k, a := range amplitudes {
+		if k+1 > (size-1)/2 {
+			break
+		}
+		w := 2 * math.Pi * float64(k+1) / float64(size)
+		for i := range cycle {
+			cycle[i] += a * math.Sin(w*float64(i))
+		}
+	}
+	return NewWavetable(cycle)
+}
+
+// Len returns the number of samples of a cycle.
+func (w *Wavetable) Len() int {
+	return w.size
+}
+
+// level returns the table which has no harmonics above the Nyquist frequency for
+// the phase increment dt, or the fundamental only if there is none.
+func (w *Wavetable) level(dt float64) []float64 {
+	for k, h := range w.harmonics {
+		if float64(h)*dt <= 0.5 {
+			return w.levels[k]
+		}
+	}
+	return w.levels[len(w.levels)-1]
+}
+
+// WavetableOscillator plays a Wavetable, the level is chosen per sample from the frequency.
+type WavetableOscillator struct {
+	Frequency Param // frequency in Hz
+	Amplitude Param // linear gain (default 1)
+
+	table *Wavetable
+	rate  float64
+	phase float64 // from 0 to 1
+}
+
+// NewWavetableOscillator returns a WavetableOscillator of table at freq Hz.
+func NewWavetableOscillator(sampleRate int, table *Wavetable, freq float64) *WavetableOscillator {
+	o := &WavetableOscillator{
+		table: table,
+		rate:  float64(sampleRate),
+	}
+	o.Frequency.Set(freq)
+	o.Amplitude.Set(1)
+	return o
+}
+
+// SetPhase changes the current phase from 0 to 1.
+func (o *WavetableOscillator) SetPhase(phase float64) {
+	o.phase = wrap(phase)
+}
+
+func (o *WavetableOscillator) next() float64 {
+	dt := math.Abs(o.Frequency.next()) / o.rate
+	amp := o.Amplitude.next()
+	l := o.table.level(dt)
+	pos := o.phase * float64(o.table.size)
+	i := int(pos)
+	frac := pos - float64(i)
+	s := l[i] + (l[i+1]-l[i])*frac
+	o.phase = wrap(o.phase + dt)
+	return amp * s
+}
+
+// ProcessFloat64 renders the following len(p) samples.
+func (o *WavetableOscillator) ProcessFloat64(p []float64) {
+	for i := range p {
+		p[i] = o.next()
+	}
+}
+
+// ProcessFloat32 renders the following len(p) samples.
+func (o *WavetableOscillator) ProcessFloat32(p []float32) {
+	for i := range p {
+		p[i] = float32(o.next())
+	}
+}
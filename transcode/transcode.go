@@ -0,0 +1,380 @@
+// Package transcode implements conversions between sample formats which work directly on the encoded bytes.
+//
+// Conversions between integer formats never go through floating point, so widening is
+// bit-exact and narrowing rounds to the nearest value, optionally with dither.
+// Integer samples are scaled by 2^(bits-1) to and from floating point, so converting
+// an integer format to a lossless floating point format and back restores the bits.
+package transcode
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/oov/audio/converter"
+	"github.com/oov/audio/dither"
+)
+
+// Encoding is the encoding of a sample.
+type Encoding int
+
+const (
+	Uint8   Encoding = iota // 8-bit unsigned integer
+	Int16                   // 16-bit signed integer
+	Int24                   // 24-bit signed integer
+	Int32                   // 32-bit signed integer
+	Float32                 // 32-bit floating point
+	Float64                 // 64-bit floating point
+)
+
+// Format is a sample format.
+type Format struct {
+	Encoding  Encoding
+	BigEndian bool
+}
+
+// SampleSize returns the size of a sample in bytes.
+func (f Format) SampleSize() int {
+	switch f.Encoding {
+	case Uint8:
+		return 1
+	case Int16:
+		return 2
+	case Int24:
+		return 3
+	case Int32, Float32:
+		return 4
+	case Float64:
+		return 8
+	}
+	panic("transcode: unknown encoding")
+}
+
+// Bits returns the number of bits of a sample.
+func (f Format) Bits() int {
+	return f.SampleSize() * 8
+}
+
+// IsFloat reports whether the samples are floating point.
+func (f Format) IsFloat() bool {
+	return f.Encoding == Float32 || f.Encoding == Float64
+}
+
+// precision returns the number of significant bits of a sample.
+func (f Format) precision() int {
+	switch f.Encoding {
+	case Float32:
+		return 24
+	case Float64:
+		return 53
+	}
+	return f.Bits()
+}
+
+// FormatOf returns the little-endian Format of the samples of c,
+// which is a Converter or an InterleavedConverter of the converter package.
+func FormatOf(c interface {
+	SampleSize() int
+}) (Format, error) {
+	switch c.(type) {
+	case converter.Uint8Converter:
+		return Format{Encoding: Uint8}, nil
+	case converter.Int16Converter:
+		return Format{Encoding: Int16}, nil
+	case converter.Int24Converter:
+		return Format{Encoding: Int24}, nil
+	case converter.Int32Converter:
+		return Format{Encoding: Int32}, nil
+	case converter.Float32Converter:
+		return Format{Encoding: Float32}, nil
+	case converter.Float64Converter:
+		return Format{Encoding: Float64}, nil
+	}
+	return Format{}, errors.New("transcode: unsupported converter")
+}
+
+// Options configures the transcoding.
+type Options struct {
+	// Dither adds noise before the precision is reduced to an integer format,
+	// it has no effect on lossless conversions and floating point outputs.
+	Dither bool
+	Mode   dither.Mode // probability density function of the noise
+	Seed   int64       // random seed of the noise, the same seed produces the same noise
+}
+
+// blockSize is the number of samples which are decoded at a time.
+const blockSize = 1024
+
+// Transcoder converts samples from a format to another.
+// A Transcoder with dither must not be used from multiple goroutines at the same time.
+type Transcoder struct {
+	from, to Format
+	mode     dither.Mode
+	rnd      *rand.Rand
+	ints     []int32
+	floats   []float64
+}
+
+// New returns a Transcoder which converts samples in the format from to the format to.
+func New(from, to Format, opts Options) *Transcoder {
+	t := &Transcoder{
+		from: from,
+		to:   to,
+		mode: opts.Mode,
+	}
+	// validate the encodings
+	from.SampleSize()
+	to.SampleSize()
+	if opts.Dither && !to.IsFloat() && !t.Lossless() {
+		t.rnd = rand.New(rand.NewSource(opts.Seed))
+	}
+	if from.IsFloat() || to.IsFloat() {
+		t.floats = make([]float64, blockSize)
+	}
+	if !from.IsFloat() || !to.IsFloat() {
+		t.ints = make([]int32, blockSize)
+	}
+	return t
+}
+
+// From returns the input format.
+func (t *Transcoder) From() Format {
+	return t.from
+}
+
+// To returns the output format.
+func (t *Transcoder) To() Format {
+	return t.to
+}
+
+// Lossless reports whether every sample of the input format is represented exactly by the output format.
+func (t *Transcoder) Lossless() bool {
+	if t.from.IsFloat() && !t.to.IsFloat() {
+		return false
+	}
+	return t.from.precision() <= t.to.precision()
+}
+
+// Transcode converts the samples of src to dst, and returns the number of samples converted,
+// which is limited by the samples of src and the space of dst.
+// Samples beyond the range of an integer output are clamped. dst and src must not overlap.
+func (t *Transcoder) Transcode(dst, src []byte) int {
+	n := len(src) / t.from.SampleSize()
+	if m := len(dst) / t.to.SampleSize(); m < n {
+		n = m
+	}
+	if t.from == t.to {
+		copy(dst, src[:n*t.from.SampleSize()])
+		return n
+	}
+	if t.from.Encoding == t.to.Encoding {
+		swap(dst, src[:n*t.from.SampleSize()], t.from.SampleSize())
+		return n
+	}
+
+	fromSize, toSize := t.from.SampleSize(), t.to.SampleSize()
+	for pos := 0; pos < n; pos += blockSize {
+		m := n - pos
+		if m > blockSize {
+			m = blockSize
+		}
+		s, d := src[pos*fromSize:(pos+m)*fromSize], dst[pos*toSize:(pos+m)*toSize]
+		switch {
+		case !t.from.IsFloat() && !t.to.IsFloat():
+			p := t.ints[:m]
+			decodeInt(p, s, t.from)
+			if t.to.Bits() < t.from.Bits() {
+				t.narrow(p, t.to.Bits())
+			}
+			encodeInt(d, p, t.to)
+		case !t.from.IsFloat():
+			p, f := t.ints[:m], t.floats[:m]
+			decodeInt(p, s, t.from)
+			for i, v := range p {
+				f[i] = float64(v) * (1.0 / (1 << 31))
+			}
+			encodeFloat(d, f, t.to)
+		case !t.to.IsFloat():
+			p, f := t.ints[:m], t.floats[:m]
+			decodeFloat(f, s, t.from)
+			t.quantize(p, f, t.to.Bits())
+			encodeInt(d, p, t.to)
+		default:
+			f := t.floats[:m]
+			decodeFloat(f, s, t.from)
+			encodeFloat(d, f, t.to)
+		}
+	}
+	return n
+}
+
+// noise returns the noise of the dither in units of 2^shift.
+func (t *Transcoder) noise(shift uint) int64 {
+	if t.mode == dither.RPDF {
+		return t.rnd.Int63n(1<<shift) - 1<<(shift-1)
+	}
+	return t.rnd.Int63n(1<<shift) - t.rnd.Int63n(1<<shift)
+}
+
+// narrow rounds the left-justified samples of p to bits.
+func (t *Transcoder) narrow(p []int32, bits int) {
+	shift := uint(32 - bits)
+	half := int64(1) << (shift - 1)
+	for i, v := range p {
+		x := int64(v) + half
+		if t.rnd != nil {
+			x += t.noise(shift)
+		}
+		x >>= shift
+		if x > 1<<uint(bits-1)-1 {
+			x = 1<<uint(bits-1) - 1
+		} else if x < -1<<uint(bits-1) {
+			x = -1 << uint(bits-1)
+		}
+		p[i] = int32(x << shift)
+	}
+}
+
+// quantize converts the floating point samples of f to left-justified integers of bits.
+func (t *Transcoder) quantize(p []int32, f []float64, bits int) {
+	scale := float64(uint64(1) << uint(bits-1))
+	max, min := scale-1, -scale
+	shift := uint(32 - bits)
+	for i, v := range f {
+		x := v * scale
+		if t.rnd != nil {
+			x += float64(t.noise(16)) * (1.0 / (1 << 16))
+		}
+		x = math.Floor(x + 0.5)
+		if x > max {
+			x = max
+		} else if x < min {
+			x = min
+		} else if x != x {
+			x = 0
+		}
+		p[i] = int32(int64(x) << shift)
+	}
+}
+
+// swap reverses the bytes of each sample of size.
+func swap(dst, src []byte, size int) {
+	for i := 0; i < len(src); i += size {
+		s, d := src[i:i+size], dst[i:i+size]
+		for j := range s {
+			d[size-1-j] = s[j]
+		}
+	}
+}
+
+// decodeInt decodes the integer samples of b to left-justified 32-bit integers.
+func decodeInt(p []int32, b []byte, f Format) {
+	switch f.Encoding {
+	case Uint8:
+		for i := range p {
+			p[i] = (int32(b[i]) - 128) << 24
+		}
+	case Int16:
+		for i := range p {
+			s := b[i*2 : i*2+2]
+			if f.BigEndian {
+				p[i] = int32(int16(binary.BigEndian.Uint16(s))) << 16
+			} else {
+				p[i] = int32(int16(binary.LittleEndian.Uint16(s))) << 16
+			}
+		}
+	case Int24:
+		for i := range p {
+			s := b[i*3 : i*3+3]
+			if f.BigEndian {
+				p[i] = int32(s[2])<<8 | int32(s[1])<<16 | int32(s[0])<<24
+			} else {
+				p[i] = converter.ByteToInt24(s[0], s[1], s[2])
+			}
+		}
+	case Int32:
+		for i := range p {
+			s := b[i*4 : i*4+4]
+			if f.BigEndian {
+				p[i] = int32(binary.BigEndian.Uint32(s))
+			} else {
+				p[i] = int32(binary.LittleEndian.Uint32(s))
+			}
+		}
+	}
+}
+
+// encodeInt encodes the left-justified 32-bit integers of p to the integer samples of b.
+func encodeInt(b []byte, p []int32, f Format) {
+	switch f.Encoding {
+	case Uint8:
+		for i, v := range p {
+			b[i] = byte(v>>24) ^ 0x80
+		}
+	case Int16:
+		for i, v := range p {
+			s := b[i*2 : i*2+2]
+			if f.BigEndian {
+				binary.BigEndian.PutUint16(s, uint16(v>>16))
+			} else {
+				binary.LittleEndian.PutUint16(s, uint16(v>>16))
+			}
+		}
+	case Int24:
+		for i, v := range p {
+			s := b[i*3 : i*3+3]
+			if f.BigEndian {
+				s[0], s[1], s[2] = byte(v>>24), byte(v>>16), byte(v>>8)
+			} else {
+				s[0], s[1], s[2] = byte(v>>8), byte(v>>16), byte(v>>24)
+			}
+		}
+	case Int32:
+		for i, v := range p {
+			s := b[i*4 : i*4+4]
+			if f.BigEndian {
+				binary.BigEndian.PutUint32(s, uint32(v))
+			} else {
+				binary.LittleEndian.PutUint32(s, uint32(v))
+			}
+		}
+	}
+}
+
+// decodeFloat decodes the floating point samples of b.
+func decodeFloat(p []float64, b []byte, f Format) {
+	order := byteOrder(f)
+	switch f.Encoding {
+	case Float32:
+		for i := range p {
+			p[i] = float64(math.Float32frombits(order.Uint32(b[i*4:])))
+		}
+	case Float64:
+		for i := range p {
+			p[i] = math.Float64frombits(order.Uint64(b[i*8:]))
+		}
+	}
+}
+
+// encodeFloat encodes p to the floating point samples of b.
+func encodeFloat(b []byte, p []float64, f Format) {
+	order := byteOrder(f)
+	switch f.Encoding {
+	case Float32:
+		for i, v := range p {
+			order.PutUint32(b[i*4:], math.Float32bits(float32(v)))
+		}
+	case Float64:
+		for i, v := range p {
+			order.PutUint64(b[i*8:], math.Float64bits(v))
+		}
+	}
+}
+
+func byteOrder(f Format) binary.ByteOrder {
+	if f.BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
@@ -0,0 +1,175 @@
+package transcode
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/oov/audio/converter"
+	"github.com/oov/audio/dither"
+)
+
+var encodings = []Encoding{Uint8, Int16, Int24, Int32, Float32, Float64}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(b)
+	return b
+}
+
+// randomSamples returns n samples of f, floating point samples are in [-1, 1).
+func randomSamples(f Format, n int) []byte {
+	if !f.IsFloat() {
+		return randomBytes(n * f.SampleSize())
+	}
+	rnd := rand.New(rand.NewSource(2))
+	p := make([]float64, n)
+	for i := range p {
+		p[i] = rnd.Float64()*2 - 1
+	}
+	b := make([]byte, n*f.SampleSize())
+	encodeFloat(b, p, f)
+	return b
+}
+
+func TestFormatOf(t *testing.T) {
+	for i, c := range []converter.Converter{converter.Uint8, converter.Int16, converter.Int24, converter.Int32, converter.Float32, converter.Float64} {
+		f, err := FormatOf(c)
+		if err != nil || f.Encoding != encodings[i] || f.BigEndian || f.SampleSize() != c.SampleSize() {
+			t.Errorf("converters[%d] unexpected format %+v %v", i, f, err)
+		}
+	}
+}
+
+// TestRoundTrip converts to every format and back, lossless conversions must restore the bits.
+func TestRoundTrip(t *testing.T) {
+	const n = 3000
+	for _, fe := range encodings {
+		for _, te := range encodings {
+			for _, be := range []bool{false, true} {
+				from, to := Format{Encoding: fe}, Format{Encoding: te, BigEndian: be}
+				src := randomSamples(from, n)
+				mid, got := make([]byte, n*to.SampleSize()), make([]byte, len(src))
+				forward := New(from, to, Options{})
+				if c := forward.Transcode(mid, src); c != n {
+					t.Fatalf("%+v to %+v want %d samples got %d", from, to, n, c)
+				}
+				New(to, from, Options{}).Transcode(got, mid)
+				if forward.Lossless() && string(got) != string(src) {
+					t.Errorf("%+v to %+v must be lossless", from, to)
+				}
+			}
+		}
+	}
+}
+
+func TestEndianness(t *testing.T) {
+	src := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+	dst := make([]byte, 6)
+	New(Format{Encoding: Int24}, Format{Encoding: Int24, BigEndian: true}, Options{}).Transcode(dst, src)
+	if string(dst) != "\x03\x02\x01\x06\x05\x04" {
+		t.Errorf("unexpected bytes %x", dst)
+	}
+	New(Format{Encoding: Int24}, Format{Encoding: Int32, BigEndian: true}, Options{}).Transcode(dst[:4], src[:3])
+	if string(dst[:4]) != "\x03\x02\x01\x00" {
+		t.Errorf("unexpected bytes %x", dst[:4])
+	}
+}
+
+func TestNarrow(t *testing.T) {
+	tests := []struct {
+		from Format
+		src  []byte
+		to   Format
+		want []byte
+	}{
+		// 0x1234_7f and 0x1234_80 round to the nearest
+		{Format{Encoding: Int24}, []byte{0x7f, 0x34, 0x12, 0x80, 0x34, 0x12}, Format{Encoding: Int16}, []byte{0x34, 0x12, 0x35, 0x12}},
+		// clamped instead of wrapping around
+		{Format{Encoding: Int16}, []byte{0xff, 0x7f, 0x00, 0x80}, Format{Encoding: Uint8}, []byte{0xff, 0x00}},
+		{Format{Encoding: Int16}, []byte{0x00, 0xc0}, Format{Encoding: Uint8}, []byte{0x40}},
+	}
+	for i, tt := range tests {
+		got := make([]byte, len(tt.want))
+		New(tt.from, tt.to, Options{}).Transcode(got, tt.src)
+		if string(got) != string(tt.want) {
+			t.Errorf("tests[%d] want %x got %x", i, tt.want, got)
+		}
+	}
+
+	f := make([]byte, 24)
+	encodeFloat(f, []float64{1, -1, 2, math.NaN(), 0.5, -0.25}, Format{Encoding: Float32})
+	got := make([]byte, 12)
+	New(Format{Encoding: Float32}, Format{Encoding: Int16}, Options{}).Transcode(got, f)
+	if want := "\xff\x7f\x00\x80\xff\x7f\x00\x00\x00\x40\x00\xe0"; string(got) != want {
+		t.Errorf("want %x got %x", want, got)
+	}
+}
+
+func TestDither(t *testing.T) {
+	const n = 100000
+	from, to := Format{Encoding: Int32}, Format{Encoding: Int16}
+	// a constant signal of a quarter of the output LSB
+	src := make([]byte, n*4)
+	p := make([]int32, n)
+	for i := range p {
+		p[i] = 1 << 14
+	}
+	encodeInt(src, p, from)
+
+	for _, mode := range []dither.Mode{dither.TPDF, dither.RPDF} {
+		dst := make([]byte, n*2)
+		tc := New(from, to, Options{Dither: true, Mode: mode, Seed: 1})
+		tc.Transcode(dst, src)
+		decodeInt(p, dst, to)
+		var sum float64
+		for _, v := range p {
+			x := v >> 16
+			if x < -1 || x > 2 {
+				t.Fatalf("mode %d noise too large: %d", mode, x)
+			}
+			sum += float64(x)
+		}
+		// the dither preserves the average below the LSB
+		if mean := sum / n; math.Abs(mean-0.25) > 0.01 {
+			t.Errorf("mode %d want mean 0.25 got %v", mode, mean)
+		}
+
+		again := make([]byte, n*2)
+		New(from, to, Options{Dither: true, Mode: mode, Seed: 1}).Transcode(again, src)
+		if string(again) != string(dst) {
+			t.Errorf("mode %d the same seed must produce the same output", mode)
+		}
+	}
+
+	// lossless conversions are never dithered
+	if New(Format{Encoding: Int16}, Format{Encoding: Int24}, Options{Dither: true}).rnd != nil {
+		t.Error("widening must not be dithered")
+	}
+}
+
+func BenchmarkTranscode(b *testing.B) {
+	for _, tt := range []struct {
+		name     string
+		from, to Encoding
+	}{
+		{"Int16ToInt24", Int16, Int24},
+		{"Int24ToInt32", Int24, Int32},
+		{"Int24ToInt16", Int24, Int16},
+		{"Int16ToFloat32", Int16, Float32},
+		{"Float32ToInt16", Float32, Int16},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			const n = 4096
+			from, to := Format{Encoding: tt.from}, Format{Encoding: tt.to}
+			src, dst := randomSamples(from, n), make([]byte, n*to.SampleSize())
+			tc := New(from, to, Options{})
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tc.Transcode(dst, src)
+			}
+		})
+	}
+}
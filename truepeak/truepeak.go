@@ -0,0 +1,141 @@
+// Package truepeak implements a true-peak detector as described in ITU-R BS.1770.
+//
+// The detector upsamples the signal by 4 with a polyphase FIR filter
+// and reports the largest magnitude of the interpolated samples.
+package truepeak
+
+import (
+	"math"
+)
+
+const (
+	// Oversample is the oversampling factor of the detector.
+	Oversample = 4
+	phaseTaps  = 12
+	filterLen  = Oversample * phaseTaps
+	kaiserBeta = 5.0
+)
+
+// coefs[p][j] is the j-th tap of phase p.
+// Phase 0 reproduces the input sample delayed by Latency.
+var coefs [Oversample][phaseTaps]float64
+
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+func init() {
+	const center = filterLen / 2
+	for n := 0; n < filterLen; n++ {
+		x := float64(n-center) / Oversample
+		s := 1.0
+		if x != 0 {
+			s = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		r := float64(n-center) / center
+		w := besselI0(kaiserBeta*math.Sqrt(1-r*r)) / besselI0(kaiserBeta)
+		coefs[n%Oversample][n/Oversample] = s * w
+	}
+	for p := range coefs {
+		var sum float64
+		for _, c := range coefs[p] {
+			sum += c
+		}
+		for j := range coefs[p] {
+			coefs[p][j] /= sum
+		}
+	}
+}
+
+// Detector is a multi-channel true-peak detector.
+type Detector struct {
+	history [][phaseTaps]float64
+	pos     []int
+}
+
+// New returns a Detector for the given number of channels.
+func New(channels int) *Detector {
+	if channels < 1 {
+		panic("you must have at least one channel")
+	}
+	return &Detector{
+		history: make([][phaseTaps]float64, channels),
+		pos:     make([]int, channels),
+	}
+}
+
+// Latency returns the delay of the detector in samples.
+// out[i] of ProcessFloat64 covers the input sample Latency samples before in[i].
+func (d *Detector) Latency() int {
+	return filterLen / 2 / Oversample
+}
+
+// Reset clears the filter memory of all channels.
+func (d *Detector) Reset() {
+	for i := range d.history {
+		d.history[i] = [phaseTaps]float64{}
+		d.pos[i] = 0
+	}
+}
+
+func (d *Detector) process(channelIndex int, s float64) float64 {
+	h := &d.history[channelIndex]
+	pos := d.pos[channelIndex]
+	h[pos] = s
+	peak := 0.0
+	for p := range coefs {
+		c := &coefs[p]
+		var sum float64
+		k := pos
+		for j := 0; j < phaseTaps; j++ {
+			sum += c[j] * h[k]
+			if k--; k < 0 {
+				k = phaseTaps - 1
+			}
+		}
+		if sum < 0 {
+			sum = -sum
+		}
+		if sum > peak {
+			peak = sum
+		}
+	}
+	if pos++; pos == phaseTaps {
+		pos = 0
+	}
+	d.pos[channelIndex] = pos
+	return peak
+}
+
+// ProcessFloat64 stores the true-peak magnitude of the channel to out.
+// out must be at least as long as in.
+func (d *Detector) ProcessFloat64(channelIndex int, in []float64, out []float64) {
+	for i, s := range in {
+		out[i] = d.process(channelIndex, s)
+	}
+}
+
+// ProcessFloat32 stores the true-peak magnitude of the channel to out.
+// out must be at least as long as in.
+func (d *Detector) ProcessFloat32(channelIndex int, in []float32, out []float32) {
+	for i, s := range in {
+		out[i] = float32(d.process(channelIndex, float64(s)))
+	}
+}
+
+// PeakFloat64 returns the true-peak magnitude of in.
+// The detector state of the channel is advanced and the tail is not flushed.
+func (d *Detector) PeakFloat64(channelIndex int, in []float64) float64 {
+	var peak float64
+	for _, s := range in {
+		if v := d.process(channelIndex, s); v > peak {
+			peak = v
+		}
+	}
+	return peak
+}
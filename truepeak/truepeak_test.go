@@ -0,0 +1,36 @@
+package truepeak
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetector(t *testing.T) {
+	d := New(1)
+	in := make([]float64, 1000)
+	for i := range in {
+		// quarter of the sample rate with 45 degree phase offset,
+		// every sample peak is 0.707 but the true peak is 1.
+		in[i] = math.Sin(float64(i)*math.Pi/2 + math.Pi/4)
+	}
+	out := make([]float64, len(in))
+	d.ProcessFloat64(0, in, out)
+	var peak float64
+	for _, s := range out[100:] {
+		peak = math.Max(peak, s)
+	}
+	if math.Abs(peak-1) > 0.02 {
+		t.Error("true peak:", peak)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	d := New(2)
+	in := make([]float64, 100)
+	in[10] = 0.5
+	out := make([]float64, len(in))
+	d.ProcessFloat64(1, in, out)
+	if out[10+d.Latency()] != 0.5 {
+		t.Error("impulse:", out[10+d.Latency()])
+	}
+}
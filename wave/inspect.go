@@ -0,0 +1,370 @@
+package wave
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// Chunk describes a chunk of the file.
+type Chunk struct {
+	ID     string `json:"id"`
+	Offset int64  `json:"offset"` // offset of the chunk header from the beginning of the file
+	Size   int64  `json:"size"`   // size of the chunk body written in the header
+}
+
+// Format is the decoded fmt chunk.
+type Format struct {
+	FormatTag          WaveFormatTag `json:"formatTag"`
+	FormatName         string        `json:"formatName"`
+	Channels           int           `json:"channels"`
+	SamplesPerSec      int           `json:"samplesPerSec"`
+	AvgBytesPerSec     int           `json:"avgBytesPerSec"`
+	BlockAlign         int           `json:"blockAlign"`
+	BitsPerSample      int           `json:"bitsPerSample"`
+	ExtSize            int           `json:"extSize"`
+	ValidBitsPerSample int           `json:"validBitsPerSample,omitempty"` // WAVE_FORMAT_EXTENSIBLE only
+	ChannelMask        WFESpeaker    `json:"channelMask,omitempty"`        // WAVE_FORMAT_EXTENSIBLE only
+	Speakers           string        `json:"speakers,omitempty"`           // names of ChannelMask
+	SubFormat          string        `json:"subFormat,omitempty"`          // WAVE_FORMAT_EXTENSIBLE only
+}
+
+// ChannelStats is the level of a channel, 1 is the full scale.
+type ChannelStats struct {
+	Peak float64 `json:"peak"` // maximum absolute value
+	RMS  float64 `json:"rms"`
+	DC   float64 `json:"dc"` // average value
+}
+
+// Info is the diagnostics of a waveform audio file.
+type Info struct {
+	FileSize int64          `json:"fileSize"` // number of bytes read
+	RIFFSize int64          `json:"riffSize"` // size written in the RIFF header
+	Chunks   []Chunk        `json:"chunks"`
+	Format   *Format        `json:"format"` // nil if the fmt chunk is missing
+	Frames   int64          `json:"frames"` // number of complete frames in the data chunk
+	Duration float64        `json:"duration"`
+	Channels []ChannelStats `json:"channels"` // nil if the samples cannot be decoded
+	Problems []string       `json:"problems"` // violations of the specification
+}
+
+// OK reports whether no problems are found.
+func (info *Info) OK() bool {
+	return len(info.Problems) == 0
+}
+
+func (info *Info) problem(format string, a ...interface{}) {
+	info.Problems = append(info.Problems, fmt.Sprintf(format, a...))
+}
+
+type inspector struct {
+	r       *bufio.Reader
+	off     int64
+	info    *Info
+	wfext   *WaveFormatExtensible
+	hasData bool
+	frames  int
+}
+
+func (in *inspector) readFull(p []byte) (int, error) {
+	n, err := io.ReadFull(in.r, p)
+	in.off += int64(n)
+	return n, err
+}
+
+func (in *inspector) discard(n int64) (int64, error) {
+	n, err := io.CopyN(ioutil.Discard, in.r, n)
+	in.off += n
+	return n, err
+}
+
+func isFourCC(b []byte) bool {
+	if len(b) < 4 {
+		return false
+	}
+	for _, c := range b[:4] {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Inspect reads a waveform audio file from r until the end and returns its diagnostics.
+// Malformed files are reported in Info.Problems, and an error is returned only when
+// r is not a RIFF waveform audio file or cannot be read.
+func Inspect(r io.Reader) (*Info, error) {
+	in := &inspector{
+		r: bufio.NewReader(r),
+		info: &Info{
+			Chunks:   []Chunk{},
+			Problems: []string{},
+		},
+		frames: 4096,
+	}
+	info := in.info
+
+	var head [12]byte
+	if _, err := in.readFull(head[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errors.New("wave: invalid header")
+		}
+		return nil, err
+	}
+	if string(head[:4]) != "RIFF" || string(head[8:]) != "WAVE" {
+		return nil, errors.New("wave: invalid header")
+	}
+	info.RIFFSize = int64(binary.LittleEndian.Uint32(head[4:8]))
+
+	for {
+		var h [8]byte
+		n, err := in.readFull(h[:])
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			info.problem("truncated chunk header at offset %d", in.off-int64(n))
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isFourCC(h[:4]) {
+			info.problem("invalid chunk ID %q at offset %d", h[:4], in.off-8)
+			if _, err = in.discard(math.MaxInt64); err != nil && err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		c := Chunk{
+			ID:     string(h[:4]),
+			Offset: in.off - 8,
+			Size:   int64(binary.LittleEndian.Uint32(h[4:])),
+		}
+		info.Chunks = append(info.Chunks, c)
+
+		var rd int64
+		switch c.ID {
+		case "fmt ":
+			rd, err = in.readFmt(c)
+		case "data":
+			rd, err = in.readData(c)
+		default:
+			rd, err = in.discard(c.Size)
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if rd < c.Size {
+			info.problem("%q chunk at offset %d is truncated: %d of %d bytes", c.ID, c.Offset, rd, c.Size)
+			break
+		}
+
+		if c.Size&1 == 0 {
+			continue
+		}
+		if p, _ := in.r.Peek(5); len(p) == 0 || (isFourCC(p) && !isFourCC(p[1:])) {
+			info.problem("%q chunk at offset %d has an odd size but no pad byte", c.ID, c.Offset)
+			continue
+		}
+		if _, err = in.discard(1); err != nil {
+			return nil, err
+		}
+	}
+
+	info.FileSize = in.off
+	if info.RIFFSize+8 != info.FileSize {
+		info.problem("RIFF size is %d but the file has %d bytes after the RIFF header", info.RIFFSize, info.FileSize-8)
+	}
+	if info.Format == nil {
+		info.problem("fmt chunk not found")
+	}
+	if !in.hasData {
+		info.problem("data chunk not found")
+	}
+	if info.Format != nil && info.Format.SamplesPerSec > 0 {
+		info.Duration = float64(info.Frames) / float64(info.Format.SamplesPerSec)
+	}
+	return info, nil
+}
+
+func (in *inspector) readFmt(c Chunk) (int64, error) {
+	info := in.info
+	if in.wfext != nil {
+		info.problem("duplicate fmt chunk at offset %d", c.Offset)
+		return in.discard(c.Size)
+	}
+
+	// the known fields are in the first 40 bytes
+	body := make([]byte, 40)
+	if c.Size < int64(len(body)) {
+		body = body[:c.Size]
+	}
+	n, err := in.readFull(body)
+	if err != nil {
+		return int64(n), err
+	}
+	rd, err := in.discard(c.Size - int64(n))
+	rd += int64(n)
+	if err != nil {
+		return rd, err
+	}
+	if c.Size < 16 {
+		info.problem("fmt chunk is too small: %d bytes", c.Size)
+		return rd, nil
+	}
+
+	var wfext WaveFormatExtensible
+	wfex := &wfext.Format
+	wfex.ReadFrom(bytes.NewReader(body))
+	if c.Size >= 18 {
+		wfex.ExtSize = binary.LittleEndian.Uint16(body[16:])
+	}
+	if c.Size >= 40 {
+		wfext.Samples = binary.LittleEndian.Uint16(body[18:])
+		wfext.ChannelMask = WFESpeaker(binary.LittleEndian.Uint32(body[20:]))
+		wfext.SubFormat.ReadFrom(bytes.NewReader(body[24:]))
+	}
+	in.wfext = &wfext
+
+	f := &Format{
+		FormatTag:      wfex.FormatTag,
+		FormatName:     wfex.FormatTag.String(),
+		Channels:       int(wfex.Channels),
+		SamplesPerSec:  int(wfex.SamplesPerSec),
+		AvgBytesPerSec: int(wfex.AvgBytesPerSec),
+		BlockAlign:     int(wfex.BlockAlign),
+		BitsPerSample:  int(wfex.BitsPerSample),
+		ExtSize:        int(wfex.ExtSize),
+	}
+	info.Format = f
+
+	if f.Channels == 0 {
+		info.problem("Channels is 0")
+	}
+	if f.SamplesPerSec == 0 {
+		info.problem("SamplesPerSec is 0")
+	}
+	if c.Size > 18 && int64(18+f.ExtSize) > c.Size {
+		info.problem("cbSize is %d but the fmt chunk has only %d bytes after it", f.ExtSize, c.Size-18)
+	}
+
+	if f.FormatTag == WAVE_FORMAT_EXTENSIBLE {
+		if c.Size < 40 {
+			info.problem("fmt chunk of WAVE_FORMAT_EXTENSIBLE is too small: %d bytes", c.Size)
+			return rd, nil
+		}
+		f.ValidBitsPerSample = int(wfext.Samples)
+		f.ChannelMask = wfext.ChannelMask
+		f.Speakers = wfext.ChannelMask.String()
+		f.SubFormat = wfext.SubFormat.String()
+		if f.ExtSize < 22 {
+			info.problem("cbSize of WAVE_FORMAT_EXTENSIBLE is %d, expected 22", f.ExtSize)
+		}
+		if f.ValidBitsPerSample > f.BitsPerSample {
+			info.problem("ValidBitsPerSample %d exceeds BitsPerSample %d", f.ValidBitsPerSample, f.BitsPerSample)
+		}
+		if f.ChannelMask.Channels() > f.Channels {
+			info.problem("ChannelMask %s has more speakers than %d channels", f.Speakers, f.Channels)
+		}
+		if wfext.SampleFormatTag() == WAVE_FORMAT_UNKNOWN {
+			info.problem("unknown SubFormat %s", f.SubFormat)
+		}
+	}
+
+	switch wfext.SampleFormatTag() {
+	case WAVE_FORMAT_PCM, WAVE_FORMAT_IEEE_FLOAT:
+		if ba := f.Channels * ((f.BitsPerSample + 7) / 8); f.BlockAlign != ba {
+			info.problem("BlockAlign is %d, expected %d", f.BlockAlign, ba)
+		}
+		if abps := f.SamplesPerSec * f.BlockAlign; f.AvgBytesPerSec != abps {
+			info.problem("AvgBytesPerSec is %d, expected %d", f.AvgBytesPerSec, abps)
+		}
+	}
+	return rd, nil
+}
+
+func (in *inspector) readData(c Chunk) (int64, error) {
+	info := in.info
+	if in.hasData {
+		info.problem("duplicate data chunk at offset %d", c.Offset)
+		return in.discard(c.Size)
+	}
+	in.hasData = true
+
+	if in.wfext == nil {
+		info.problem("data chunk at offset %d precedes the fmt chunk", c.Offset)
+		return in.discard(c.Size)
+	}
+	f := info.Format
+	if f.BlockAlign == 0 {
+		return in.discard(c.Size)
+	}
+	if c.Size%int64(f.BlockAlign) != 0 {
+		info.problem("data chunk size %d is not a multiple of BlockAlign %d", c.Size, f.BlockAlign)
+	}
+
+	conv, err := in.wfext.InterleavedConverter()
+	if err != nil || f.Channels == 0 || f.BlockAlign != f.Channels*conv.SampleSize() {
+		// samples cannot be decoded, count complete frames only
+		rd, err := in.discard(c.Size)
+		info.Frames = rd / int64(f.BlockAlign)
+		return rd, err
+	}
+
+	buf := make([]byte, in.frames*f.BlockAlign)
+	p := make([][]float64, f.Channels)
+	for ch := range p {
+		p[ch] = make([]float64, in.frames)
+	}
+	sum := make([]float64, f.Channels)
+	sumSq := make([]float64, f.Channels)
+	stats := make([]ChannelStats, f.Channels)
+
+	var rd int64
+	for rd < c.Size {
+		ln := int64(len(buf))
+		if rem := c.Size - rd; rem < ln {
+			ln = rem
+		}
+		n, rerr := in.readFull(buf[:ln])
+		rd += int64(n)
+		frames := n / f.BlockAlign
+		if frames > 0 {
+			for ch := range p {
+				p[ch] = p[ch][:frames]
+			}
+			conv.ToFloat64Interleaved(buf[:frames*f.BlockAlign], p)
+			for ch, samples := range p {
+				st := &stats[ch]
+				for _, s := range samples {
+					sum[ch] += s
+					sumSq[ch] += s * s
+					if a := math.Abs(s); a > st.Peak {
+						st.Peak = a
+					}
+				}
+			}
+			info.Frames += int64(frames)
+		}
+		if rerr != nil {
+			err = rerr
+			break
+		}
+	}
+
+	if info.Frames > 0 {
+		for ch := range stats {
+			stats[ch].RMS = math.Sqrt(sumSq[ch] / float64(info.Frames))
+			stats[ch].DC = sum[ch] / float64(info.Frames)
+		}
+	}
+	info.Channels = stats
+	return rd, err
+}
@@ -0,0 +1,143 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	for _, tf := range testfiles {
+		f, err := os.Open(tf.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := Inspect(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(tf.filename, err)
+		}
+		if !info.OK() {
+			t.Error(tf.filename, "unexpected problems:", info.Problems)
+		}
+		if info.Format == nil || info.Format.Channels != int(tf.wf.Format.Channels) {
+			t.Fatal(tf.filename, "unexpected format:", info.Format)
+		}
+		if len(info.Chunks) < 2 || len(info.Channels) != info.Format.Channels {
+			t.Fatal(tf.filename, "unexpected info:", info)
+		}
+		// the test files start with 4 samples of 1, 4 of 0 and 4 of -1
+		if info.Frames < 12 || info.Duration != float64(info.Frames)/48000 {
+			t.Error(tf.filename, "unexpected frames:", info.Frames, info.Duration)
+		}
+		for ch, st := range info.Channels {
+			if math.Abs(st.Peak-1) > 0.01 || st.RMS <= 0 || st.RMS > st.Peak || math.Abs(st.DC) > st.Peak {
+				t.Errorf("%s ch%d unexpected stats: %+v", tf.filename, ch, st)
+			}
+		}
+	}
+}
+
+// riff builds a RIFF file from pairs of chunk ID and body.
+// The size of the RIFF header is correct, and odd chunks are padded.
+func riff(chunks ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("WAVE")
+	for i := 0; i < len(chunks); i += 2 {
+		b.WriteString(chunks[i])
+		binary.Write(&b, binary.LittleEndian, uint32(len(chunks[i+1])))
+		b.WriteString(chunks[i+1])
+		if len(chunks[i+1])&1 == 1 {
+			b.WriteByte(0)
+		}
+	}
+	var r bytes.Buffer
+	r.WriteString("RIFF")
+	binary.Write(&r, binary.LittleEndian, uint32(b.Len()))
+	r.Write(b.Bytes())
+	return r.Bytes()
+}
+
+func fmtChunk(wfext *WaveFormatExtensible) string {
+	var b bytes.Buffer
+	wfext.WriteTo(&b)
+	return b.String()
+}
+
+func TestInspectProblems(t *testing.T) {
+	pcm := fmtChunk(&WaveFormatExtensible{Format: WaveFormatEx{
+		FormatTag:      WAVE_FORMAT_PCM,
+		Channels:       1,
+		SamplesPerSec:  8000,
+		AvgBytesPerSec: 8000,
+		BlockAlign:     1,
+		BitsPerSample:  8,
+	}})
+	broken := fmtChunk(&WaveFormatExtensible{Format: WaveFormatEx{
+		FormatTag:      WAVE_FORMAT_PCM,
+		Channels:       2,
+		SamplesPerSec:  8000,
+		AvgBytesPerSec: 16000,
+		BlockAlign:     1,
+		BitsPerSample:  16,
+	}})
+	ext := fmtChunk(&WaveFormatExtensible{
+		Format: WaveFormatEx{
+			FormatTag:      WAVE_FORMAT_EXTENSIBLE,
+			Channels:       2,
+			SamplesPerSec:  8000,
+			AvgBytesPerSec: 8000 * 2,
+			BlockAlign:     2,
+			BitsPerSample:  8,
+			ExtSize:        22,
+		},
+		Samples:     8,
+		ChannelMask: SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER,
+		SubFormat:   GUID{Data1: 0x12345678},
+	})
+
+	valid := riff("fmt ", pcm, "LIST", "odd", "data", "\x80\xff\x00")
+	noPad := append([]byte{}, valid...)
+	noPad = append(noPad[:len(noPad)-1-12], noPad[len(noPad)-12:]...) // drop the pad of "LIST"
+	binary.LittleEndian.PutUint32(noPad[4:], uint32(len(noPad)-8))
+	truncated := riff("fmt ", pcm, "data", "\x80\xff\x00\x00")
+	binary.LittleEndian.PutUint32(truncated[len(truncated)-8:], 16)
+	riffSize := append(riff("fmt ", pcm, "data", "\x80\x80"), "junk"...)
+
+	tests := []struct {
+		name     string
+		b        []byte
+		problems []string
+	}{
+		{"valid", valid, nil},
+		{"no pad", noPad, []string{`"LIST" chunk at offset 36 has an odd size but no pad byte`}},
+		{"truncated", truncated, []string{`"data" chunk at offset 36 is truncated: 4 of 16 bytes`}},
+		{"riff size", riffSize, []string{"truncated chunk header at offset 46", "RIFF size is 38 but the file has 42 bytes"}},
+		{"fmt", riff("fmt ", broken, "data", "\x80\x80"), []string{"BlockAlign is 1, expected 4", "AvgBytesPerSec is 16000, expected 8000"}},
+		{"extensible", riff("fmt ", ext, "data", "\x80\x80"), []string{"more speakers than 2 channels", "unknown SubFormat {12345678-"}},
+		{"missing", riff("LIST", ""), []string{"fmt chunk not found", "data chunk not found"}},
+		{"order", riff("data", "\x80", "fmt ", pcm), []string{"precedes the fmt chunk"}},
+	}
+	for _, tt := range tests {
+		info, err := Inspect(bytes.NewReader(tt.b))
+		if err != nil {
+			t.Fatal(tt.name, err)
+		}
+		if len(info.Problems) != len(tt.problems) {
+			t.Errorf("%s: want %d problems got %q", tt.name, len(tt.problems), info.Problems)
+			continue
+		}
+		for i, p := range tt.problems {
+			if !strings.Contains(info.Problems[i], p) {
+				t.Errorf("%s: problems[%d] want %q got %q", tt.name, i, p, info.Problems[i])
+			}
+		}
+	}
+
+	if _, err := Inspect(strings.NewReader("RIFX\x00\x00\x00\x00WAVE")); err == nil {
+		t.Error("an error is expected for a non RIFF file")
+	}
+}
@@ -2,19 +2,14 @@
 package wave
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"github.com/oov/audio"
 	"io"
+	"io/ioutil"
 )
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 // NewLimitedReader returns an *io.LimitedReader which waveform audio data from r.
 func NewLimitedReader(r io.Reader) (*io.LimitedReader, *WaveFormatExtensible, error) {
 	var chunk [4]byte
@@ -26,7 +21,7 @@ func NewLimitedReader(r io.Reader) (*io.LimitedReader, *WaveFormatExtensible, er
 		return nil, nil, errors.New("wave: invalid header")
 	}
 
-	var ln int32
+	var ln uint32
 	if err = binary.Read(r, binary.LittleEndian, &ln); err != nil {
 		return nil, nil, err
 	}
@@ -40,75 +35,48 @@ func NewLimitedReader(r io.Reader) (*io.LimitedReader, *WaveFormatExtensible, er
 		return nil, nil, errors.New("wave: invalid header")
 	}
 
-	var n int
-	var ignored [4096]byte
-
-	// find "fmt " chunk
-
-	ln = 0
-	for string(chunk[:4]) != "fmt " {
-		n, err = io.ReadFull(lr, ignored[:min(len(ignored), int(ln))])
-		if err != nil {
-			return nil, nil, err
-		}
-		ln -= int32(n)
-		if ln != 0 {
-			continue
-		}
+	// find "fmt " and "data" chunk, other chunks are ignored
 
+	var wf *WaveFormatExtensible
+	for {
 		if _, err = io.ReadFull(lr, chunk[:]); err != nil {
 			return nil, nil, err
 		}
-		if err = binary.Read(r, binary.LittleEndian, &ln); err != nil {
-			return nil, nil, err
-		}
-	}
-
-	if ln < 16 {
-		return nil, nil, errors.New("wave: fmt chunk too small")
-	}
-
-	// read "fmt " chunk
-
-	var wf WaveFormatExtensible
-	var rd int64
-	if rd, err = wf.Format.ReadFrom(lr); err != nil {
-		return nil, nil, err
-	}
-
-	// ignore unsupported chunk data
-
-	ln -= int32(rd)
-	for ln > 0 {
-		n, err = io.ReadFull(lr, ignored[:min(len(ignored), int(ln))])
-		if err != nil {
-			return nil, nil, err
-		}
-		ln -= int32(n)
-	}
-
-	// find "data" chunk
-
-	ln = 0
-	for string(chunk[:4]) != "data" {
-		n, err = io.ReadFull(lr, ignored[:min(len(ignored), int(ln))])
-		if err != nil {
+		var sz uint32
+		if err = binary.Read(lr, binary.LittleEndian, &sz); err != nil {
 			return nil, nil, err
 		}
-		ln -= int32(n)
-		if ln != 0 {
-			continue
+		// chunks are padded to an even size
+		skip := int64(sz) + int64(sz&1)
+
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if sz < 16 {
+				return nil, nil, errors.New("wave: fmt chunk too small")
+			}
+			body := make([]byte, sz)
+			if _, err = io.ReadFull(lr, body); err != nil {
+				return nil, nil, err
+			}
+			wf = &WaveFormatExtensible{}
+			if _, err = wf.ReadFrom(bytes.NewReader(body)); err != nil {
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					err = errors.New("wave: fmt chunk too small")
+				}
+				return nil, nil, err
+			}
+			skip -= int64(sz)
+		case "data":
+			if wf == nil {
+				return nil, nil, errors.New("wave: fmt chunk not found")
+			}
+			return &io.LimitedReader{R: r, N: int64(sz)}, wf, nil
 		}
 
-		if _, err = io.ReadFull(lr, chunk[:]); err != nil {
-			return nil, nil, err
-		}
-		if err = binary.Read(r, binary.LittleEndian, &ln); err != nil {
+		if _, err = io.CopyN(ioutil.Discard, lr, skip); err != nil {
 			return nil, nil, err
 		}
 	}
-
-	return &io.LimitedReader{r, int64(ln)}, &wf, nil
 }
 
 // NewReader returns an audio.InterleavedReader which waveform audio data from r.
@@ -118,7 +86,7 @@ func NewReader(r io.Reader) (audio.InterleavedReader, *WaveFormatExtensible, err
 		return nil, nil, err
 	}
 
-	conv, err := wf.Format.InterleavedConverter()
+	conv, err := wf.InterleavedConverter()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,97 @@
+package wave
+
+import (
+	"errors"
+	"io"
+
+	"github.com/oov/audio/transcode"
+)
+
+// rewriteFrames is the number of frames which are transcoded at a time.
+const rewriteFrames = 4096
+
+// RewriteFormat reads a waveform audio file from r and writes it to w with the samples encoded in to.
+// The samples are transcoded directly on the bytes as described in the transcode package,
+// so integer formats never go through floating point. The other properties of the format,
+// such as the channel mask, are kept.
+//
+// Floating point samples are scaled by 2^(bits-1) and rounded to an integer format,
+// while Writer scales them by 2^(bits-1)-1 and truncates toward zero,
+// so the output differs from Writer; 0.5 becomes 16384 in Int16 here and 16383 with Writer.
+func RewriteFormat(w io.Writer, r io.Reader, to transcode.Encoding, opts transcode.Options) error {
+	lr, wfext, err := NewLimitedReader(r)
+	if err != nil {
+		return err
+	}
+	return RewriteFormatData(w, lr, wfext, to, opts)
+}
+
+// RewriteFormatData works as RewriteFormat for the waveform audio data from r in the format wfext,
+// as returned by NewLimitedReader.
+func RewriteFormatData(w io.Writer, r io.Reader, wfext *WaveFormatExtensible, to transcode.Encoding, opts transcode.Options) error {
+	conv, err := wfext.InterleavedConverter()
+	if err != nil {
+		return err
+	}
+	from, err := transcode.FormatOf(conv)
+	if err != nil {
+		return err
+	}
+	channels := int(wfext.Format.Channels)
+	inFrame := int(wfext.Format.BlockAlign)
+	if channels == 0 || inFrame != channels*from.SampleSize() {
+		return errors.New("wave: unsupported block alignment")
+	}
+
+	tc := transcode.New(from, transcode.Format{Encoding: to}, opts)
+	out := RewrittenFormat(wfext, to)
+	outFrame := int(out.Format.BlockAlign)
+	aw, err := NewWriter(w, out)
+	if err != nil {
+		return err
+	}
+
+	src, dst := make([]byte, rewriteFrames*inFrame), make([]byte, rewriteFrames*outFrame)
+	for {
+		n, rerr := io.ReadFull(r, src)
+		if frames := n / inFrame; frames > 0 {
+			tc.Transcode(dst[:frames*outFrame], src[:frames*inFrame])
+			if _, err = aw.Write(dst[:frames*outFrame]); err != nil {
+				return err
+			}
+		}
+		switch rerr {
+		case nil:
+			continue
+		case io.EOF:
+			return aw.Close()
+		case io.ErrUnexpectedEOF:
+			if n%inFrame == 0 {
+				return aw.Close()
+			}
+		}
+		// including an incomplete frame at the end
+		return rerr
+	}
+}
+
+// RewrittenFormat returns a copy of wfext whose samples are encoded in to.
+func RewrittenFormat(wfext *WaveFormatExtensible, to transcode.Encoding) *WaveFormatExtensible {
+	f := transcode.Format{Encoding: to}
+	tag, subFormat := WAVE_FORMAT_PCM, KSDATAFORMAT_SUBTYPE_PCM
+	if f.IsFloat() {
+		tag, subFormat = WAVE_FORMAT_IEEE_FLOAT, KSDATAFORMAT_SUBTYPE_IEEE_FLOAT
+	}
+
+	out := *wfext
+	out.Format.BitsPerSample = uint16(f.Bits())
+	out.Format.BlockAlign = out.Format.Channels * uint16(f.SampleSize())
+	out.Format.AvgBytesPerSec = out.Format.SamplesPerSec * uint32(out.Format.BlockAlign)
+	if out.Format.FormatTag == WAVE_FORMAT_EXTENSIBLE {
+		out.Samples = uint16(f.Bits())
+		out.SubFormat = subFormat
+	} else {
+		out.Format.FormatTag = tag
+	}
+	return &out
+}
@@ -0,0 +1,92 @@
+package wave
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/oov/audio/transcode"
+)
+
+func readData(t *testing.T, b []byte) ([]byte, *WaveFormatExtensible) {
+	lr, wfext, err := NewLimitedReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(lr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, wfext
+}
+
+func TestRewriteFormat(t *testing.T) {
+	in, err := ioutil.ReadFile("48kHz2ch16bit.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := readData(t, in)
+
+	for _, enc := range []transcode.Encoding{transcode.Int24, transcode.Int32, transcode.Float32, transcode.Float64} {
+		var wide, back bytes.Buffer
+		if err = RewriteFormat(&wide, bytes.NewReader(in), enc, transcode.Options{}); err != nil {
+			t.Fatal(err)
+		}
+		wideData, wfext := readData(t, wide.Bytes())
+		size := transcode.Format{Encoding: enc}.SampleSize()
+		if int(wfext.Format.BitsPerSample) != size*8 || int(wfext.Format.BlockAlign) != 2*size ||
+			wfext.Format.AvgBytesPerSec != 48000*uint32(2*size) || len(wideData) != len(data)/2*size {
+			t.Fatalf("encoding %d unexpected format %+v and %d bytes", enc, wfext.Format, len(wideData))
+		}
+		if enc >= transcode.Float32 && wfext.SampleFormatTag() != WAVE_FORMAT_IEEE_FLOAT {
+			t.Errorf("encoding %d must be floating point", enc)
+		}
+
+		// the widening is lossless
+		if err = RewriteFormat(&back, bytes.NewReader(wide.Bytes()), transcode.Int16, transcode.Options{}); err != nil {
+			t.Fatal(err)
+		}
+		if backData, _ := readData(t, back.Bytes()); string(backData) != string(data) {
+			t.Errorf("encoding %d samples differ after the round trip", enc)
+		}
+	}
+
+	// WAVE_FORMAT_EXTENSIBLE keeps the channel mask
+	ext := *wfext
+	ext.Format.FormatTag = WAVE_FORMAT_EXTENSIBLE
+	ext.Format.ExtSize = 22
+	ext.Samples = 16
+	ext.ChannelMask = SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT
+	ext.SubFormat = KSDATAFORMAT_SUBTYPE_PCM
+	out := RewrittenFormat(&ext, transcode.Float32)
+	if out.Format.FormatTag != WAVE_FORMAT_EXTENSIBLE || out.SampleFormatTag() != WAVE_FORMAT_IEEE_FLOAT ||
+		out.Samples != 32 || out.ChannelMask != ext.ChannelMask || out.Format.BlockAlign != 8 {
+		t.Errorf("unexpected format %+v", out)
+	}
+}
+
+func TestRewriteFormatTruncated(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, wfext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(golden[44:47]); err == nil {
+		t.Error("an incomplete frame must not be written")
+	}
+	if n, err := w.Write(golden[44:]); n != 12 || err != nil {
+		t.Fatalf("want 12 <nil> got %d %v", n, err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(golden) {
+		t.Errorf("want %q got %q", golden, b.Bytes())
+	}
+
+	// the data chunk which ends with an incomplete frame
+	truncated := append([]byte{}, golden[:len(golden)-1]...)
+	if err = RewriteFormat(ioutil.Discard, bytes.NewReader(truncated), transcode.Int24, transcode.Options{}); err == nil {
+		t.Error("an incomplete frame must be an error")
+	}
+}
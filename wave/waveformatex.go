@@ -3,8 +3,10 @@ package wave
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/oov/audio/converter"
 	"io"
+	"strings"
 )
 
 type GUID struct {
@@ -35,7 +37,7 @@ func (guid *GUID) ReadFrom(r io.Reader) (n int64, err error) {
 	n += 2
 
 	var rd int
-	rd, err = r.Read(guid.Data4[:])
+	rd, err = io.ReadFull(r, guid.Data4[:])
 	n += int64(rd)
 	return
 }
@@ -62,6 +64,21 @@ func (guid *GUID) WriteTo(w io.Writer) (n int64, err error) {
 	return
 }
 
+// String returns the GUID in the registry format, such as {00000001-0000-0010-8000-00AA00389B71}.
+func (guid GUID) String() string {
+	return fmt.Sprintf("{%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X}",
+		guid.Data1, guid.Data2, guid.Data3,
+		guid.Data4[0], guid.Data4[1], guid.Data4[2], guid.Data4[3],
+		guid.Data4[4], guid.Data4[5], guid.Data4[6], guid.Data4[7])
+}
+
+// The SubFormat GUIDs of WAVE_FORMAT_EXTENSIBLE.
+// Data1 of these GUIDs is the format tag of the samples.
+var (
+	KSDATAFORMAT_SUBTYPE_PCM        = GUID{0x00000001, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
+	KSDATAFORMAT_SUBTYPE_IEEE_FLOAT = GUID{0x00000003, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
+)
+
 type WaveFormatEx struct {
 	FormatTag      WaveFormatTag
 	Channels       uint16
@@ -205,8 +222,34 @@ type WaveFormatExtensible struct {
 	SubFormat   GUID
 }
 
+// SampleFormatTag returns the format tag of the samples.
+// For WAVE_FORMAT_EXTENSIBLE it is taken from SubFormat, and it is
+// WAVE_FORMAT_UNKNOWN if SubFormat is not derived from a format tag.
+func (wfext *WaveFormatExtensible) SampleFormatTag() WaveFormatTag {
+	if wfext.Format.FormatTag != WAVE_FORMAT_EXTENSIBLE {
+		return wfext.Format.FormatTag
+	}
+	g := wfext.SubFormat
+	g.Data1 = KSDATAFORMAT_SUBTYPE_PCM.Data1
+	if g != KSDATAFORMAT_SUBTYPE_PCM || wfext.SubFormat.Data1 > 0xffff {
+		return WAVE_FORMAT_UNKNOWN
+	}
+	return WaveFormatTag(wfext.SubFormat.Data1)
+}
+
+// InterleavedConverter returns the converter of the samples, which also supports WAVE_FORMAT_EXTENSIBLE.
+func (wfext *WaveFormatExtensible) InterleavedConverter() (converter.InterleavedConverter, error) {
+	wfex := wfext.Format
+	wfex.FormatTag = wfext.SampleFormatTag()
+	return wfex.InterleavedConverter()
+}
+
 func (wfext *WaveFormatExtensible) Size() int {
-	return wfext.Format.Size() + int(wfext.Format.ExtSize)
+	if wfext.Format.FormatTag != WAVE_FORMAT_EXTENSIBLE {
+		return wfext.Format.Size()
+	}
+	// cbSize and the extension
+	return wfext.Format.Size() + 2 + int(wfext.Format.ExtSize)
 }
 
 func (wfext *WaveFormatExtensible) ReadFrom(r io.Reader) (n int64, err error) {
@@ -227,11 +270,6 @@ func (wfext *WaveFormatExtensible) ReadFrom(r io.Reader) (n int64, err error) {
 		return n, errors.New("wave: unsupported wave file format")
 	}
 
-	if err = binary.Read(r, binary.LittleEndian, &wfext.Format.ExtSize); err != nil {
-		return
-	}
-	n += 2
-
 	if err = binary.Read(r, binary.LittleEndian, &wfext.Samples); err != nil {
 		return
 	}
@@ -394,6 +432,123 @@ const (
 	WAVE_FORMAT_EXTENSIBLE              = WaveFormatTag(0xFFFE) //
 )
 
+var formatTagNames = map[WaveFormatTag]string{
+	WAVE_FORMAT_UNKNOWN:                 "WAVE_FORMAT_UNKNOWN",
+	WAVE_FORMAT_PCM:                     "WAVE_FORMAT_PCM",
+	WAVE_FORMAT_MS_ADPCM:                "WAVE_FORMAT_MS_ADPCM",
+	WAVE_FORMAT_IEEE_FLOAT:              "WAVE_FORMAT_IEEE_FLOAT",
+	WAVE_FORMAT_VSELP:                   "WAVE_FORMAT_VSELP",
+	WAVE_FORMAT_IBM_CVSD:                "WAVE_FORMAT_IBM_CVSD",
+	WAVE_FORMAT_ALAW:                    "WAVE_FORMAT_ALAW",
+	WAVE_FORMAT_MULAW:                   "WAVE_FORMAT_MULAW",
+	WAVE_FORMAT_OKI_ADPCM:               "WAVE_FORMAT_OKI_ADPCM",
+	WAVE_FORMAT_IMA_ADPCM:               "WAVE_FORMAT_IMA_ADPCM",
+	WAVE_FORMAT_MEDIASPACE_ADPCM:        "WAVE_FORMAT_MEDIASPACE_ADPCM",
+	WAVE_FORMAT_SIERRA_ADPCM:            "WAVE_FORMAT_SIERRA_ADPCM",
+	WAVE_FORMAT_G723_ADPCM:              "WAVE_FORMAT_G723_ADPCM",
+	WAVE_FORMAT_DIGISTD:                 "WAVE_FORMAT_DIGISTD",
+	WAVE_FORMAT_DIGIFIX:                 "WAVE_FORMAT_DIGIFIX",
+	WAVE_FORMAT_DIALOGIC_OKI_ADPCM:      "WAVE_FORMAT_DIALOGIC_OKI_ADPCM",
+	WAVE_FORMAT_MEDIAVISION_ADPCM:       "WAVE_FORMAT_MEDIAVISION_ADPCM",
+	WAVE_FORMAT_CU_CODEC:                "WAVE_FORMAT_CU_CODEC",
+	WAVE_FORMAT_YAMAHA_ADPCM:            "WAVE_FORMAT_YAMAHA_ADPCM",
+	WAVE_FORMAT_SONARC:                  "WAVE_FORMAT_SONARC",
+	WAVE_FORMAT_DSPGROUP_TRUESPEECH:     "WAVE_FORMAT_DSPGROUP_TRUESPEECH",
+	WAVE_FORMAT_ECHOSC1:                 "WAVE_FORMAT_ECHOSC1",
+	WAVE_FORMAT_AUDIOFILE_AF36:          "WAVE_FORMAT_AUDIOFILE_AF36",
+	WAVE_FORMAT_APTX:                    "WAVE_FORMAT_APTX",
+	WAVE_FORMAT_AUDIOFILE_AF10:          "WAVE_FORMAT_AUDIOFILE_AF10",
+	WAVE_FORMAT_PROSODY_1612:            "WAVE_FORMAT_PROSODY_1612",
+	WAVE_FORMAT_LRC:                     "WAVE_FORMAT_LRC",
+	WAVE_FORMAT_DOLBY_AC2:               "WAVE_FORMAT_DOLBY_AC2",
+	WAVE_FORMAT_GSM610:                  "WAVE_FORMAT_GSM610",
+	WAVE_FORMAT_MSNAUDIO:                "WAVE_FORMAT_MSNAUDIO",
+	WAVE_FORMAT_ANTEX_ADPCME:            "WAVE_FORMAT_ANTEX_ADPCME",
+	WAVE_FORMAT_CONTROL_RES_VQLPC:       "WAVE_FORMAT_CONTROL_RES_VQLPC",
+	WAVE_FORMAT_DIGIREAL:                "WAVE_FORMAT_DIGIREAL",
+	WAVE_FORMAT_DIGIADPCM:               "WAVE_FORMAT_DIGIADPCM",
+	WAVE_FORMAT_CONTROL_RES_CR10:        "WAVE_FORMAT_CONTROL_RES_CR10",
+	WAVE_FORMAT_NMS_VBXADPCM:            "WAVE_FORMAT_NMS_VBXADPCM",
+	WAVE_FORMAT_ROLAND_RDAC:             "WAVE_FORMAT_ROLAND_RDAC",
+	WAVE_FORMAT_ECHOSC3:                 "WAVE_FORMAT_ECHOSC3",
+	WAVE_FORMAT_ROCKWELL_ADPCM:          "WAVE_FORMAT_ROCKWELL_ADPCM",
+	WAVE_FORMAT_ROCKWELL_DIGITALK:       "WAVE_FORMAT_ROCKWELL_DIGITALK",
+	WAVE_FORMAT_XEBEC:                   "WAVE_FORMAT_XEBEC",
+	WAVE_FORMAT_G721_ADPCM:              "WAVE_FORMAT_G721_ADPCM",
+	WAVE_FORMAT_G728_CELP:               "WAVE_FORMAT_G728_CELP",
+	WAVE_FORMAT_MSG723:                  "WAVE_FORMAT_MSG723",
+	WAVE_FORMAT_MPEG:                    "WAVE_FORMAT_MPEG",
+	WAVE_FORMAT_RT24:                    "WAVE_FORMAT_RT24",
+	WAVE_FORMAT_PAC:                     "WAVE_FORMAT_PAC",
+	WAVE_FORMAT_MPEGLAYER3:              "WAVE_FORMAT_MPEGLAYER3",
+	WAVE_FORMAT_LUCENT_G723:             "WAVE_FORMAT_LUCENT_G723",
+	WAVE_FORMAT_CIRRUS:                  "WAVE_FORMAT_CIRRUS",
+	WAVE_FORMAT_ESPCM:                   "WAVE_FORMAT_ESPCM",
+	WAVE_FORMAT_VOXWARE:                 "WAVE_FORMAT_VOXWARE",
+	WAVE_FORMAT_CANOPUS_ATRAC:           "WAVE_FORMAT_CANOPUS_ATRAC",
+	WAVE_FORMAT_G726_ADPCM:              "WAVE_FORMAT_G726_ADPCM",
+	WAVE_FORMAT_G722_ADPCM:              "WAVE_FORMAT_G722_ADPCM",
+	WAVE_FORMAT_DSAT:                    "WAVE_FORMAT_DSAT",
+	WAVE_FORMAT_DSAT_DISPLAY:            "WAVE_FORMAT_DSAT_DISPLAY",
+	WAVE_FORMAT_VOXWARE_BYTE_ALIGNED:    "WAVE_FORMAT_VOXWARE_BYTE_ALIGNED",
+	WAVE_FORMAT_VOXWARE_AC8:             "WAVE_FORMAT_VOXWARE_AC8",
+	WAVE_FORMAT_VOXWARE_AC10:            "WAVE_FORMAT_VOXWARE_AC10",
+	WAVE_FORMAT_VOXWARE_AC16:            "WAVE_FORMAT_VOXWARE_AC16",
+	WAVE_FORMAT_VOXWARE_AC20:            "WAVE_FORMAT_VOXWARE_AC20",
+	WAVE_FORMAT_VOXWARE_RT24:            "WAVE_FORMAT_VOXWARE_RT24",
+	WAVE_FORMAT_VOXWARE_RT29:            "WAVE_FORMAT_VOXWARE_RT29",
+	WAVE_FORMAT_VOXWARE_RT29HW:          "WAVE_FORMAT_VOXWARE_RT29HW",
+	WAVE_FORMAT_VOXWARE_VR12:            "WAVE_FORMAT_VOXWARE_VR12",
+	WAVE_FORMAT_VOXWARE_VR18:            "WAVE_FORMAT_VOXWARE_VR18",
+	WAVE_FORMAT_VOXWARE_TQ40:            "WAVE_FORMAT_VOXWARE_TQ40",
+	WAVE_FORMAT_SOFTSOUND:               "WAVE_FORMAT_SOFTSOUND",
+	WAVE_FORMAT_VOXARE_TQ60:             "WAVE_FORMAT_VOXARE_TQ60",
+	WAVE_FORMAT_MSRT24:                  "WAVE_FORMAT_MSRT24",
+	WAVE_FORMAT_G729A:                   "WAVE_FORMAT_G729A",
+	WAVE_FORMAT_MVI_MV12:                "WAVE_FORMAT_MVI_MV12",
+	WAVE_FORMAT_DF_G726:                 "WAVE_FORMAT_DF_G726",
+	WAVE_FORMAT_DF_GSM610:               "WAVE_FORMAT_DF_GSM610",
+	WAVE_FORMAT_ONLIVE:                  "WAVE_FORMAT_ONLIVE",
+	WAVE_FORMAT_SBC24:                   "WAVE_FORMAT_SBC24",
+	WAVE_FORMAT_DOLBY_AC3_SPDIF:         "WAVE_FORMAT_DOLBY_AC3_SPDIF",
+	WAVE_FORMAT_ZYXEL_ADPCM:             "WAVE_FORMAT_ZYXEL_ADPCM",
+	WAVE_FORMAT_PHILIPS_LPCBB:           "WAVE_FORMAT_PHILIPS_LPCBB",
+	WAVE_FORMAT_PACKED:                  "WAVE_FORMAT_PACKED",
+	WAVE_FORMAT_RHETOREX_ADPCM:          "WAVE_FORMAT_RHETOREX_ADPCM",
+	IBM_FORMAT_MULAW:                    "IBM_FORMAT_MULAW",
+	IBM_FORMAT_ALAW:                     "IBM_FORMAT_ALAW",
+	IBM_FORMAT_ADPCM:                    "IBM_FORMAT_ADPCM",
+	WAVE_FORMAT_VIVO_G723:               "WAVE_FORMAT_VIVO_G723",
+	WAVE_FORMAT_VIVO_SIREN:              "WAVE_FORMAT_VIVO_SIREN",
+	WAVE_FORMAT_DIGITAL_G723:            "WAVE_FORMAT_DIGITAL_G723",
+	WAVE_FORMAT_CREATIVE_ADPCM:          "WAVE_FORMAT_CREATIVE_ADPCM",
+	WAVE_FORMAT_CREATIVE_FASTSPEECH8:    "WAVE_FORMAT_CREATIVE_FASTSPEECH8",
+	WAVE_FORMAT_CREATIVE_FASTSPEECH10:   "WAVE_FORMAT_CREATIVE_FASTSPEECH10",
+	WAVE_FORMAT_QUARTERDECK:             "WAVE_FORMAT_QUARTERDECK",
+	WAVE_FORMAT_FM_TOWNS_SND:            "WAVE_FORMAT_FM_TOWNS_SND",
+	WAVE_FORMAT_BZV_DIGITAL:             "WAVE_FORMAT_BZV_DIGITAL",
+	WAVE_FORMAT_VME_VMPCM:               "WAVE_FORMAT_VME_VMPCM",
+	WAVE_FORMAT_OLIGSM:                  "WAVE_FORMAT_OLIGSM",
+	WAVE_FORMAT_OLIADPCM:                "WAVE_FORMAT_OLIADPCM",
+	WAVE_FORMAT_OLICELP:                 "WAVE_FORMAT_OLICELP",
+	WAVE_FORMAT_OLISBC:                  "WAVE_FORMAT_OLISBC",
+	WAVE_FORMAT_OLIOPR:                  "WAVE_FORMAT_OLIOPR",
+	WAVE_FORMAT_LH_CODEC:                "WAVE_FORMAT_LH_CODEC",
+	WAVE_FORMAT_NORRIS:                  "WAVE_FORMAT_NORRIS",
+	WAVE_FORMAT_SOUNDSPACE_MUSICOMPRESS: "WAVE_FORMAT_SOUNDSPACE_MUSICOMPRESS",
+	WAVE_FORMAT_DVM:                     "WAVE_FORMAT_DVM",
+	WAVE_FORMAT_INTERWAV_VSC112:         "WAVE_FORMAT_INTERWAV_VSC112",
+	WAVE_FORMAT_EXTENSIBLE:              "WAVE_FORMAT_EXTENSIBLE",
+}
+
+// String returns the name of the format tag, or its value in hexadecimal if it is unknown.
+func (tag WaveFormatTag) String() string {
+	if s, ok := formatTagNames[tag]; ok {
+		return s
+	}
+	return fmt.Sprintf("0x%04X", uint16(tag))
+}
+
 type WFESpeaker uint32
 
 const (
@@ -416,3 +571,74 @@ const (
 	SPEAKER_TOP_BACK_CENTER       = WFESpeaker(0x00010000)
 	SPEAKER_TOP_BACK_RIGHT        = WFESpeaker(0x00020000)
 )
+
+var speakerNames = [...]string{
+	"FL", "FR", "FC", "LFE", "BL", "BR", "FLC", "FRC", "BC",
+	"SL", "SR", "TC", "TFL", "TFC", "TFR", "TBL", "TBC", "TBR",
+}
+
+// String returns the short names of the speakers in the mask joined by "|", such as "FL|FR".
+// Unknown bits are represented in hexadecimal.
+func (s WFESpeaker) String() string {
+	if s == 0 {
+		return "0"
+	}
+	var names []string
+	for i, name := range speakerNames {
+		if b := WFESpeaker(1) << uint(i); s&b != 0 {
+			names = append(names, name)
+			s &^= b
+		}
+	}
+	if s != 0 {
+		names = append(names, fmt.Sprintf("0x%08X", uint32(s)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Channels returns the number of speakers in the mask.
+func (s WFESpeaker) Channels() int {
+	n := 0
+	for ; s != 0; s &= s - 1 {
+		n++
+	}
+	return n
+}
+
+// Speakers returns the speakers in the mask in the order of the channels of waveform audio data.
+func (s WFESpeaker) Speakers() []WFESpeaker {
+	r := make([]WFESpeaker, 0, s.Channels())
+	for b := WFESpeaker(1); b != 0 && b <= s; b <<= 1 {
+		if s&b != 0 {
+			r = append(r, b)
+		}
+	}
+	return r
+}
+
+// DefaultChannelMask returns the conventional channel mask for the number of channels.
+// It returns 0 if there is no convention.
+func DefaultChannelMask(channels int) WFESpeaker {
+	switch channels {
+	case 1:
+		return SPEAKER_FRONT_CENTER
+	case 2:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT
+	case 3:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER
+	case 4:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT
+	case 5:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER | SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT
+	case 6:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER | SPEAKER_LOW_FREQUENCY |
+			SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT
+	case 7:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER | SPEAKER_LOW_FREQUENCY |
+			SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT | SPEAKER_BACK_CENTER
+	case 8:
+		return SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT | SPEAKER_FRONT_CENTER | SPEAKER_LOW_FREQUENCY |
+			SPEAKER_BACK_LEFT | SPEAKER_BACK_RIGHT | SPEAKER_SIDE_LEFT | SPEAKER_SIDE_RIGHT
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package wave
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSpeakers(t *testing.T) {
+	for ch := 1; ch <= 8; ch++ {
+		mask := DefaultChannelMask(ch)
+		if mask.Channels() != ch {
+			t.Error("channels:", ch, "mask:", mask.Channels())
+		}
+		s := mask.Speakers()
+		if len(s) != ch {
+			t.Fatal("speakers:", s)
+		}
+		for i := 1; i < len(s); i++ {
+			if s[i-1] >= s[i] {
+				t.Error("speakers must be ordered:", s)
+			}
+		}
+	}
+	if s := WFESpeaker(0x80000000).Speakers(); len(s) != 1 || s[0] != 0x80000000 {
+		t.Error("unexpected speakers:", s)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{WAVE_FORMAT_PCM.String(), "WAVE_FORMAT_PCM"},
+		{WAVE_FORMAT_EXTENSIBLE.String(), "WAVE_FORMAT_EXTENSIBLE"},
+		{WaveFormatTag(0x1234).String(), "0x1234"},
+		{DefaultChannelMask(6).String(), "FL|FR|FC|LFE|BL|BR"},
+		{(SPEAKER_TOP_BACK_RIGHT | 0x80000000).String(), "TBR|0x80000000"},
+		{WFESpeaker(0).String(), "0"},
+		{KSDATAFORMAT_SUBTYPE_IEEE_FLOAT.String(), "{00000003-0000-0010-8000-00AA00389B71}"},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("tests[%d] want %q got %q", i, tt.want, tt.got)
+		}
+	}
+}
+
+func TestExtensible(t *testing.T) {
+	wf := &WaveFormatExtensible{
+		Format: WaveFormatEx{
+			FormatTag:      WAVE_FORMAT_EXTENSIBLE,
+			Channels:       2,
+			SamplesPerSec:  48000,
+			AvgBytesPerSec: 48000 * 2 * 4,
+			BlockAlign:     8,
+			BitsPerSample:  32,
+			ExtSize:        22,
+		},
+		Samples:     32,
+		ChannelMask: SPEAKER_FRONT_LEFT | SPEAKER_FRONT_RIGHT,
+		SubFormat:   KSDATAFORMAT_SUBTYPE_IEEE_FLOAT,
+	}
+	if wf.SampleFormatTag() != WAVE_FORMAT_IEEE_FLOAT {
+		t.Fatal("unexpected sample format:", wf.SampleFormatTag())
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.WriteFloat64Interleaved([][]float64{{0.25, -0.5}, {0.5, -0.25}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, got, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *wf {
+		t.Fatalf("want %+v got %+v", wf, got)
+	}
+	p := [][]float64{make([]float64, 2), make([]float64, 2)}
+	if n, err := r.ReadFloat64Interleaved(p); n != 2 || err != nil {
+		t.Fatal("n:", n, "err:", err)
+	}
+	if p[0][0] != 0.25 || p[0][1] != -0.5 || p[1][0] != 0.5 || p[1][1] != -0.25 {
+		t.Error("unexpected samples:", p)
+	}
+}
@@ -3,6 +3,7 @@ package wave
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"github.com/oov/audio"
 	"io"
 	"io/ioutil"
@@ -18,9 +19,14 @@ type Writer struct {
 	written int64
 }
 
+// NewWriter returns a Writer which writes waveform audio data to w.
+// If w is not seekable, such as a pipe, the data is buffered in a temporary file
+// or in memory and written on Close.
 func NewWriter(w io.Writer, wfext *WaveFormatExtensible) (*Writer, error) {
 	if ws, ok := w.(io.WriteSeeker); ok {
-		return newDirectWriter(ws, wfext)
+		if _, err := ws.Seek(0, os.SEEK_CUR); err == nil {
+			return newDirectWriter(ws, wfext)
+		}
 	}
 
 	if wr, err := newTempFileWriter(w, wfext); err == nil {
@@ -30,7 +36,7 @@ func NewWriter(w io.Writer, wfext *WaveFormatExtensible) (*Writer, error) {
 }
 
 func newDirectWriter(ws io.WriteSeeker, wfext *WaveFormatExtensible) (*Writer, error) {
-	conv, err := wfext.Format.InterleavedConverter()
+	conv, err := wfext.InterleavedConverter()
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +62,7 @@ func newDirectWriter(ws io.WriteSeeker, wfext *WaveFormatExtensible) (*Writer, e
 }
 
 func newTempFileWriter(w io.Writer, wfext *WaveFormatExtensible) (*Writer, error) {
-	conv, err := wfext.Format.InterleavedConverter()
+	conv, err := wfext.InterleavedConverter()
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +81,7 @@ func newTempFileWriter(w io.Writer, wfext *WaveFormatExtensible) (*Writer, error
 }
 
 func newTempMemWriter(w io.Writer, wfext *WaveFormatExtensible) (*Writer, error) {
-	conv, err := wfext.Format.InterleavedConverter()
+	conv, err := wfext.InterleavedConverter()
 	if err != nil {
 		return nil, err
 	}
@@ -101,26 +107,41 @@ func (w *Writer) WriteFloat64Interleaved(p [][]float64) (n int, err error) {
 	return
 }
 
+// Write writes the encoded frames of p as they are, p must hold complete frames.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	blockAlign := int(w.wfext.Format.BlockAlign)
+	if len(p)%blockAlign != 0 {
+		return 0, errors.New("wave: incomplete frame")
+	}
+	body := w.body
+	if body == nil {
+		body = w.w
+	}
+	n, err = body.Write(p)
+	w.written += int64(n / blockAlign)
+	return
+}
+
 func (w *Writer) Close() error {
 	var err error
 
-	ws, isWriteSeeker := w.w.(io.WriteSeeker)
-	if isWriteSeeker {
-		if _, err = ws.Seek(w.head, os.SEEK_SET); err != nil {
+	isDirect := w.body == nil
+	if isDirect {
+		if _, err = w.w.(io.WriteSeeker).Seek(w.head, os.SEEK_SET); err != nil {
 			return err
 		}
 	}
 
 	dataSize := w.written * int64(w.wfext.Format.BlockAlign)
-	// "RIFF" + sz + "WAVE" + "fmt " + sz + fmtbody + "data" + sz + databody
-	fileSize := 4 + 4 + 4 + 4 + 4 + int64(w.wfext.Size()) + 4 + 4 + dataSize
+	// "WAVE" + "fmt " + sz + fmtbody + "data" + sz + databody + pad
+	riffSize := 4 + 4 + 4 + int64(w.wfext.Size()) + 4 + 4 + dataSize + dataSize&1
 
 	_, err = w.w.Write([]byte("RIFF"))
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w.w, binary.LittleEndian, int32(fileSize))
+	err = binary.Write(w.w, binary.LittleEndian, uint32(riffSize))
 	if err != nil {
 		return err
 	}
@@ -154,14 +175,14 @@ func (w *Writer) Close() error {
 		return err
 	}
 
-	err = binary.Write(w.w, binary.LittleEndian, int32(dataSize))
+	err = binary.Write(w.w, binary.LittleEndian, uint32(dataSize))
 	if err != nil {
 		return err
 	}
 
-	if isWriteSeeker {
+	if isDirect {
 		// already written
-		return nil
+		return w.pad(dataSize)
 	}
 
 	switch t := w.body.(type) {
@@ -189,5 +210,19 @@ func (w *Writer) Close() error {
 		}
 		t.Reset()
 	}
-	return nil
+	return w.pad(dataSize)
+}
+
+// pad writes the pad byte of the odd sized data chunk.
+func (w *Writer) pad(dataSize int64) error {
+	if dataSize&1 == 0 {
+		return nil
+	}
+	if ws, ok := w.w.(io.WriteSeeker); ok && w.body == nil {
+		if _, err := ws.Seek(0, os.SEEK_END); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.Write([]byte{0})
+	return err
 }
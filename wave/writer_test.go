@@ -22,7 +22,7 @@ var samples = [][]float64{
 	[]float64{-1, 0, 1},
 	[]float64{1, 0, -1},
 }
-var golden = []byte("RIFF\x38\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x02\x00\x80\xbb\x00\x00\x00\x77\x01\x00\x04\x00\x10\x00data\x0c\x00\x00\x00\x01\x80\xff\x7f\x00\x00\x00\x00\xff\x7f\x01\x80")
+var golden = []byte("RIFF\x30\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x02\x00\x80\xbb\x00\x00\x00\x77\x01\x00\x04\x00\x10\x00data\x0c\x00\x00\x00\x01\x80\xff\x7f\x00\x00\x00\x00\xff\x7f\x01\x80")
 
 func TestDirectWriter(t *testing.T) {
 	f, err := ioutil.TempFile("", "test")
@@ -138,3 +138,77 @@ func TestTempMemWriter(t *testing.T) {
 		return
 	}
 }
+
+func TestPipeWriter(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer pr.Close()
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(pr)
+		done <- b
+	}()
+
+	w, err := NewWriter(pw, wfext)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err = w.WriteFloat64Interleaved(samples); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err = w.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+	pw.Close()
+
+	b := <-done
+	if !bytes.Equal(golden, b) {
+		t.Log("golden:", golden)
+		t.Log("invalid output:", b)
+		t.Fail()
+		return
+	}
+}
+
+func TestOddDataSize(t *testing.T) {
+	wf := &WaveFormatExtensible{
+		Format: WaveFormatEx{
+			FormatTag:      WAVE_FORMAT_PCM,
+			Channels:       1,
+			SamplesPerSec:  8000,
+			AvgBytesPerSec: 8000,
+			BlockAlign:     1,
+			BitsPerSample:  8,
+		},
+	}
+	for _, newWriter := range []func(*bytes.Buffer) (*Writer, error){
+		func(b *bytes.Buffer) (*Writer, error) { return newTempFileWriter(b, wf) },
+		func(b *bytes.Buffer) (*Writer, error) { return newTempMemWriter(b, wf) },
+	} {
+		var b bytes.Buffer
+		w, err := newWriter(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.WriteFloat64Interleaved([][]float64{{0, 0.5, -0.5}}); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// the RIFF size excludes "RIFF" and itself, and includes the pad byte
+		want := "RIFF\x28\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x40\x1f\x00\x00\x01\x00\x08\x00data\x03\x00\x00\x00\x80\xbf\x40\x00"
+		if b.String() != want {
+			t.Errorf("want %q got %q", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package window implements window functions.
+package window
+
+import (
+	"math"
+)
+
+// Func fills w with a window function.
+//
+// A symmetric window is used for filter design, a periodic window is used
+// for spectral analysis because it overlap-adds to a constant.
+type Func func(w []float64, periodic bool)
+
+// New returns a window of length n.
+func New(f Func, n int, periodic bool) []float64 {
+	w := make([]float64, n)
+	f(w, periodic)
+	return w
+}
+
+func denominator(n int, periodic bool) float64 {
+	if periodic {
+		return float64(n)
+	}
+	return float64(n - 1)
+}
+
+func cosine(w []float64, periodic bool, a ...float64) {
+	if len(w) == 1 {
+		w[0] = 1
+		return
+	}
+	d := denominator(len(w), periodic)
+	for i := range w {
+		x := 2 * math.Pi * float64(i) / d
+		var v float64
+		for k, c := range a {
+			if k%2 == 0 {
+				v += c * math.Cos(float64(k)*x)
+			} else {
+				v -= c * math.Cos(float64(k)*x)
+			}
+		}
+		w[i] = v
+	}
+}
+
+// Rectangular fills w with 1.
+func Rectangular(w []float64, periodic bool) {
+	for i := range w {
+		w[i] = 1
+	}
+}
+
+// Hann fills w with the Hann window.
+func Hann(w []float64, periodic bool) {
+	cosine(w, periodic, 0.5, 0.5)
+}
+
+// Hamming fills w with the Hamming window.
+func Hamming(w []float64, periodic bool) {
+	cosine(w, periodic, 0.54, 0.46)
+}
+
+// Blackman fills w with the Blackman window.
+func Blackman(w []float64, periodic bool) {
+	cosine(w, periodic, 0.42, 0.5, 0.08)
+}
+
+// Kaiser returns the Kaiser window with the shape parameter beta.
+func Kaiser(beta float64) Func {
+	return func(w []float64, periodic bool) {
+		if len(w) == 1 {
+			w[0] = 1
+			return
+		}
+		d := denominator(len(w), periodic)
+		i0 := BesselI0(beta)
+		for i := range w {
+			r := 2*float64(i)/d - 1
+			w[i] = BesselI0(beta*math.Sqrt(math.Max(0, 1-r*r))) / i0
+		}
+	}
+}
+
+// KaiserBeta returns the shape parameter of the Kaiser window
+// that achieves the stopband attenuation in dB.
+func KaiserBeta(attenuation float64) float64 {
+	switch {
+	case attenuation > 50:
+		return 0.1102 * (attenuation - 8.7)
+	case attenuation >= 21:
+		return 0.5842*math.Pow(attenuation-21, 0.4) + 0.07886*(attenuation-21)
+	}
+	return 0
+}
+
+// BesselI0 returns the zeroth order modified Bessel function of the first kind.
+func BesselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-16; k++ {
+		t := x / (2 * float64(k))
+		term *= t * t
+		sum += term
+	}
+	return sum
+}
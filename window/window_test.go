@@ -0,0 +1,54 @@
+package window
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSymmetric(t *testing.T) {
+	for name, f := range map[string]Func{"hann": Hann, "hamming": Hamming, "blackman": Blackman, "kaiser": Kaiser(8)} {
+		w := New(f, 65, false)
+		if math.Abs(w[32]-1) > 1e-12 {
+			t.Error(name, "center:", w[32])
+		}
+		for i := range w {
+			if math.Abs(w[i]-w[len(w)-1-i]) > 1e-12 {
+				t.Fatal(name, "is not symmetric at", i)
+			}
+		}
+	}
+}
+
+func TestCOLA(t *testing.T) {
+	// periodic windows overlap-add to a constant with these hop sizes
+	tests := []struct {
+		name string
+		f    Func
+		hop  int
+	}{
+		{"hann", Hann, 256},
+		{"hann", Hann, 128},
+		{"hamming", Hamming, 256},
+		{"blackman", Blackman, 128},
+	}
+	for _, test := range tests {
+		w := New(test.f, 512, true)
+		sum := make([]float64, test.hop)
+		for i, v := range w {
+			sum[i%test.hop] += v
+		}
+		for _, v := range sum {
+			if math.Abs(v-sum[0]) > 1e-9 {
+				t.Fatal(test.name, test.hop, "is not COLA:", sum)
+			}
+		}
+	}
+}
+
+func TestBesselI0(t *testing.T) {
+	for _, v := range [][2]float64{{0, 1}, {1, 1.2660658777520082}, {5, 27.239871823604442}} {
+		if math.Abs(BesselI0(v[0])-v[1]) > 1e-12*v[1] {
+			t.Error(v[0], BesselI0(v[0]))
+		}
+	}
+}